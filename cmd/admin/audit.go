@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/service"
+	"github.com/Edbeer/Project/internal/storage/psql"
+	"github.com/Edbeer/Project/pkg/database/postgres"
+	"github.com/Edbeer/Project/pkg/logger"
+)
+
+var errBrokenChain = errors.New("audit log chain is broken")
+
+func runAudit(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing audit subcommand")
+	}
+
+	switch args[0] {
+	case "verify":
+		flags := flag.NewFlagSet("audit verify", flag.ExitOnError)
+		headSeq := flags.Int64("head-seq", 0, "sequence number of a previously printed head, detects removal of newer events")
+		headHash := flags.String("head-hash", "", "hash of the previously printed head")
+		flags.Parse(args[1:])
+
+		return verifyAudit(cfg, *headSeq, *headHash)
+	default:
+		return fmt.Errorf("unknown audit subcommand %q", args[0])
+	}
+}
+
+// Walk the audit log hash chain, prints every problem and the head to keep
+// outside of the database for the next run
+func verifyAudit(cfg *config.Config, headSeq int64, headHash string) error {
+	db, err := postgres.NewPsqlDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	apiLogger := logger.NewApiLogger(cfg)
+	apiLogger.InitLogger()
+
+	ctx := context.Background()
+	storage := psql.NewStorage(db)
+	result, err := service.NewAuditService(storage.Audit, apiLogger).Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range result.Problems {
+		fmt.Printf("event %d: %s\n", problem.Seq, problem.Problem)
+	}
+	problems := len(result.Problems)
+
+	if headSeq > 0 {
+		events, err := storage.Audit.ScanEvents(ctx, headSeq-1, 1)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 || events[0].Seq != headSeq || events[0].Hash != headHash {
+			fmt.Printf("event %d: previously printed head is missing or changed\n", headSeq)
+			problems++
+		}
+	}
+
+	fmt.Printf("checked %d events, head %d %s\n", result.Checked, result.HeadSeq, result.HeadHash)
+	if problems > 0 {
+		return fmt.Errorf("%w: %d problems", errBrokenChain, problems)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/service"
+	"github.com/Edbeer/Project/internal/storage/psql"
+	"github.com/Edbeer/Project/pkg/database/postgres"
+)
+
+func runClients(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing clients subcommand")
+	}
+
+	flags := flag.NewFlagSet("clients "+args[0], flag.ExitOnError)
+	id := flags.String("id", "", "client id")
+
+	var run func(ctx context.Context, clients *service.OAuthService) error
+	switch args[0] {
+	case "list":
+		flags.Parse(args[1:])
+		run = listClients
+	case "create":
+		name := flags.String("name", "", "client name shown on the consent page")
+		redirectURIs := flags.String("redirect-uris", "", "comma separated redirect uris")
+		scopes := flags.String("scopes", "", "space delimited scopes the client may request")
+		public := flags.Bool("public", false, "client without secret, like a single page or native app")
+		flags.Parse(args[1:])
+
+		run = func(ctx context.Context, clients *service.OAuthService) error {
+			client, secret, err := clients.CreateClient(ctx, &entity.OAuthClient{
+				ID:           *id,
+				Name:         *name,
+				RedirectURIs: splitList(*redirectURIs),
+				Scopes:       strings.Fields(*scopes),
+			}, *public)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("client_id: %s\n", client.ID)
+			if secret != "" {
+				fmt.Printf("client_secret: %s\n", secret)
+				fmt.Println("the secret is not stored, save it now")
+			}
+			return nil
+		}
+	case "delete":
+		flags.Parse(args[1:])
+		if *id == "" {
+			return errors.New("missing -id")
+		}
+
+		run = func(ctx context.Context, clients *service.OAuthService) error {
+			return clients.DeleteClient(ctx, *id)
+		}
+	default:
+		return fmt.Errorf("unknown clients subcommand %q", args[0])
+	}
+
+	db, err := postgres.NewPsqlDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return run(context.Background(), service.NewOAuthClientService(psql.NewStorage(db).OAuth))
+}
+
+func listClients(ctx context.Context, clients *service.OAuthService) error {
+	list, err := clients.ListClients(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT_ID\tNAME\tTYPE\tSCOPES\tREDIRECT_URIS")
+	for _, client := range list {
+		kind := "confidential"
+		if client.IsPublic() {
+			kind = "public"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", client.ID, client.Name, kind,
+			strings.Join(client.Scopes, " "), strings.Join(client.RedirectURIs, ","))
+	}
+	return w.Flush()
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/service"
+	"github.com/Edbeer/Project/internal/storage/psql"
+	"github.com/Edbeer/Project/pkg/database/postgres"
+)
+
+var errImportFailed = errors.New("some users were not imported")
+
+// Failure of the input itself, no record can be read after it
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string { return "read input: " + e.err.Error() }
+
+func (e *readError) Unwrap() error { return e.err }
+
+// CSV columns, header row is required
+var importColumns = []string{"email", "name", "algorithm", "hash", "salt", "iterations", "verified"}
+
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "CSV or JSONL file of users")
+	format := flags.String("format", "", "csv or jsonl, taken from the file extension by default")
+	flags.Parse(args)
+
+	if *file == "" {
+		return errors.New("missing -file")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	input, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var next func() (*entity.ImportRecord, error)
+	switch *format {
+	case "csv":
+		next, err = csvRecords(input)
+		if err != nil {
+			return err
+		}
+	case "jsonl":
+		next = jsonlRecords(input)
+	default:
+		return fmt.Errorf("unknown import format %q", *format)
+	}
+
+	return importUsers(cfg, next)
+}
+
+// Import every record, broken records are reported and skipped,
+// import stops on failure of the input
+func importUsers(cfg *config.Config, next func() (*entity.ImportRecord, error)) error {
+	db, err := postgres.NewPsqlDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	importService := service.NewImportService(psql.NewStorage(db).User)
+
+	var imported, skipped, failed int
+	for line := 1; ; line++ {
+		record, err := next()
+		if err == io.EOF {
+			break
+		}
+		var readErr *readError
+		if errors.As(err, &readErr) {
+			fmt.Printf("imported %d, skipped %d, failed %d\n", imported, skipped, failed)
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+		if err != nil {
+			fmt.Printf("record %d: %v\n", line, err)
+			failed++
+			continue
+		}
+
+		ok, err := importService.ImportUser(ctx, record)
+		switch {
+		case err != nil:
+			fmt.Printf("record %d (%s): %v\n", line, record.Email, err)
+			failed++
+		case !ok:
+			fmt.Printf("record %d (%s): email already exists, skipped\n", line, record.Email)
+			skipped++
+		default:
+			imported++
+		}
+	}
+
+	fmt.Printf("imported %d, skipped %d, failed %d\n", imported, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%w: %d failed", errImportFailed, failed)
+	}
+	return nil
+}
+
+// Records of CSV with header, columns may come in any order
+func csvRecords(input io.Reader) (func() (*entity.ImportRecord, error), error) {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"email", "algorithm", "hash"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header has no %q column, expected %s", name, strings.Join(importColumns, ","))
+		}
+	}
+
+	return func() (*entity.ImportRecord, error) {
+		row, err := reader.Read()
+		var parseErr *csv.ParseError
+		switch {
+		case err == io.EOF || errors.As(err, &parseErr):
+			// reader goes on with the next row after a malformed one
+			return nil, err
+		case err != nil:
+			return nil, &readError{err: err}
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		record := &entity.ImportRecord{
+			Email:     value("email"),
+			Name:      value("name"),
+			Algorithm: value("algorithm"),
+			Hash:      value("hash"),
+			Salt:      value("salt"),
+		}
+		if iterations := value("iterations"); iterations != "" {
+			if record.Iterations, err = strconv.Atoi(iterations); err != nil {
+				return nil, fmt.Errorf("invalid iterations %q", iterations)
+			}
+		}
+		if verified := value("verified"); verified != "" {
+			if record.Verified, err = strconv.ParseBool(verified); err != nil {
+				return nil, fmt.Errorf("invalid verified %q", verified)
+			}
+		}
+		return record, nil
+	}, nil
+}
+
+// Records of JSON lines, blank lines are ignored
+func jsonlRecords(input io.Reader) func() (*entity.ImportRecord, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return func() (*entity.ImportRecord, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			record := &entity.ImportRecord{}
+			if err := json.Unmarshal([]byte(line), record); err != nil {
+				return nil, err
+			}
+			return record, nil
+		}
+		// scanner stops for good on a too long line or a failed read
+		if err := scanner.Err(); err != nil {
+			return nil, &readError{err: err}
+		}
+		return nil, io.EOF
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/pkg/jwt"
+)
+
+var errNoKeyRing = errors.New("jwt.KeyRingFile is not configured")
+
+func runKeys(cfg *config.Config, args []string) error {
+	if cfg.JWT.KeyRingFile == "" {
+		return errNoKeyRing
+	}
+	if len(args) == 0 {
+		return errors.New("missing keys subcommand")
+	}
+
+	ring, err := loadKeyRing(cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	flags := flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	kid := flags.String("kid", "", "key id")
+	grace := flags.Duration("grace", defaultGrace(cfg), "how long previous signing keys keep verifying")
+
+	switch args[0] {
+	case "list":
+		return listKeys(ring, now)
+	case "generate":
+		alg := flags.String("alg", jwt.AlgES256, "signing algorithm: HS256, RS256, ES256 or EdDSA")
+		promote := flags.Bool("promote", false, "make the key the signing key now")
+		activateAt := flags.String("activate-at", "", "schedule promotion, RFC 3339 time")
+		flags.Parse(args[1:])
+
+		key, err := jwt.GenerateKey(*kid, *alg)
+		if err != nil {
+			return err
+		}
+		if err := ring.Add(key); err != nil {
+			return err
+		}
+		switch {
+		case *promote:
+			err = ring.Promote(key.ID, now, *grace)
+		case *activateAt != "":
+			var at time.Time
+			if at, err = time.Parse(time.RFC3339, *activateAt); err == nil {
+				err = ring.Promote(key.ID, at, *grace)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(key.ID)
+	case "promote":
+		at := flags.String("at", "", "promotion time, RFC 3339, defaults to now")
+		flags.Parse(args[1:])
+
+		promoteAt, err := parseTime(*at, now)
+		if err != nil {
+			return err
+		}
+		if err := ring.Promote(*kid, promoteAt, *grace); err != nil {
+			return err
+		}
+	case "retire":
+		at := flags.String("at", "", "retirement time, RFC 3339, defaults to now")
+		flags.Parse(args[1:])
+
+		retireAt, err := parseTime(*at, now)
+		if err != nil {
+			return err
+		}
+		if err := ring.Retire(*kid, retireAt); err != nil {
+			return err
+		}
+	case "prune":
+		ring.Prune(now)
+	default:
+		return fmt.Errorf("unknown keys subcommand %q", args[0])
+	}
+
+	if _, err := ring.Signing(now); err != nil {
+		return err
+	}
+	return ring.Save(cfg.JWT.KeyRingFile)
+}
+
+// Load key ring, a missing file starts a ring with the configured single key
+// so tokens issued before the migration stay valid
+func loadKeyRing(cfg *config.Config) (*jwt.KeyRing, error) {
+	ring, err := jwt.LoadKeyRing(cfg.JWT.KeyRingFile)
+	if err == nil {
+		return ring, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key, err := jwt.ConfigKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key.ActivatesAt = time.Now()
+	return &jwt.KeyRing{Keys: []*jwt.Key{key}}, nil
+}
+
+// Grace covers the longest lived token signed by the manager
+func defaultGrace(cfg *config.Config) time.Duration {
+	grace := jwt.AccessTokenTTL
+	for _, seconds := range []int{cfg.Verification.Expire, cfg.PasswordReset.Expire, cfg.MFA.ChallengeExpire} {
+		if d := time.Duration(seconds) * time.Second; d > grace {
+			grace = d
+		}
+	}
+	return grace
+}
+
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func listKeys(ring *jwt.KeyRing, now time.Time) error {
+	signing, _ := ring.Signing(now)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tSTATE\tACTIVATES\tRETIRES")
+	for _, key := range ring.Keys {
+		state := "verify"
+		switch {
+		case key == signing:
+			state = "active"
+		case key.Retired(now):
+			state = "retired"
+		case key.ActivatesAt.IsZero() || key.ActivatesAt.After(now):
+			state = "pending"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Alg(), state, formatTime(key.ActivatesAt), formatTime(key.RetiresAt))
+	}
+	return w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Edbeer/Project/config"
+)
+
+const usage = `Usage: admin <command> [arguments]
+
+Commands:
+  keys list                                    show signing key ring
+  keys generate -alg ES256 [-kid id] [-promote | -activate-at time] [-grace duration]
+                                               add a new key, verify-only until promoted
+  keys promote -kid id [-at time] [-grace duration]
+                                               make key the signing key
+  keys retire -kid id [-at time]               stop accepting tokens signed by key
+  keys prune                                   drop retired keys
+  audit verify [-head-seq n -head-hash hash]   check audit log hash chain, prints the head
+                                               to pass on the next run
+  import -file users.csv [-format csv|jsonl]   import users with password hashes of another
+                                               system, columns: email,name,algorithm,hash,
+                                               salt,iterations,verified; algorithm is sha1,
+                                               pbkdf2-sha1, pbkdf2-sha256, pbkdf2-sha512,
+                                               bcrypt or argon2id
+  clients list                                 show oauth clients
+  clients create -name app -redirect-uris uri[,uri] [-scopes "a b"] [-public] [-id id]
+                                               register oauth client, prints its secret once
+  clients delete -id id                        remove oauth client
+  service-accounts list                        show service accounts
+  service-accounts create -name job -owner email [-scopes "a b"] [-id id]
+                                               add service account for the client credentials
+                                               grant, prints its secret once
+  service-accounts delete -id id               remove service account
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// init config
+	config := config.GetConfig()
+
+	var err error
+	switch os.Args[1] {
+	case "keys":
+		err = runKeys(config, os.Args[2:])
+	case "audit":
+		err = runAudit(config, os.Args[2:])
+	case "import":
+		err = runImport(config, os.Args[2:])
+	case "clients":
+		err = runClients(config, os.Args[2:])
+	case "service-accounts":
+		err = runServiceAccounts(config, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
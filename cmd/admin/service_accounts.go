@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/service"
+	"github.com/Edbeer/Project/internal/storage/psql"
+	"github.com/Edbeer/Project/pkg/database/postgres"
+)
+
+func runServiceAccounts(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing service-accounts subcommand")
+	}
+
+	flags := flag.NewFlagSet("service-accounts "+args[0], flag.ExitOnError)
+	id := flags.String("id", "", "client id")
+
+	var run func(ctx context.Context, storage *psql.Storage, accounts *service.ServiceAccountService) error
+	switch args[0] {
+	case "list":
+		flags.Parse(args[1:])
+		run = listServiceAccounts
+	case "create":
+		name := flags.String("name", "", "service account name")
+		owner := flags.String("owner", "", "email of the owning user")
+		scopes := flags.String("scopes", "", "space delimited permissions the account may use")
+		flags.Parse(args[1:])
+		if *owner == "" {
+			return errors.New("missing -owner")
+		}
+
+		run = func(ctx context.Context, storage *psql.Storage, accounts *service.ServiceAccountService) error {
+			user, err := storage.User.FindUserByEmail(ctx, &entity.User{Email: strings.ToLower(*owner)})
+			if err != nil {
+				return fmt.Errorf("owner %s: %w", *owner, err)
+			}
+
+			account, secret, err := accounts.CreateServiceAccount(ctx, &entity.ServiceAccount{
+				ClientID: *id,
+				Name:     *name,
+				OwnerID:  user.ID,
+				Scopes:   strings.Fields(*scopes),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("client_id: %s\n", account.ClientID)
+			fmt.Printf("client_secret: %s\n", secret)
+			fmt.Println("the secret is not stored, save it now")
+			return nil
+		}
+	case "delete":
+		flags.Parse(args[1:])
+		if *id == "" {
+			return errors.New("missing -id")
+		}
+
+		run = func(ctx context.Context, _ *psql.Storage, accounts *service.ServiceAccountService) error {
+			return accounts.DeleteServiceAccount(ctx, *id)
+		}
+	default:
+		return fmt.Errorf("unknown service-accounts subcommand %q", args[0])
+	}
+
+	db, err := postgres.NewPsqlDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	storage := psql.NewStorage(db)
+	return run(context.Background(), storage, service.NewServiceAccountService(cfg, storage.Service, storage.User, nil))
+}
+
+func listServiceAccounts(ctx context.Context, storage *psql.Storage, accounts *service.ServiceAccountService) error {
+	list, err := accounts.ListServiceAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT_ID\tNAME\tOWNER\tSCOPES")
+	for _, account := range list {
+		owner := account.OwnerID.String()
+		if user, err := storage.User.GetUserByID(ctx, account.OwnerID); err == nil {
+			owner = user.Email
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", account.ClientID, account.Name, owner, strings.Join(account.Scopes, " "))
+	}
+	return w.Flush()
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"strings"
 	"sync"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -9,13 +10,26 @@ import (
 
 // Config
 type Config struct {
-	Server   Server   `yaml:"server"`
-	Postgres Postgres `yaml:"postgres"`
-	Redis    Redis    `yaml:"redis"`
-	Session  Session  `yaml:"session"`
-	Cookie   Cookie   `yaml:"cookie"`
-	Logger   Logger   `yaml:"logger"`
-	Jaeger   Jaeger   `yaml:"jaeger"`
+	Server         Server         `yaml:"server"`
+	Postgres       Postgres       `yaml:"postgres"`
+	Redis          Redis          `yaml:"redis"`
+	Session        Session        `yaml:"session"`
+	Cookie         Cookie         `yaml:"cookie"`
+	Logger         Logger         `yaml:"logger"`
+	Jaeger         Jaeger         `yaml:"jaeger"`
+	Mail           Mail           `yaml:"mail"`
+	Verification   Verification   `yaml:"verification"`
+	PasswordReset  PasswordReset  `yaml:"passwordReset"`
+	EmailChange    EmailChange    `yaml:"emailChange"`
+	Deletion       Deletion       `yaml:"deletion"`
+	BruteForce     BruteForce     `yaml:"bruteForce"`
+	RateLimit      RateLimit      `yaml:"rateLimit"`
+	PasswordHash   PasswordHash   `yaml:"passwordHash"`
+	PasswordPolicy PasswordPolicy `yaml:"passwordPolicy"`
+	MFA            MFA            `yaml:"mfa"`
+	WebAuthn       WebAuthn       `yaml:"webauthn"`
+	JWT            JWT            `yaml:"jwt"`
+	OAuth          OAuth          `yaml:"oauth"`
 }
 
 // Server config struct
@@ -26,6 +40,8 @@ type Server struct {
 	ReadTimeout  int    `yaml:"ReadTimeout"`
 	WriteTimeout int    `yaml:"WriteTimeout"`
 	SSL          bool   `yaml:"SSL"`
+	// CIDR ranges of proxies whose X-Forwarded-For is trusted, empty uses the peer address
+	TrustedProxies []string `yaml:"TrustedProxies"`
 }
 
 // Postgresql config
@@ -54,9 +70,10 @@ type Redis struct {
 
 // Session Config
 type Session struct {
-	Prefix string `yaml:"Prefix"`
-	Name   string `yaml:"Name"`
-	Expire int    `yaml:"Expire"`
+	Prefix      string `yaml:"Prefix"`
+	Name        string `yaml:"Name"`
+	Expire      int    `yaml:"Expire"`
+	TokenSecret string `yaml:"TokenSecret"`
 }
 
 // Cookie config
@@ -83,6 +100,142 @@ type Jaeger struct {
 	LogSpans    bool
 }
 
+// Mail config
+type Mail struct {
+	Host     string `yaml:"Host"`
+	Port     string `yaml:"Port"`
+	Username string `yaml:"Username"`
+	Password string `yaml:"Password"`
+	From     string `yaml:"From"`
+}
+
+// Email verification config
+type Verification struct {
+	Required bool   `yaml:"Required"`
+	Expire   int    `yaml:"Expire"`
+	URL      string `yaml:"URL"`
+}
+
+// Password reset config
+type PasswordReset struct {
+	Expire int    `yaml:"Expire"`
+	URL    string `yaml:"URL"`
+}
+
+// Email change config
+type EmailChange struct {
+	Expire int    `yaml:"Expire"`
+	URL    string `yaml:"URL"`
+}
+
+// Account deletion config, deleted accounts can be restored
+// during GracePeriod seconds, purger runs every PurgeInterval seconds
+type Deletion struct {
+	GracePeriod   int    `yaml:"GracePeriod"`
+	PurgeInterval int    `yaml:"PurgeInterval"`
+	RestoreURL    string `yaml:"RestoreURL"`
+}
+
+// Sign-in brute-force protection config in seconds, failures are counted
+// per email and per IP during Window, each email failure delays the next attempt
+// for BaseDelay doubled up to MaxDelay, reaching MaxEmailFailures locks the account
+// and MaxIPFailures blocks the IP for Lockout, zero Window disables protection
+type BruteForce struct {
+	Window           int `yaml:"Window"`
+	BaseDelay        int `yaml:"BaseDelay"`
+	MaxDelay         int `yaml:"MaxDelay"`
+	Lockout          int `yaml:"Lockout"`
+	MaxEmailFailures int `yaml:"MaxEmailFailures"`
+	MaxIPFailures    int `yaml:"MaxIPFailures"`
+}
+
+// Rate limit config, routes without own policy share Default quota,
+// FailOpen lets requests through when redis is unavailable
+type RateLimit struct {
+	Enabled  bool              `yaml:"Enabled"`
+	FailOpen bool              `yaml:"FailOpen"`
+	Default  RateLimitPolicy   `yaml:"Default"`
+	Routes   []RateLimitPolicy `yaml:"Routes"`
+}
+
+// Rate limit policy of Method and echo route Path, empty Method matches any.
+// Key is ip, user or route, Limit requests are allowed per Period seconds
+// with Burst requests at once, Burst defaults to Limit
+type RateLimitPolicy struct {
+	Method string `yaml:"Method"`
+	Path   string `yaml:"Path"`
+	Key    string `yaml:"Key"`
+	Limit  int    `yaml:"Limit"`
+	Period int    `yaml:"Period"`
+	Burst  int    `yaml:"Burst"`
+}
+
+// Password hashing config, Algorithm is argon2id or bcrypt, stored hashes
+// of another algorithm or with weaker parameters are re-hashed on sign-in.
+// Argon2Memory is in KiB, zero parameters fall back to defaults
+type PasswordHash struct {
+	Algorithm     string `yaml:"Algorithm"`
+	BcryptCost    int    `yaml:"BcryptCost"`
+	Argon2Memory  uint32 `yaml:"Argon2Memory"`
+	Argon2Time    uint32 `yaml:"Argon2Time"`
+	Argon2Threads uint8  `yaml:"Argon2Threads"`
+	Argon2KeyLen  uint32 `yaml:"Argon2KeyLen"`
+	Argon2SaltLen uint32 `yaml:"Argon2SaltLen"`
+}
+
+// Password policy config applied at sign-up, password change and reset,
+// zero values disable the rule. Disallowed passwords are compared case-insensitively,
+// BreachedDir holds SHA-1 range files of breached passwords named by 5 hex prefix
+type PasswordPolicy struct {
+	MinLength      int      `yaml:"MinLength"`
+	MaxLength      int      `yaml:"MaxLength"`
+	RequireLower   bool     `yaml:"RequireLower"`
+	RequireUpper   bool     `yaml:"RequireUpper"`
+	RequireDigit   bool     `yaml:"RequireDigit"`
+	RequireSymbol  bool     `yaml:"RequireSymbol"`
+	Disallowed     []string `yaml:"Disallowed"`
+	DisallowedFile string   `yaml:"DisallowedFile"`
+	BreachedDir    string   `yaml:"BreachedDir"`
+}
+
+// Two-factor authentication config
+type MFA struct {
+	Issuer          string `yaml:"Issuer"`
+	ChallengeExpire int    `yaml:"ChallengeExpire"`
+	BackupCodes     int    `yaml:"BackupCodes"`
+}
+
+// WebAuthn relying party config
+type WebAuthn struct {
+	RPID             string   `yaml:"RPID"`
+	RPName           string   `yaml:"RPName"`
+	Origins          []string `yaml:"Origins"`
+	UserVerification string   `yaml:"UserVerification"`
+	Timeout          int      `yaml:"Timeout"`
+}
+
+// JWT signing key config, HS256 uses Server.JwtSecretKey,
+// RS256, ES256 and EdDSA load PKCS#1, SEC 1 or PKCS#8 PEM private key.
+// KeyRingFile takes precedence over a single key and is reloaded every ReloadInterval seconds
+type JWT struct {
+	Algorithm      string `yaml:"Algorithm"`
+	KeyID          string `yaml:"KeyID"`
+	PrivateKeyFile string `yaml:"PrivateKeyFile"`
+	KeyRingFile    string `yaml:"KeyRingFile"`
+	ReloadInterval int    `yaml:"ReloadInterval"`
+}
+
+// OAuth 2.0 authorization server config, expirations are in seconds.
+// Users without a session are sent to LoginURL with the authorize url in return_to.
+// Issuer is loaded without trailing slash, tokens and discovery use it as is
+type OAuth struct {
+	Issuer        string `yaml:"Issuer"`
+	LoginURL      string `yaml:"LoginURL"`
+	CodeExpire    int    `yaml:"CodeExpire"`
+	ConsentExpire int    `yaml:"ConsentExpire"`
+	RefreshExpire int    `yaml:"RefreshExpire"`
+}
+
 var (
 	config *Config
 	once   sync.Once
@@ -98,6 +251,8 @@ func GetConfig() *Config {
 			log.Println(help)
 			log.Fatal(err)
 		}
+		// iss of the tokens must match the discovery issuer exactly
+		config.OAuth.Issuer = strings.TrimRight(config.OAuth.Issuer, "/")
 	})
 	return config
 }
@@ -0,0 +1,121 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit event types
+const (
+	AuditSignUp             = "sign_up"
+	AuditSignIn             = "sign_in"
+	AuditSignInMFA          = "sign_in_mfa"
+	AuditTokenRefresh       = "token_refresh"
+	AuditSignOut            = "sign_out"
+	AuditEmailVerify        = "email_verify"
+	AuditPasswordReset      = "password_reset"
+	AuditPasswordChange     = "password_change"
+	AuditEmailChange        = "email_change"
+	AuditMFAEnable          = "mfa_enable"
+	AuditMFADisable         = "mfa_disable"
+	AuditAccountDelete      = "account_delete"
+	AuditAccountRestore     = "account_restore"
+	AuditAccountPurge       = "account_purge"
+	AuditAccountLock        = "account_lock"
+	AuditOAuthConsent       = "oauth_consent"
+	AuditTokenRevoke        = "token_revoke"
+	AuditAdminUserUpdate    = "admin_user_update"
+	AuditAdminPasswordReset = "admin_password_reset"
+	AuditAdminUserSuspend   = "admin_user_suspend"
+	AuditAdminUserUnsuspend = "admin_user_unsuspend"
+	AuditAdminUserDelete    = "admin_user_delete"
+	AuditAdminUserUnlock    = "admin_user_unlock"
+)
+
+// Audit event outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Previous hash of the first audit log entry
+const AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Security audit log entry, every entry is chained to the previous one
+// by its hash so edited, removed or reordered entries are detected
+type AuditEvent struct {
+	Seq       int64      `json:"seq" db:"seq"`
+	Event     string     `json:"event" db:"event"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	IP        string     `json:"ip" db:"ip"`
+	UserAgent string     `json:"user_agent" db:"user_agent"`
+	RequestID string     `json:"request_id" db:"request_id"`
+	Outcome   string     `json:"outcome" db:"outcome"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	PrevHash  string     `json:"prev_hash" db:"prev_hash"`
+	Hash      string     `json:"hash" db:"hash"`
+}
+
+// SHA-256 of the entry fields and the previous hash, hex encoded
+func (e *AuditEvent) Digest() string {
+	userID := ""
+	if e.UserID != nil {
+		userID = e.UserID.String()
+	}
+	// fixed field order, postgres keeps microseconds
+	b, _ := json.Marshal([]interface{}{
+		e.Seq,
+		e.PrevHash,
+		e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		e.Event,
+		userID,
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.Outcome,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Admin filter of audit log, empty fields do not filter
+type AuditFilter struct {
+	UserID *uuid.UUID
+	Event  string
+	From   *time.Time
+	To     *time.Time
+	Page   int
+	Size   int
+}
+
+// Page of audit log, newest first
+type AuditList struct {
+	Events []*AuditEvent `json:"events"`
+	Total  int           `json:"total"`
+	Page   int           `json:"page"`
+	Size   int           `json:"size"`
+}
+
+// Audit log chain problem
+type AuditProblem struct {
+	Seq     int64  `json:"seq"`
+	Problem string `json:"problem"`
+}
+
+// Result of audit log chain verification, HeadSeq and HeadHash
+// should be kept elsewhere to detect removal of the newest entries
+type AuditVerification struct {
+	Checked  int64           `json:"checked"`
+	HeadSeq  int64           `json:"head_seq"`
+	HeadHash string          `json:"head_hash"`
+	Problems []*AuditProblem `json:"problems"`
+}
+
+// Check that no problems were found
+func (v *AuditVerification) Valid() bool {
+	return len(v.Problems) == 0
+}
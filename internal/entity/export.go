@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Archive of everything stored about the user
+type UserExport struct {
+	ExportedAt  time.Time             `json:"exported_at"`
+	Profile     *User                 `json:"profile"`
+	TOTP        *TOTP                 `json:"totp,omitempty"`
+	Passkeys    []*WebAuthnCredential `json:"passkeys"`
+	Sessions    []*SessionInfo        `json:"sessions"`
+	AuditEvents []*AuditEvent         `json:"audit_events"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+// User exported from another system, Hash is produced by Algorithm,
+// Salt and Iterations are used by pbkdf2 only
+type ImportRecord struct {
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	Algorithm  string `json:"algorithm"`
+	Hash       string `json:"hash"`
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Verified   bool   `json:"verified"`
+}
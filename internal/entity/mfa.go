@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User TOTP secret
+type TOTP struct {
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret      string     `json:"-" db:"secret"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	LastStep    int64      `json:"-" db:"last_step"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Check that TOTP enrollment is confirmed
+func (t *TOTP) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TOTP enrollment, shown to the user once
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Short-lived challenge returned by sign-in when MFA is enabled
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	Challenge   string `json:"challenge"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Backup codes, shown to the user once
+type BackupCodes struct {
+	Codes []string `json:"backup_codes"`
+}
@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuth client signing users in with the authorization code flow,
+// public clients have no secret and rely on PKCE only
+type OAuthClient struct {
+	ID           string    `json:"client_id" db:"client_id"`
+	SecretHash   *string   `json:"-" db:"secret_hash"`
+	Name         string    `json:"name" db:"name"`
+	RedirectURIs SpaceList `json:"redirect_uris" db:"redirect_uris"`
+	Scopes       SpaceList `json:"scopes" db:"scopes"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Check that client has no secret
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == nil
+}
+
+// List stored as space delimited text
+type SpaceList []string
+
+// Scan implements sql.Scanner
+func (l *SpaceList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = SpaceList{}
+	case string:
+		*l = strings.Fields(v)
+	case []byte:
+		*l = strings.Fields(string(v))
+	default:
+		return errors.New("unsupported space list type")
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (l SpaceList) Value() (driver.Value, error) {
+	return strings.Join(l, " "), nil
+}
+
+// Authorization endpoint request, query params on the way to the consent
+// page and form fields when the consent is submitted
+type AuthorizationRequest struct {
+	ResponseType        string `query:"response_type" form:"response_type"`
+	ClientID            string `query:"client_id" form:"client_id"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri"`
+	Scope               string `query:"scope" form:"scope"`
+	State               string `query:"state" form:"state"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method"`
+	Nonce               string `query:"nonce" form:"nonce"`
+}
+
+// Consent page of a valid authorization request, the token is single-use
+// and binds the submitted consent to the signed in user
+type OAuthConsent struct {
+	Token   string
+	Client  *OAuthClient
+	Scopes  []string
+	Request *AuthorizationRequest
+}
+
+// Access granted by the user to the client, kept behind
+// authorization codes and refresh tokens
+type OAuthGrant struct {
+	ClientID            string    `json:"client_id"`
+	UserID              uuid.UUID `json:"user_id"`
+	Scopes              []string  `json:"scopes"`
+	RedirectURI         string    `json:"redirect_uri,omitempty"`
+	CodeChallenge       string    `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string    `json:"code_challenge_method,omitempty"`
+	Nonce               string    `json:"nonce,omitempty"`
+	AuthTime            time.Time `json:"auth_time"`
+	ExpiresAt           time.Time `json:"-"`
+}
+
+// Token endpoint request, client credentials come from the form
+// or from basic authorization header
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// Token endpoint response
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// Introspection and revocation request, client credentials
+// come from the form or from basic authorization header
+type TokenHintRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// Token introspection response of RFC 7662, inactive tokens have no other
+// members. Token type is access_token or refresh_token
+type Introspection struct {
+	Active        bool   `json:"active"`
+	Scope         string `json:"scope,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	Username      string `json:"username,omitempty"`
+	TokenType     string `json:"token_type,omitempty"`
+	ExpiresAt     int64  `json:"exp,omitempty"`
+	IssuedAt      int64  `json:"iat,omitempty"`
+	Subject       string `json:"sub,omitempty"`
+	Issuer        string `json:"iss,omitempty"`
+	TokenID       string `json:"jti,omitempty"`
+	PrincipalType string `json:"principal_type,omitempty"`
+	IssuedAtMs    int64  `json:"-"`
+}
+
+// Access token of the introspection, issue time is in milliseconds
+// when the token has it
+func (i *Introspection) AccessToken() *AccessToken {
+	issuedAt := time.Unix(i.IssuedAt, 0)
+	if i.IssuedAtMs != 0 {
+		issuedAt = time.UnixMilli(i.IssuedAtMs)
+	}
+	return &AccessToken{
+		ID:        i.TokenID,
+		Subject:   i.Subject,
+		IssuedAt:  issuedAt,
+		ExpiresAt: time.Unix(i.ExpiresAt, 0),
+	}
+}
+
+// OpenID Connect scopes
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OpenID Connect claims of the user, profile and email
+// claims are released only with their scopes
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// User claims released by the scopes
+func NewUserInfo(user *User, scopes []string) *UserInfo {
+	info := &UserInfo{Subject: user.ID.String()}
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeProfile:
+			info.Name = user.Name
+		case ScopeEmail:
+			verified := user.IsVerified()
+			info.Email = user.Email
+			info.EmailVerified = &verified
+		}
+	}
+	return info
+}
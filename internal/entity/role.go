@@ -0,0 +1,29 @@
+package entity
+
+// Seeded roles
+const (
+	RoleAdmin = "admin"
+)
+
+// Seeded permissions
+const (
+	PermissionUsersRead  = "users:read"
+	PermissionUsersWrite = "users:write"
+	PermissionAuditRead  = "audit:read"
+)
+
+// Roles of the user and permissions granted by them
+type Access struct {
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Check that permission is granted
+func (a *Access) HasPermission(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of authenticated principals
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// Authenticated caller of the api, c.Get("user") holds
+// *User for users and *ServiceAccount for service accounts
+type Principal interface {
+	PrincipalType() string
+	HasPermission(permission string) bool
+}
+
+// Principal type of the user
+func (u *User) PrincipalType() string {
+	return PrincipalUser
+}
+
+// Non-human principal of backend jobs, signs in with the client
+// credentials grant. Its scopes are the permissions it may use
+type ServiceAccount struct {
+	ClientID   string    `json:"client_id" db:"client_id"`
+	SecretHash string    `json:"-" db:"secret_hash"`
+	Name       string    `json:"name" db:"name"`
+	OwnerID    uuid.UUID `json:"owner_id" db:"owner_id"`
+	Scopes     SpaceList `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// Principal type of the service account
+func (a *ServiceAccount) PrincipalType() string {
+	return PrincipalService
+}
+
+// Check that the scopes grant the permission
+func (a *ServiceAccount) HasPermission(permission string) bool {
+	for _, scope := range a.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,28 @@
 package entity
 
 import (
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Session struct {
-	RefreshToken string  `json:"refresh_token" redis:"refresh_token"`
-	UserID    uuid.UUID `json:"user_id" redis:"user_id"`
+	RefreshToken string     `json:"refresh_token" redis:"refresh_token"`
+	UserID       uuid.UUID  `json:"user_id" redis:"user_id"`
+	FamilyID     uuid.UUID  `json:"family_id" redis:"family_id"`
+	IP           string     `json:"ip,omitempty" redis:"ip"`
+	UserAgent    string     `json:"user_agent,omitempty" redis:"user_agent"`
+	AuthTime     *time.Time `json:"auth_time,omitempty" redis:"auth_time"`
+	ExpiresAt    time.Time  `json:"-" redis:"-"`
+}
+
+// Active session of the user, its id is the refresh token family
+// and stays the same across token rotation
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
 }
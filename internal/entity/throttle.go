@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// Failed sign-in throttling state of an email or IP
+type LoginThrottle struct {
+	Failures   int
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// Failed sign-in limits, every failure within Window delays the next attempt
+// for BaseDelay doubled per failure up to MaxDelay, MaxFailures failures lock for Lockout
+type ThrottlePolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Lockout     time.Duration
+}
+
+// Request rate limit, Burst requests can be made at once,
+// then Limit requests per Period
+type RateLimitPolicy struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Rate limit decision of a request
+type RateLimit struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	// time until the quota is fully restored
+	Reset time.Duration
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token actions
+const (
+	ActionVerifyEmail    = "verify_email"
+	ActionResetPassword  = "password_reset"
+	ActionChangeEmail    = "email_change"
+	ActionRestoreAccount = "account_restore"
+	ActionMFAChallenge   = "mfa_challenge"
+	ActionWebAuthnReg    = "webauthn_register"
+	ActionWebAuthnLogin  = "webauthn_login"
+	ActionOAuthConsent   = "oauth_consent"
+)
+
+// Signed single-use token mailed to the user
+type ActionToken struct {
+	ID        string
+	UserID    uuid.UUID
+	Email     string
+	Action    string
+	ExpiresAt time.Time
+}
+
+// Access token checked against revocations, subject is the user
+// id or the client id of a service account
+type AccessToken struct {
+	ID        string
+	Subject   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
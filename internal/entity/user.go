@@ -5,16 +5,43 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// Password hasher interface
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+}
+
 // User model
 type User struct {
-	ID         uuid.UUID `json:"user_id" db:"user_id" validate:"omitempty,uuid"`
-	Name       string    `json:"name" db:"name" validate:"required_with,lte=30"`
-	Email      string    `json:"email" db:"email" validate:"omitempty,email"`
-	Password   string    `json:"password,omitempty" db:"password" validate:"required,gte=6"`
-	Created_at time.Time `json:"created_at" db:"created_at"`
+	ID          uuid.UUID  `json:"user_id" db:"user_id" validate:"omitempty,uuid"`
+	Name        string     `json:"name" db:"name" validate:"required_with,lte=30"`
+	Email       string     `json:"email" db:"email" validate:"omitempty,email"`
+	Password    string     `json:"password,omitempty" db:"password" validate:"required,gte=6"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty" db:"suspended_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	Created_at  time.Time  `json:"created_at" db:"created_at"`
+	Access      `db:"-"`
+}
+
+// Admin filter of users listing, empty fields do not filter
+type UserFilter struct {
+	Email         string
+	Name          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Page          int
+	Size          int
+}
+
+// Page of users
+type UserList struct {
+	Users []*User `json:"users"`
+	Total int     `json:"total"`
+	Page  int     `json:"page"`
+	Size  int     `json:"size"`
 }
 
 // User with token
@@ -23,34 +50,47 @@ type UserWithToken struct {
 	AccessToken string `json:"access_token"`
 }
 
-// Compare user password and payload
-func (u *User) ComparePassword(password string) error {
-	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
-		return err
-	}
-	return nil
+// Compare user password and payload, rehash reports that
+// the stored hash should be upgraded
+func (u *User) ComparePassword(hasher PasswordHasher, password string) (bool, error) {
+	return hasher.Verify(u.Password, password)
 }
 
-func (u *User) HashPassword() error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+func (u *User) HashPassword(hasher PasswordHasher) error {
+	hashedPassword, err := hasher.Hash(u.Password)
 	if err != nil {
 		return err
 	}
 
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
 // Prepare user struct for register
-func (u *User) PrepareCreate() error {
+func (u *User) PrepareCreate(hasher PasswordHasher) error {
 	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
 	u.Password = strings.TrimSpace(u.Password)
-	if err := u.HashPassword(); err != nil {
+	if err := u.HashPassword(hasher); err != nil {
 		return err
 	}
 	return nil
 }
 
+// Check that user email is verified
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
+// Check that user is suspended by admin
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// Check that user deleted the account, it is purged after the grace period
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
 // Sanitize password
 func (u *User) SanitizePasswor() {
 	u.Password = ""
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthn credential (passkey)
+type WebAuthnCredential struct {
+	ID         []byte     `json:"id" db:"credential_id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	PublicKey  []byte     `json:"-" db:"public_key"`
+	SignCount  int64      `json:"-" db:"sign_count"`
+	AAGUID     []byte     `json:"-" db:"aaguid"`
+	Name       string     `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
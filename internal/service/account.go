@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Soft delete account checking the password, all sessions are revoked
+// and the account can be restored by the link from the letter until it is purged
+func (u *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID, password string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.DeleteAccount")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAccountDelete, userID, err) }()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := foundUser.ComparePassword(u.hasher, password); err != nil {
+		return httpe.NewBadRequestError(httpe.WrongPassword)
+	}
+
+	if err := u.psql.SetDeleted(ctx, userID, true); err != nil {
+		return err
+	}
+	if err := u.sessions.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	grace := u.config.Deletion.GracePeriod
+	expiresAt := time.Now().Add(time.Second * time.Duration(grace))
+	actionToken := &entity.ActionToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Email:     foundUser.Email,
+		Action:    entity.ActionRestoreAccount,
+		ExpiresAt: expiresAt,
+	}
+
+	token, err := u.tokenManager.GenerateActionToken(actionToken)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.CreateToken(ctx, entity.ActionRestoreAccount, actionToken.ID, userID, grace); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, &mail.Message{
+		To:      foundUser.Email,
+		Subject: "Your account was deleted",
+		Body: fmt.Sprintf("Your account and all its data will be erased on %s. Follow the link to restore it before then: %s?token=%s",
+			expiresAt.UTC().Format(time.RFC1123), u.config.Deletion.RestoreURL, token),
+	})
+}
+
+// Restore soft deleted account by token from deletion letter
+func (u *UserService) RestoreAccount(ctx context.Context, token string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.RestoreAccount")
+	defer span.Finish()
+
+	actionToken, err := u.tokenManager.ParseActionToken(token, entity.ActionRestoreAccount)
+	if err != nil {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+
+	userID, err := u.tokens.ConsumeToken(ctx, entity.ActionRestoreAccount, actionToken.ID)
+	if err != nil || userID != actionToken.UserID {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+	defer func() { u.audit.Record(ctx, entity.AuditAccountRestore, userID, err) }()
+
+	return u.psql.SetDeleted(ctx, userID, false)
+}
+
+// Erase accounts deleted longer than the grace period ago with their sessions,
+// returns number of purged accounts
+func (u *UserService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.PurgeDeletedAccounts")
+	defer span.Finish()
+
+	before := time.Now().Add(-time.Second * time.Duration(u.config.Deletion.GracePeriod))
+	userIDs, err := u.psql.PurgeDeletedUsers(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		u.audit.Record(ctx, entity.AuditAccountPurge, userID, nil)
+		if err := u.sessions.DeleteUserSessions(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+	return len(userIDs), nil
+}
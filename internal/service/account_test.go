@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DeleteAccount(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		Deletion: config.Deletion{
+			GracePeriod: 3600,
+			RestoreURL:  "http://localhost/restore-account",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		ID:       uuid.New(),
+		Email:    "edbeermtn@gmail.com",
+		Password: "12345678",
+	}
+	require.NoError(t, user.HashPassword(testHasher))
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+
+		err := userService.DeleteAccount(context.Background(), user.ID, "wrong password")
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		var tokenID string
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().SetDeleted(gomock.Any(), user.ID, true).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), user.ID).Return(nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionRestoreAccount, gomock.Any(), user.ID, 3600).
+			DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+				tokenID = id
+				return nil
+			})
+
+		err := userService.DeleteAccount(context.Background(), user.ID, "12345678")
+		require.NoError(t, err)
+
+		letter := outbox.Last(user.Email)
+		require.NotNil(t, letter)
+		link, err := url.Parse(letter.Body[strings.Index(letter.Body, "http"):])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionRestoreAccount, tokenID).Return(user.ID, nil)
+		mockUserStorage.EXPECT().SetDeleted(gomock.Any(), user.ID, false).Return(nil)
+
+		err = userService.RestoreAccount(context.Background(), token)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeletedCannotSignIn", func(t *testing.T) {
+		deletedAt := time.Now()
+		deletedUser := &entity.User{ID: user.ID, Email: user.Email, DeletedAt: &deletedAt}
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(deletedUser, nil)
+
+		_, err := userService.GetUserByID(context.Background(), user.ID)
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		purgedIDs := []uuid.UUID{uuid.New(), uuid.New()}
+		mockUserStorage.EXPECT().PurgeDeletedUsers(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, before time.Time) ([]uuid.UUID, error) {
+				require.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Minute)
+				return purgedIDs, nil
+			})
+		for _, id := range purgedIDs {
+			mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), id).Return(nil)
+		}
+
+		purged, err := userService.PurgeDeletedAccounts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, purged)
+	})
+}
+
+func TestService_ExportUser(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockWebAuthnStorage := mockstorage.NewMockWebAuthnPsql(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockAuditStorage := mockstorage.NewMockAuditPsql(ctrl)
+	exportService := newExportService(mockUserStorage, mockWebAuthnStorage, mockSessionStorage, mockAuditStorage)
+
+	user := &entity.User{
+		ID:       uuid.New(),
+		Email:    "edbeermtn@gmail.com",
+		Password: "hash",
+	}
+
+	mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+	mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), user.ID).Return(&entity.Access{Roles: []string{entity.RoleAdmin}}, nil)
+	mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(nil, sql.ErrNoRows)
+	mockWebAuthnStorage.EXPECT().GetUserCredentials(gomock.Any(), user.ID).
+		Return([]*entity.WebAuthnCredential{{ID: []byte("credential"), Name: "laptop"}}, nil)
+	mockSessionStorage.EXPECT().GetUserSessions(gomock.Any(), user.ID).
+		Return([]*entity.SessionInfo{{ID: uuid.New(), IP: "127.0.0.1"}}, nil)
+	mockAuditStorage.EXPECT().GetUserEvents(gomock.Any(), user.ID).
+		Return([]*entity.AuditEvent{{Seq: 1, Event: entity.AuditSignUp, UserID: &user.ID}}, nil)
+
+	export, err := exportService.ExportUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Empty(t, export.Profile.Password)
+	require.Equal(t, []string{entity.RoleAdmin}, export.Profile.Roles)
+	require.Nil(t, export.TOTP)
+	require.Len(t, export.Passkeys, 1)
+	require.Len(t, export.Sessions, 1)
+	require.Len(t, export.AuditEvents, 1)
+}
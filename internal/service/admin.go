@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Users listing page size
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// List users page by filter
+func (u *UserService) ListUsers(ctx context.Context, filter *entity.UserFilter) (*entity.UserList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ListUsers")
+	defer span.Finish()
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Size < 1 {
+		filter.Size = defaultPageSize
+	}
+	if filter.Size > maxPageSize {
+		filter.Size = maxPageSize
+	}
+
+	users, total, err := u.psql.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.UserList{
+		Users: users,
+		Total: total,
+		Page:  filter.Page,
+		Size:  filter.Size,
+	}, nil
+}
+
+// Get user with roles and permissions
+func (u *UserService) GetUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.GetUser")
+	defer span.Finish()
+
+	user, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := u.psql.GetUserAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Access = *access
+	user.SanitizePasswor()
+
+	return user, nil
+}
+
+// Update user name and email, empty fields are kept
+func (u *UserService) UpdateUser(ctx context.Context, user *entity.User) (_ *entity.User, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.UpdateUser")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminUserUpdate, user.ID, err) }()
+
+	foundUser, err := u.psql.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Name = strings.TrimSpace(user.Name)
+	if user.Name == "" {
+		user.Name = foundUser.Name
+	}
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	if user.Email == "" {
+		user.Email = foundUser.Email
+	}
+
+	if user.Email != foundUser.Email {
+		existsUser, err := u.psql.FindUserByEmail(ctx, user)
+		if err == nil && existsUser.ID != user.ID {
+			return nil, httpe.NewBadRequestError(httpe.ExistsEmailError)
+		}
+	}
+
+	return u.psql.UpdateUser(ctx, user)
+}
+
+// Invalidate user password, revoke all sessions and access tokens and send password reset letter
+func (u *UserService) ForcePasswordReset(ctx context.Context, userID uuid.UUID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ForcePasswordReset")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminPasswordReset, userID, err) }()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// nobody knows the new password, only the reset link lets the user in
+	secret, err := token.Random(32)
+	if err != nil {
+		return err
+	}
+	user := &entity.User{Password: hex.EncodeToString(secret)}
+	if err := user.HashPassword(u.hasher); err != nil {
+		return err
+	}
+	if err := u.psql.UpdatePassword(ctx, userID, user.Password); err != nil {
+		return err
+	}
+	if err := revokeUserTokens(ctx, u.revocations, userID); err != nil {
+		return err
+	}
+
+	if err := u.sessions.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	return u.sendPasswordReset(ctx, foundUser)
+}
+
+// Suspend user and revoke all sessions and access tokens, tokens
+// issued before the suspension stay invalid once it is lifted
+func (u *UserService) SuspendUser(ctx context.Context, userID uuid.UUID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.SuspendUser")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminUserSuspend, userID, err) }()
+
+	if err := u.psql.SetSuspended(ctx, userID, true); err != nil {
+		return err
+	}
+	if err := revokeUserTokens(ctx, u.revocations, userID); err != nil {
+		return err
+	}
+
+	return u.sessions.DeleteUserSessions(ctx, userID)
+}
+
+// Lift user suspension
+func (u *UserService) UnsuspendUser(ctx context.Context, userID uuid.UUID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.UnsuspendUser")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminUserUnsuspend, userID, err) }()
+
+	return u.psql.SetSuspended(ctx, userID, false)
+}
+
+// Lift sign-in lockout and backoff of user email
+func (u *UserService) UnlockUser(ctx context.Context, userID uuid.UUID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.UnlockUser")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminUserUnlock, userID, err) }()
+
+	user, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return u.throttle.ResetThrottle(ctx, emailThrottleKey(user.Email))
+}
+
+// Delete user permanently and revoke all sessions
+func (u *UserService) DeleteUser(ctx context.Context, userID uuid.UUID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.DeleteUser")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditAdminUserDelete, userID, err) }()
+
+	if err := u.psql.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return u.sessions.DeleteUserSessions(ctx, userID)
+}
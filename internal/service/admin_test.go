@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Admin(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		PasswordReset: config.PasswordReset{
+			Expire: 60,
+			URL:    "http://localhost/reset-password",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		ID:       uuid.New(),
+		Name:     "PavelV",
+		Email:    "edbeermtn@gmail.com",
+		Password: "hash",
+	}
+
+	t.Run("ListUsersPageSize", func(t *testing.T) {
+		mockUserStorage.EXPECT().ListUsers(gomock.Any(), &entity.UserFilter{Page: 1, Size: maxPageSize}).
+			Return([]*entity.User{user}, 1, nil)
+
+		list, err := userService.ListUsers(context.Background(), &entity.UserFilter{Page: -1, Size: 1000})
+		require.NoError(t, err)
+		require.Equal(t, 1, list.Total)
+		require.Equal(t, 1, list.Page)
+		require.Equal(t, maxPageSize, list.Size)
+	})
+
+	t.Run("GetUser", func(t *testing.T) {
+		found := *user
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&found, nil)
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), user.ID).
+			Return(&entity.Access{Roles: []string{entity.RoleAdmin}}, nil)
+
+		u, err := userService.GetUser(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, "", u.Password)
+		require.Equal(t, []string{entity.RoleAdmin}, u.Roles)
+	})
+
+	t.Run("UpdateUserEmailTaken", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Any()).
+			Return(&entity.User{ID: uuid.New(), Email: "taken@gmail.com"}, nil)
+
+		_, err := userService.UpdateUser(context.Background(), &entity.User{ID: user.ID, Email: " Taken@gmail.com"})
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("UpdateUserName", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().UpdateUser(gomock.Any(), &entity.User{ID: user.ID, Name: "Pavel", Email: user.Email}).
+			Return(&entity.User{ID: user.ID, Name: "Pavel", Email: user.Email}, nil)
+
+		updated, err := userService.UpdateUser(context.Background(), &entity.User{ID: user.ID, Name: "Pavel"})
+		require.NoError(t, err)
+		require.Equal(t, "Pavel", updated.Name)
+	})
+
+	t.Run("ForcePasswordReset", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Not(user.Password)).Return(nil)
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), user.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), user.ID).Return(nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionResetPassword, gomock.Any(), user.ID, 60).Return(nil)
+
+		err := userService.ForcePasswordReset(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, outbox.Last(user.Email))
+	})
+
+	t.Run("SuspendUser", func(t *testing.T) {
+		mockUserStorage.EXPECT().SetSuspended(gomock.Any(), user.ID, true).Return(nil)
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), user.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), user.ID).Return(nil)
+
+		err := userService.SuspendUser(context.Background(), user.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("SuspendedUserGetsNoToken", func(t *testing.T) {
+		suspendedAt := time.Now()
+		suspended := *user
+		suspended.SuspendedAt = &suspendedAt
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&suspended, nil)
+
+		_, err := userService.GetUserByID(context.Background(), user.ID)
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		mockUserStorage.EXPECT().DeleteUser(gomock.Any(), user.ID).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), user.ID).Return(nil)
+
+		err := userService.DeleteUser(context.Background(), user.ID)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Audit log psql storage interface
+type AuditPsql interface {
+	AppendEvent(ctx context.Context, event *entity.AuditEvent) error
+	ListEvents(ctx context.Context, filter *entity.AuditFilter) ([]*entity.AuditEvent, int, error)
+	GetUserEvents(ctx context.Context, userID uuid.UUID) ([]*entity.AuditEvent, error)
+	ScanEvents(ctx context.Context, afterSeq int64, limit int) ([]*entity.AuditEvent, error)
+}
+
+// Security audit log writer
+type Auditor interface {
+	Record(ctx context.Context, event string, userID uuid.UUID, err error)
+}
+
+// Events read at once by chain verification
+const auditVerifyBatch = 1000
+
+// Security audit log service
+type AuditService struct {
+	psql   AuditPsql
+	logger logger.Logger
+}
+
+// New audit service constructor
+func NewAuditService(psql AuditPsql, logger logger.Logger) *AuditService {
+	return &AuditService{
+		psql:   psql,
+		logger: logger,
+	}
+}
+
+// Record event of the user with client and request id from context, outcome is failure when err is not nil.
+// Audit log failures do not fail the audited operation, they are logged
+func (a *AuditService) Record(ctx context.Context, event string, userID uuid.UUID, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditService.Record")
+	defer span.Finish()
+
+	client := utils.GetClientFromCtx(ctx)
+	auditEvent := &entity.AuditEvent{
+		Event:     event,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		RequestID: utils.GetRequestIDFromCtx(ctx),
+		Outcome:   entity.AuditSuccess,
+	}
+	if userID != uuid.Nil {
+		auditEvent.UserID = &userID
+	}
+	if err != nil {
+		auditEvent.Outcome = entity.AuditFailure
+	}
+
+	if err := a.psql.AppendEvent(ctx, auditEvent); err != nil {
+		a.logger.Errorf("audit log: append %s event of user %s: %v", event, userID, err)
+	}
+}
+
+// List audit log page by filter
+func (a *AuditService) ListEvents(ctx context.Context, filter *entity.AuditFilter) (*entity.AuditList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditService.ListEvents")
+	defer span.Finish()
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Size < 1 {
+		filter.Size = defaultPageSize
+	}
+	if filter.Size > maxPageSize {
+		filter.Size = maxPageSize
+	}
+
+	events, total, err := a.psql.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.AuditList{
+		Events: events,
+		Total:  total,
+		Page:   filter.Page,
+		Size:   filter.Size,
+	}, nil
+}
+
+// Walk the whole audit log checking sequence numbers and hash chain,
+// reports missing, edited and replaced events
+func (a *AuditService) Verify(ctx context.Context) (*entity.AuditVerification, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditService.Verify")
+	defer span.Finish()
+
+	result := &entity.AuditVerification{Problems: []*entity.AuditProblem{}}
+	prev := &entity.AuditEvent{Hash: entity.AuditGenesisHash}
+	for {
+		events, err := a.psql.ScanEvents(ctx, prev.Seq, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			result.Checked++
+			switch {
+			case event.Seq != prev.Seq+1:
+				result.Problems = append(result.Problems, &entity.AuditProblem{
+					Seq:     event.Seq,
+					Problem: fmt.Sprintf("events %d to %d are missing", prev.Seq+1, event.Seq-1),
+				})
+			case event.PrevHash != prev.Hash:
+				result.Problems = append(result.Problems, &entity.AuditProblem{
+					Seq:     event.Seq,
+					Problem: fmt.Sprintf("previous hash does not match event %d, it was edited or replaced", prev.Seq),
+				})
+			}
+			if event.Hash != event.Digest() {
+				result.Problems = append(result.Problems, &entity.AuditProblem{
+					Seq:     event.Seq,
+					Problem: "hash does not match content, event was edited",
+				})
+			}
+			prev = event
+		}
+
+		if len(events) < auditVerifyBatch {
+			break
+		}
+	}
+
+	result.HeadSeq = prev.Seq
+	result.HeadHash = prev.Hash
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Auditor keeping recorded events in memory
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []*entity.AuditEvent
+}
+
+func (r *auditRecorder) Record(ctx context.Context, event string, userID uuid.UUID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outcome := entity.AuditSuccess
+	if err != nil {
+		outcome = entity.AuditFailure
+	}
+	r.events = append(r.events, &entity.AuditEvent{Event: event, UserID: &userID, Outcome: outcome})
+}
+
+// Last recorded event of the type, nil when there is none
+func (r *auditRecorder) Last(event string) *entity.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].Event == event {
+			return r.events[i]
+		}
+	}
+	return nil
+}
+
+// Chain of events as the storage appends them
+func newAuditChain(n int) []*entity.AuditEvent {
+	events := make([]*entity.AuditEvent, 0, n)
+	prevHash := entity.AuditGenesisHash
+	for i := 1; i <= n; i++ {
+		userID := uuid.New()
+		event := &entity.AuditEvent{
+			Seq:       int64(i),
+			Event:     entity.AuditSignIn,
+			UserID:    &userID,
+			IP:        "127.0.0.1",
+			Outcome:   entity.AuditSuccess,
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			PrevHash:  prevHash,
+		}
+		event.Hash = event.Digest()
+		prevHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestService_AuditRecord(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apiLogger := logger.NewApiLogger(&config.Config{})
+	apiLogger.InitLogger()
+
+	mockAuditStorage := mockstorage.NewMockAuditPsql(ctrl)
+	auditService := NewAuditService(mockAuditStorage, apiLogger)
+
+	ctx := context.WithValue(context.Background(), utils.ReqIDCtxKey{}, "request id")
+	ctx = context.WithValue(ctx, utils.ClientCtxKey{}, utils.Client{IP: "127.0.0.1", UserAgent: "Firefox"})
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mockAuditStorage.EXPECT().AppendEvent(gomock.Any(), &entity.AuditEvent{
+			Event:     entity.AuditSignIn,
+			UserID:    &userID,
+			IP:        "127.0.0.1",
+			UserAgent: "Firefox",
+			RequestID: "request id",
+			Outcome:   entity.AuditSuccess,
+		}).Return(nil)
+
+		auditService.Record(ctx, entity.AuditSignIn, userID, nil)
+	})
+
+	t.Run("FailureWithoutUser", func(t *testing.T) {
+		mockAuditStorage.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event *entity.AuditEvent) error {
+				require.Nil(t, event.UserID)
+				require.Equal(t, entity.AuditFailure, event.Outcome)
+				return errors.New("storage is down")
+			})
+
+		auditService.Record(ctx, entity.AuditSignIn, uuid.Nil, errors.New("wrong password"))
+	})
+}
+
+func TestService_AuditVerify(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apiLogger := logger.NewApiLogger(&config.Config{})
+	apiLogger.InitLogger()
+
+	mockAuditStorage := mockstorage.NewMockAuditPsql(ctrl)
+	auditService := NewAuditService(mockAuditStorage, apiLogger)
+
+	t.Run("Valid", func(t *testing.T) {
+		events := newAuditChain(3)
+		mockAuditStorage.EXPECT().ScanEvents(gomock.Any(), int64(0), auditVerifyBatch).Return(events, nil)
+
+		result, err := auditService.Verify(context.Background())
+		require.NoError(t, err)
+		require.True(t, result.Valid())
+		require.Equal(t, int64(3), result.Checked)
+		require.Equal(t, int64(3), result.HeadSeq)
+		require.Equal(t, events[2].Hash, result.HeadHash)
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		events := newAuditChain(3)
+		mockAuditStorage.EXPECT().ScanEvents(gomock.Any(), int64(0), auditVerifyBatch).
+			Return([]*entity.AuditEvent{events[0], events[2]}, nil)
+
+		result, err := auditService.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, result.Valid())
+		require.Len(t, result.Problems, 1)
+		require.Equal(t, int64(3), result.Problems[0].Seq)
+	})
+
+	t.Run("EditedRow", func(t *testing.T) {
+		events := newAuditChain(3)
+		events[1].Outcome = entity.AuditFailure
+		mockAuditStorage.EXPECT().ScanEvents(gomock.Any(), int64(0), auditVerifyBatch).Return(events, nil)
+
+		result, err := auditService.Verify(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Problems, 1)
+		require.Equal(t, int64(2), result.Problems[0].Seq)
+	})
+
+	t.Run("RehashedRow", func(t *testing.T) {
+		events := newAuditChain(3)
+		events[1].Outcome = entity.AuditFailure
+		events[1].Hash = events[1].Digest()
+		mockAuditStorage.EXPECT().ScanEvents(gomock.Any(), int64(0), auditVerifyBatch).Return(events, nil)
+
+		result, err := auditService.Verify(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Problems, 1)
+		require.Equal(t, int64(3), result.Problems[0].Seq)
+	})
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// User data export service
+type ExportService struct {
+	psql        UserPsql
+	credentials WebAuthnPsql
+	sessions    SessionStorage
+	audit       AuditPsql
+}
+
+// New export service constructor
+func newExportService(psql UserPsql, credentials WebAuthnPsql, sessions SessionStorage, audit AuditPsql) *ExportService {
+	return &ExportService{
+		psql:        psql,
+		credentials: credentials,
+		sessions:    sessions,
+		audit:       audit,
+	}
+}
+
+// Collect everything stored about the user, secrets are left out
+func (e *ExportService) ExportUser(ctx context.Context, userID uuid.UUID) (*entity.UserExport, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ExportService.ExportUser")
+	defer span.Finish()
+
+	user, err := e.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	access, err := e.psql.GetUserAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Access = *access
+	user.SanitizePasswor()
+
+	userTOTP, err := e.psql.GetTOTP(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		userTOTP = nil
+	}
+
+	passkeys, err := e.credentials.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := e.sessions.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	auditEvents, err := e.audit.GetUserEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.UserExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     user,
+		TOTP:        userTOTP,
+		Passkeys:    passkeys,
+		Sessions:    sessions,
+		AuditEvents: auditEvents,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/hash"
+	"github.com/opentracing/opentracing-go"
+)
+
+// User import storage interface
+type ImportPsql interface {
+	ImportUser(ctx context.Context, user *entity.User) (bool, error)
+}
+
+// Bulk import of users from other systems, foreign password hashes
+// are kept with their algorithm tag and upgraded on the first sign-in
+type ImportService struct {
+	psql ImportPsql
+}
+
+// New import service constructor
+func NewImportService(psql ImportPsql) *ImportService {
+	return &ImportService{
+		psql: psql,
+	}
+}
+
+// Import one user, returns false when the email is already taken
+func (i *ImportService) ImportUser(ctx context.Context, record *entity.ImportRecord) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ImportService.ImportUser")
+	defer span.Finish()
+
+	email := strings.ToLower(strings.TrimSpace(record.Email))
+	local, _, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return false, errors.New("invalid email")
+	}
+
+	encoded, err := hash.EncodeForeign(strings.ToLower(record.Algorithm), strings.TrimSpace(record.Hash), strings.TrimSpace(record.Salt), record.Iterations)
+	if err != nil {
+		return false, err
+	}
+
+	// name is required, the local part of email is used when it is missing
+	name := strings.TrimSpace(record.Name)
+	if name == "" {
+		name = local
+	}
+
+	user := &entity.User{
+		Name:     name,
+		Email:    email,
+		Password: encoded,
+	}
+	if record.Verified {
+		now := time.Now().UTC()
+		user.VerifiedAt = &now
+	}
+
+	return i.psql.ImportUser(ctx, user)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/hash"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestService_ImportUser(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		PasswordHash: config.PasswordHash{
+			Argon2Memory:  16 * 1024,
+			Argon2Time:    1,
+			Argon2Threads: 1,
+		},
+	}
+
+	hasher, err := hash.NewPasswordHasherFromConfig(config)
+	require.NoError(t, err)
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	importService := NewImportService(mockUserStorage)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, hasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
+
+	// import the record and sign in with the password, the hash is upgraded once
+	importAndSignIn := func(t *testing.T, record *entity.ImportRecord, password string) {
+		var imported *entity.User
+		mockUserStorage.EXPECT().ImportUser(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, user *entity.User) (bool, error) {
+				imported = user
+				return true, nil
+			})
+
+		ok, err := importService.ImportUser(context.Background(), record)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "edbeermtn@gmail.com", imported.Email)
+		require.True(t, strings.HasPrefix(imported.Password, "$"+record.Algorithm+"$"))
+
+		imported.ID = uuid.New()
+		login := &entity.User{Email: imported.Email, Password: "wrong password"}
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), login).Return(imported, nil)
+
+		_, _, err = userService.SignIn(context.Background(), login)
+		require.Error(t, err)
+
+		var upgradedHash string
+		login = &entity.User{Email: imported.Email, Password: password}
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), login).Return(imported, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), imported.ID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, password string) error {
+				upgradedHash = password
+				return nil
+			})
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), imported.ID).Return(nil, sql.ErrNoRows)
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), imported.ID).Return(&entity.Access{}, nil)
+
+		_, _, err = userService.SignIn(context.Background(), login)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(upgradedHash, "$argon2id$"))
+		require.Equal(t, upgradedHash, imported.Password)
+	}
+
+	t.Run("SHA1", func(t *testing.T) {
+		importAndSignIn(t, &entity.ImportRecord{
+			Email:     " Edbeermtn@gmail.com",
+			Algorithm: hash.AlgSHA1,
+			Hash:      hash.NewSHA1Hasher().Hash("12345678"),
+			Verified:  true,
+		}, "12345678")
+	})
+
+	t.Run("PBKDF2", func(t *testing.T) {
+		salt := []byte("legacy salt")
+		key := pbkdf2.Key([]byte("12345678"), salt, 1000, 32, sha256.New)
+
+		importAndSignIn(t, &entity.ImportRecord{
+			Email:      "edbeermtn@gmail.com",
+			Name:       "Pavel",
+			Algorithm:  hash.AlgPBKDF2SHA256,
+			Hash:       hex.EncodeToString(key),
+			Salt:       hex.EncodeToString(salt),
+			Iterations: 1000,
+		}, "12345678")
+	})
+
+	t.Run("UnknownAlgorithm", func(t *testing.T) {
+		_, err := importService.ImportUser(context.Background(), &entity.ImportRecord{
+			Email:     "edbeermtn@gmail.com",
+			Algorithm: "md5",
+			Hash:      "e10adc3949ba59abbe56e057f20f883e",
+		})
+		require.ErrorIs(t, err, hash.ErrUnknownAlgorithm)
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		mockUserStorage.EXPECT().ImportUser(gomock.Any(), gomock.Any()).Return(false, nil)
+
+		ok, err := importService.ImportUser(context.Background(), &entity.ImportRecord{
+			Email:     "edbeermtn@gmail.com",
+			Algorithm: hash.AlgSHA1,
+			Hash:      hash.NewSHA1Hasher().Hash("12345678"),
+		})
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -3,21 +3,98 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/webauthn"
 	"github.com/google/uuid"
 )
 
 // User service interface
 type User interface {
 	SignUp(ctx context.Context, input *entity.User) (*entity.UserWithToken, error)
-	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, error)
+	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, *entity.MFAChallenge, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.UserWithToken, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, user *entity.User) error
+	ForgotPassword(ctx context.Context, user *entity.User) error
+	ResetPassword(ctx context.Context, token, password string) error
+	SignInMFA(ctx context.Context, challenge, code string) (*entity.UserWithToken, error)
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (*entity.BackupCodes, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, name string) (*entity.User, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword, refreshToken string) error
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, email, password string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error
+	RestoreAccount(ctx context.Context, token string) error
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+}
+
+// Security audit log interface
+type Audit interface {
+	Record(ctx context.Context, event string, userID uuid.UUID, err error)
+	ListEvents(ctx context.Context, filter *entity.AuditFilter) (*entity.AuditList, error)
+}
+
+// Rate limit interface
+type RateLimit interface {
+	Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error)
+}
+
+// User data export interface
+type Export interface {
+	ExportUser(ctx context.Context, userID uuid.UUID) (*entity.UserExport, error)
+}
+
+// Admin user management interface
+type Admin interface {
+	ListUsers(ctx context.Context, filter *entity.UserFilter) (*entity.UserList, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	ForcePasswordReset(ctx context.Context, userID uuid.UUID) error
+	SuspendUser(ctx context.Context, userID uuid.UUID) error
+	UnsuspendUser(ctx context.Context, userID uuid.UUID) error
+	UnlockUser(ctx context.Context, userID uuid.UUID) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// OAuth authorization server interface
+type OAuth interface {
+	Authorize(ctx context.Context, userID uuid.UUID, request *entity.AuthorizationRequest) (*entity.OAuthConsent, error)
+	Consent(ctx context.Context, userID uuid.UUID, authTime time.Time, consentToken string, request *entity.AuthorizationRequest, approved bool) (string, error)
+	Exchange(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error)
+	ValidateAccessToken(ctx context.Context, accessToken string) (*entity.OAuthGrant, error)
+	Introspect(ctx context.Context, request *entity.TokenHintRequest) (*entity.Introspection, error)
+	Revoke(ctx context.Context, request *entity.TokenHintRequest) error
 }
 
 // Session service interface
 type Session interface {
 	CreateSession(ctx context.Context, session *entity.Session, expire int) (string, error)
 	GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error)
+	GetSession(ctx context.Context, refreshToken string) (*entity.Session, error)
+	RefreshSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error)
 	DeleteSession(ctx context.Context, refreshToken string) error
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID, refreshToken string) ([]*entity.SessionInfo, error)
+	DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID uuid.UUID, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, token *entity.AccessToken) error
+	IsAccessTokenRevoked(ctx context.Context, token *entity.AccessToken) (bool, error)
+}
+
+// WebAuthn service interface
+type WebAuthn interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (*webauthn.CreationOptions, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, name string, response *webauthn.AttestationResponse) (*entity.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, user *entity.User) (*webauthn.RequestOptions, error)
+	FinishLogin(ctx context.Context, response *webauthn.AssertionResponse) (*entity.UserWithToken, error)
+}
+
+// Service account service interface
+type ServiceAccount interface {
+	GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error)
+	IssueToken(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error)
 }
\ No newline at end of file
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/Edbeer/Project/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Start TOTP enrollment, secret is enabled after confirmation with the first valid code
+func (u *UserService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTPEnrollment, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.EnrollTOTP")
+	defer span.Finish()
+
+	user, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := u.mfaEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, httpe.NewBadRequestError(httpe.MFAAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.psql.CreateTOTP(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &entity.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(u.config.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm TOTP enrollment, returns new backup codes
+func (u *UserService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (_ *entity.BackupCodes, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ConfirmTOTP")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditMFAEnable, userID, err) }()
+
+	userTOTP, err := u.psql.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpe.NewBadRequestError(httpe.MFANotEnabled)
+		}
+		return nil, err
+	}
+	if userTOTP.IsEnabled() {
+		return nil, httpe.NewBadRequestError(httpe.MFAAlreadyEnabled)
+	}
+
+	step, ok := totp.Match(userTOTP.Secret, code, time.Now())
+	if !ok {
+		return nil, httpe.NewBadRequestError(httpe.InvalidMFACode)
+	}
+	// the confirming code can not be replayed to sign in
+	if err := u.psql.UseTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpe.NewBadRequestError(httpe.InvalidMFACode)
+		}
+		return nil, err
+	}
+
+	codes, hashes, err := newBackupCodes(u.config.MFA.BackupCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.psql.ConfirmTOTP(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &entity.BackupCodes{Codes: codes}, nil
+}
+
+// Disable two-factor authentication, requires TOTP or backup code
+func (u *UserService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.DisableTOTP")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditMFADisable, userID, err) }()
+
+	if err := u.checkMFACode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return u.psql.DeleteTOTP(ctx, userID)
+}
+
+// Exchange MFA challenge and code for access token
+func (u *UserService) SignInMFA(ctx context.Context, challenge, code string) (*entity.UserWithToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.SignInMFA")
+	defer span.Finish()
+
+	userID, err := u.tokens.ConsumeToken(ctx, entity.ActionMFAChallenge, hashToken(challenge))
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(httpe.InvalidMFACode)
+	}
+
+	if err := u.checkMFACode(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := generateAccessToken(ctx, u.psql, u.tokenManager, foundUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.UserWithToken{
+		User:        foundUser,
+		AccessToken: accessToken,
+	}, nil
+}
+
+func (u *UserService) mfaEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	userTOTP, err := u.psql.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return userTOTP.IsEnabled(), nil
+}
+
+func (u *UserService) newMFAChallenge(ctx context.Context, userID uuid.UUID) (*entity.MFAChallenge, error) {
+	challenge, err := token.New(token.MFAChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	expire := u.config.MFA.ChallengeExpire
+	if err := u.tokens.CreateToken(ctx, entity.ActionMFAChallenge, hashToken(challenge), userID, expire); err != nil {
+		return nil, err
+	}
+
+	return &entity.MFAChallenge{
+		MFARequired: true,
+		Challenge:   challenge,
+		ExpiresIn:   expire,
+	}, nil
+}
+
+// Check TOTP code or use one of backup codes
+func (u *UserService) checkMFACode(ctx context.Context, userID uuid.UUID, code string) error {
+	userTOTP, err := u.psql.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return httpe.NewBadRequestError(httpe.MFANotEnabled)
+		}
+		return err
+	}
+	if !userTOTP.IsEnabled() {
+		return httpe.NewBadRequestError(httpe.MFANotEnabled)
+	}
+
+	if step, ok := totp.Match(userTOTP.Secret, code, time.Now()); ok {
+		// each time step is accepted once, RFC 6238 section 5.2
+		if err := u.psql.UseTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return httpe.NewUnauthorizedError(httpe.InvalidMFACode)
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := u.psql.UseBackupCode(ctx, userID, hashToken(normalizeBackupCode(code))); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return httpe.NewUnauthorizedError(httpe.InvalidMFACode)
+		}
+		return err
+	}
+	return nil
+}
+
+var backupCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Backup codes look like xxxxx-xxxxx, only hashes are stored
+func newBackupCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b, err := token.Random(7)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(backupCodeEncoding.EncodeToString(b)[:10])
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/totp"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_TOTP(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		MFA: config.MFA{
+			Issuer:          "Auth App",
+			ChallengeExpire: 60,
+			BackupCodes:     10,
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		ID:    uuid.New(),
+		Email: "edbeermtn@gmail.com",
+	}
+	userTOTP := &entity.TOTP{
+		UserID: user.ID,
+	}
+	// storage accepts each time step once
+	mockUserStorage.EXPECT().UseTOTPStep(gomock.Any(), user.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, step int64) error {
+			if step <= userTOTP.LastStep {
+				return sql.ErrNoRows
+			}
+			userTOTP.LastStep = step
+			return nil
+		}).AnyTimes()
+
+	t.Run("Enroll", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(nil, sql.ErrNoRows)
+		mockUserStorage.EXPECT().CreateTOTP(gomock.Any(), user.ID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, secret string) error {
+				userTOTP.Secret = secret
+				return nil
+			})
+
+		enrollment, err := userService.EnrollTOTP(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, userTOTP.Secret, enrollment.Secret)
+		require.Contains(t, enrollment.URI, "otpauth://totp/")
+	})
+
+	var backupCodes []string
+	t.Run("Confirm", func(t *testing.T) {
+		code, err := totp.Code(userTOTP.Secret, time.Now())
+		require.NoError(t, err)
+
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+		mockUserStorage.EXPECT().ConfirmTOTP(gomock.Any(), user.ID, gomock.Len(10)).Return(nil)
+
+		codes, err := userService.ConfirmTOTP(context.Background(), user.ID, code)
+		require.NoError(t, err)
+		require.Len(t, codes.Codes, 10)
+		backupCodes = codes.Codes
+
+		now := time.Now()
+		userTOTP.ConfirmedAt = &now
+	})
+
+	var challenge string
+	t.Run("SignIn", func(t *testing.T) {
+		var challengeHash string
+		login := &entity.User{Email: user.Email, Password: "12345678"}
+		hashedPassword, err := testHasher.Hash(login.Password)
+		require.NoError(t, err)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(login)).Return(&entity.User{
+			ID:       user.ID,
+			Email:    user.Email,
+			Password: hashedPassword,
+		}, nil)
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionMFAChallenge, gomock.Any(), user.ID, 60).
+			DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+				challengeHash = id
+				return nil
+			})
+
+		userWithToken, mfaChallenge, err := userService.SignIn(context.Background(), login)
+		require.NoError(t, err)
+		require.Nil(t, userWithToken)
+		require.NotNil(t, mfaChallenge)
+		require.True(t, mfaChallenge.MFARequired)
+		require.Equal(t, hashToken(mfaChallenge.Challenge), challengeHash)
+		challenge = mfaChallenge.Challenge
+	})
+
+	var signInCode string
+	t.Run("SignInMFA", func(t *testing.T) {
+		// code of the confirmation step is used up, the next one is within skew
+		code, err := totp.Code(userTOTP.Secret, time.Now().Add(totp.Period*time.Second))
+		require.NoError(t, err)
+		signInCode = code
+
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionMFAChallenge, hashToken(challenge)).Return(user.ID, nil)
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), user.ID).Return(&entity.Access{}, nil)
+
+		userWithToken, err := userService.SignInMFA(context.Background(), challenge, code)
+		require.NoError(t, err)
+		require.NotEqual(t, userWithToken.AccessToken, "")
+	})
+
+	t.Run("ReplayedCode", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+
+		err := userService.DisableTOTP(context.Background(), user.ID, signInCode)
+		require.Error(t, err)
+	})
+
+	t.Run("DisableWithBackupCode", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+		mockUserStorage.EXPECT().UseBackupCode(gomock.Any(), user.ID, hashToken(normalizeBackupCode(backupCodes[0]))).Return(nil)
+		mockUserStorage.EXPECT().DeleteTOTP(gomock.Any(), user.ID).Return(nil)
+
+		err := userService.DisableTOTP(context.Background(), user.ID, backupCodes[0])
+		require.NoError(t, err)
+	})
+
+	t.Run("WrongCode", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), user.ID).Return(userTOTP, nil)
+		mockUserStorage.EXPECT().UseBackupCode(gomock.Any(), user.ID, gomock.Any()).Return(sql.ErrNoRows)
+
+		err := userService.DisableTOTP(context.Background(), user.ID, "000000")
+		require.Error(t, err)
+	})
+}
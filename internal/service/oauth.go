@@ -0,0 +1,662 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/oauth"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// OAuth clients psql storage interface
+type OAuthPsql interface {
+	CreateClient(ctx context.Context, client *entity.OAuthClient) (*entity.OAuthClient, error)
+	GetClient(ctx context.Context, clientID string) (*entity.OAuthClient, error)
+	ListClients(ctx context.Context) ([]*entity.OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID string) error
+}
+
+// OAuth grants storage interface
+type OAuthStorage interface {
+	CreateCode(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error)
+	ConsumeCode(ctx context.Context, code string) (*entity.OAuthGrant, error)
+	CreateRefreshToken(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error)
+	ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error)
+	GetRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error)
+}
+
+// OAuth access and ID token issuer interface
+type OAuthTokenManager interface {
+	GenerateOAuthToken(issuer string, grant *entity.OAuthGrant) (string, error)
+	ParseOAuthToken(issuer, tokenString string) (*entity.OAuthGrant, error)
+	GenerateIDToken(issuer string, grant *entity.OAuthGrant, user *entity.UserInfo) (string, error)
+	IntrospectToken(tokenString string) (*entity.Introspection, error)
+}
+
+// OAuth 2.0 authorization server service
+type OAuthService struct {
+	config       *config.Config
+	clients      OAuthPsql
+	accounts     ServiceAccountPsql
+	grants       OAuthStorage
+	tokens       TokenStorage
+	sessions     SessionStorage
+	revocations  RevocationStorage
+	users        UserPsql
+	tokenManager OAuthTokenManager
+	audit        Auditor
+}
+
+// New oauth service constructor
+func NewOAuthService(config *config.Config, clients OAuthPsql, accounts ServiceAccountPsql, grants OAuthStorage, tokens TokenStorage, sessions SessionStorage, revocations RevocationStorage, users UserPsql, tokenManager OAuthTokenManager, audit Auditor) *OAuthService {
+	return &OAuthService{
+		config:       config,
+		clients:      clients,
+		accounts:     accounts,
+		grants:       grants,
+		tokens:       tokens,
+		sessions:     sessions,
+		revocations:  revocations,
+		users:        users,
+		tokenManager: tokenManager,
+		audit:        audit,
+	}
+}
+
+// New oauth client management service for the admin command
+func NewOAuthClientService(clients OAuthPsql) *OAuthService {
+	return &OAuthService{clients: clients}
+}
+
+// Register client, confidential clients get a secret which is shown only once
+func (o *OAuthService) CreateClient(ctx context.Context, client *entity.OAuthClient, public bool) (*entity.OAuthClient, string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.CreateClient")
+	defer span.Finish()
+
+	client.Name = strings.TrimSpace(client.Name)
+	if client.Name == "" {
+		return nil, "", errors.New("client name is required")
+	}
+	if len(client.RedirectURIs) == 0 {
+		return nil, "", errors.New("at least one redirect uri is required")
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if !oauth.ValidRedirectURI(redirectURI) {
+			return nil, "", errors.New("invalid redirect uri " + redirectURI)
+		}
+	}
+	client.Scopes = oauth.ParseScope(oauth.JoinScope(client.Scopes))
+	if client.ID == "" {
+		client.ID = uuid.New().String()
+	}
+
+	var secret string
+	client.SecretHash = nil
+	if !public {
+		var err error
+		secret, err = token.New(token.ClientSecret)
+		if err != nil {
+			return nil, "", err
+		}
+		secretHash := hashClientSecret(secret)
+		client.SecretHash = &secretHash
+	}
+
+	created, err := o.clients.CreateClient(ctx, client)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, secret, nil
+}
+
+// Get all registered clients
+func (o *OAuthService) ListClients(ctx context.Context) ([]*entity.OAuthClient, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.ListClients")
+	defer span.Finish()
+
+	return o.clients.ListClients(ctx)
+}
+
+// Delete client, issued codes and tokens stop working as the client is unknown
+func (o *OAuthService) DeleteClient(ctx context.Context, clientID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.DeleteClient")
+	defer span.Finish()
+
+	return o.clients.DeleteClient(ctx, clientID)
+}
+
+// Describe the token to a client or a service account, RFC 7662. Tokens of
+// oauth clients and service accounts are described only to their holder,
+// session refresh and access tokens of the first-party sign in only to
+// service accounts allowed to read users
+func (o *OAuthService) Introspect(ctx context.Context, request *entity.TokenHintRequest) (*entity.Introspection, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.Introspect")
+	defer span.Finish()
+
+	callerID, account, err := o.authenticateCaller(ctx, request.ClientID, request.ClientSecret, false)
+	if err != nil {
+		return nil, err
+	}
+
+	inactive := &entity.Introspection{}
+	var introspection *entity.Introspection
+	// token kind is told by its format, the type hint is not needed
+	switch kind, _ := token.Parse(request.Token); kind {
+	case token.RefreshToken:
+		if account == nil || !account.HasPermission(entity.PermissionUsersRead) {
+			return inactive, nil
+		}
+		session, err := o.sessions.GetSession(ctx, request.Token)
+		if errors.Is(err, redis.Nil) {
+			return inactive, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		introspection = &entity.Introspection{
+			Subject:   session.UserID.String(),
+			ExpiresAt: session.ExpiresAt.Unix(),
+		}
+	case token.OAuthRefresh:
+		grant, err := o.grants.GetRefreshToken(ctx, request.Token)
+		if errors.Is(err, redis.Nil) {
+			return inactive, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if grant.ClientID != callerID {
+			return inactive, nil
+		}
+		introspection = &entity.Introspection{
+			Scope:     oauth.JoinScope(grant.Scopes),
+			ClientID:  grant.ClientID,
+			Subject:   grant.UserID.String(),
+			ExpiresAt: grant.ExpiresAt.Unix(),
+			Issuer:    o.config.OAuth.Issuer,
+		}
+	default:
+		introspection, err = o.tokenManager.IntrospectToken(request.Token)
+		if err != nil {
+			return inactive, nil
+		}
+		if !canAccessToken(introspection, callerID, account, entity.PermissionUsersRead) {
+			return inactive, nil
+		}
+		revoked, err := o.revocations.IsRevoked(ctx, introspection.AccessToken())
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return inactive, nil
+		}
+		introspection.TokenType = oauth.TokenTypeAccessToken
+		if introspection.PrincipalType == entity.PrincipalService {
+			// service accounts are gone with their tokens once deleted
+			_, err := o.accounts.GetServiceAccount(ctx, introspection.Subject)
+			if errors.Is(err, sql.ErrNoRows) {
+				return inactive, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return introspection, nil
+		}
+	}
+
+	if introspection.TokenType == "" {
+		introspection.TokenType = oauth.TokenTypeRefreshToken
+	}
+	introspection.Active = true
+	introspection.PrincipalType = entity.PrincipalUser
+
+	// tokens of suspended and deleted users are not active
+	userID, err := uuid.Parse(introspection.Subject)
+	if err != nil {
+		return inactive, nil
+	}
+	user, err := o.users.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return inactive, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSuspended() || user.IsDeleted() {
+		return inactive, nil
+	}
+	introspection.Username = user.Email
+	return introspection, nil
+}
+
+// Revoke refresh or access token, RFC 7009. Unknown tokens and tokens of
+// other clients are ignored, so the response does not tell them apart.
+// Session refresh and access tokens of the first-party sign in are revoked only
+// by service accounts allowed to write users. Access tokens are denied until they expire
+func (o *OAuthService) Revoke(ctx context.Context, request *entity.TokenHintRequest) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.Revoke")
+	defer span.Finish()
+
+	callerID, account, err := o.authenticateCaller(ctx, request.ClientID, request.ClientSecret, true)
+	if err != nil {
+		return err
+	}
+
+	switch kind, _ := token.Parse(request.Token); kind {
+	case token.RefreshToken:
+		if account == nil || !account.HasPermission(entity.PermissionUsersWrite) {
+			return nil
+		}
+		session, err := o.sessions.GetSession(ctx, request.Token)
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := o.sessions.DeleteSession(ctx, request.Token); err != nil {
+			return err
+		}
+		o.audit.Record(ctx, entity.AuditTokenRevoke, session.UserID, nil)
+		return nil
+	case token.OAuthRefresh:
+		grant, err := o.grants.GetRefreshToken(ctx, request.Token)
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if grant.ClientID != callerID {
+			return nil
+		}
+		if _, err := o.grants.ConsumeRefreshToken(ctx, request.Token); err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		o.audit.Record(ctx, entity.AuditTokenRevoke, grant.UserID, nil)
+		return nil
+	default:
+		introspection, err := o.tokenManager.IntrospectToken(request.Token)
+		if err != nil {
+			return nil
+		}
+		if !canAccessToken(introspection, callerID, account, entity.PermissionUsersWrite) {
+			return nil
+		}
+		if err := o.revocations.RevokeToken(ctx, introspection.AccessToken()); err != nil {
+			return err
+		}
+		if userID, err := uuid.Parse(introspection.Subject); err == nil {
+			o.audit.Record(ctx, entity.AuditTokenRevoke, userID, nil)
+		}
+		return nil
+	}
+}
+
+// Validate authorization request of the signed in user and start consent
+func (o *OAuthService) Authorize(ctx context.Context, userID uuid.UUID, request *entity.AuthorizationRequest) (*entity.OAuthConsent, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.Authorize")
+	defer span.Finish()
+
+	client, scopes, _, err := o.validateAuthorization(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	consentToken, err := token.New(token.OAuthConsent)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.tokens.CreateToken(ctx, entity.ActionOAuthConsent, consentTokenID(consentToken, request), userID, o.config.OAuth.ConsentExpire); err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthConsent{
+		Token:   consentToken,
+		Client:  client,
+		Scopes:  scopes,
+		Request: request,
+	}, nil
+}
+
+// Consent token is stored under the digest of the token with the authorization
+// request shown to the user, so the consent approves only that request
+func consentTokenID(consentToken string, request *entity.AuthorizationRequest) string {
+	canonical := url.Values{
+		"response_type":         {request.ResponseType},
+		"client_id":             {request.ClientID},
+		"redirect_uri":          {request.RedirectURI},
+		"scope":                 {request.Scope},
+		"state":                 {request.State},
+		"code_challenge":        {request.CodeChallenge},
+		"code_challenge_method": {request.CodeChallengeMethod},
+		"nonce":                 {request.Nonce},
+	}
+	return hashToken(consentToken + "?" + canonical.Encode())
+}
+
+// Finish consent of the user signed in at auth time, returns the client
+// redirect url with authorization code or access_denied error
+func (o *OAuthService) Consent(ctx context.Context, userID uuid.UUID, authTime time.Time, consentToken string, request *entity.AuthorizationRequest, approved bool) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.Consent")
+	defer span.Finish()
+
+	// token of another client, scope or redirect uri is not found
+	ownerID, err := o.tokens.ConsumeToken(ctx, entity.ActionOAuthConsent, consentTokenID(consentToken, request))
+	if err != nil || ownerID != userID {
+		return "", httpe.NewBadRequestError(httpe.InvalidConsent)
+	}
+
+	client, scopes, redirectURI, err := o.validateAuthorization(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	if !approved {
+		denied := oauth.NewError(oauth.ErrAccessDenied, "The user denied the request")
+		o.audit.Record(ctx, entity.AuditOAuthConsent, userID, denied)
+		return oauth.NewRedirectError(denied, redirectURI, request.State, o.config.OAuth.Issuer).URL, nil
+	}
+
+	code, err := o.grants.CreateCode(ctx, &entity.OAuthGrant{
+		ClientID:            client.ID,
+		UserID:              userID,
+		Scopes:              scopes,
+		RedirectURI:         request.RedirectURI,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		Nonce:               request.Nonce,
+		AuthTime:            authTime,
+	}, o.config.OAuth.CodeExpire)
+	if err != nil {
+		return "", err
+	}
+	o.audit.Record(ctx, entity.AuditOAuthConsent, userID, nil)
+
+	params := url.Values{"code": {code}}
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+	if o.config.OAuth.Issuer != "" {
+		params.Set("iss", o.config.OAuth.Issuer)
+	}
+	return oauth.RedirectURL(redirectURI, params), nil
+}
+
+// Token endpoint, exchanges authorization code or refresh token for tokens
+func (o *OAuthService) Exchange(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.Exchange")
+	defer span.Finish()
+
+	client, err := o.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.GrantType {
+	case oauth.GrantAuthorizationCode:
+		return o.exchangeCode(ctx, client, request)
+	case oauth.GrantRefreshToken:
+		return o.exchangeRefreshToken(ctx, client, request)
+	case "":
+		return nil, oauth.NewError(oauth.ErrInvalidRequest, "grant_type is required")
+	default:
+		return nil, oauth.NewError(oauth.ErrUnsupportedGrantType, "")
+	}
+}
+
+func (o *OAuthService) exchangeCode(ctx context.Context, client *entity.OAuthClient, request *entity.TokenRequest) (*entity.OAuthToken, error) {
+	if request.Code == "" || request.CodeVerifier == "" {
+		return nil, oauth.NewError(oauth.ErrInvalidRequest, "code and code_verifier are required")
+	}
+
+	grant, err := o.grants.ConsumeCode(ctx, request.Code)
+	if errors.Is(err, redis.Nil) {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "Invalid or expired authorization code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.ClientID != client.ID {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "Authorization code was issued to another client")
+	}
+	if grant.RedirectURI != request.RedirectURI {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !oauth.VerifyChallenge(grant.CodeChallenge, grant.CodeChallengeMethod, request.CodeVerifier) {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "Invalid code_verifier")
+	}
+
+	return o.issueTokens(ctx, grant, grant.Scopes)
+}
+
+func (o *OAuthService) exchangeRefreshToken(ctx context.Context, client *entity.OAuthClient, request *entity.TokenRequest) (*entity.OAuthToken, error) {
+	if request.RefreshToken == "" {
+		return nil, oauth.NewError(oauth.ErrInvalidRequest, "refresh_token is required")
+	}
+
+	grant, err := o.grants.ConsumeRefreshToken(ctx, request.RefreshToken)
+	if errors.Is(err, redis.Nil) {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "Invalid or expired refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.ClientID != client.ID {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "Refresh token was issued to another client")
+	}
+
+	// access token may be narrowed, the refresh token keeps the granted scopes
+	scopes := grant.Scopes
+	if request.Scope != "" {
+		scopes = oauth.ParseScope(request.Scope)
+		if !oauth.ScopeAllowed(scopes, grant.Scopes) {
+			return nil, oauth.NewError(oauth.ErrInvalidScope, "scope exceeds the granted scope")
+		}
+	}
+
+	return o.issueTokens(ctx, grant, scopes)
+}
+
+// Issue access token with the scopes and a new refresh token of the grant,
+// openid scope adds ID token. Suspended and deleted users lose their grants
+func (o *OAuthService) issueTokens(ctx context.Context, grant *entity.OAuthGrant, scopes []string) (*entity.OAuthToken, error) {
+	user, err := o.users.GetUserByID(ctx, grant.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "User not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSuspended() || user.IsDeleted() {
+		return nil, oauth.NewError(oauth.ErrInvalidGrant, "User is not active")
+	}
+
+	accessToken, err := o.tokenManager.GenerateOAuthToken(o.config.OAuth.Issuer, &entity.OAuthGrant{
+		ClientID: grant.ClientID,
+		UserID:   grant.UserID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var idToken string
+	if oauth.HasScope(scopes, entity.ScopeOpenID) {
+		idToken, err = o.tokenManager.GenerateIDToken(o.config.OAuth.Issuer, grant, entity.NewUserInfo(user, scopes))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// nonce belongs to the authorization request only
+	refreshToken, err := o.grants.CreateRefreshToken(ctx, &entity.OAuthGrant{
+		ClientID: grant.ClientID,
+		UserID:   grant.UserID,
+		Scopes:   grant.Scopes,
+		AuthTime: grant.AuthTime,
+	}, o.config.OAuth.RefreshExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthToken{
+		AccessToken:  accessToken,
+		TokenType:    oauth.TokenTypeBearer,
+		ExpiresIn:    int(jwt.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        oauth.JoinScope(scopes),
+		IDToken:      idToken,
+	}, nil
+}
+
+// Validate unrevoked access token presented to a protected resource, returns its grant
+func (o *OAuthService) ValidateAccessToken(ctx context.Context, accessToken string) (*entity.OAuthGrant, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthService.ValidateAccessToken")
+	defer span.Finish()
+
+	grant, err := o.tokenManager.ParseOAuthToken(o.config.OAuth.Issuer, accessToken)
+	if err != nil {
+		return nil, oauth.NewError(oauth.ErrInvalidToken, "Invalid or expired access token")
+	}
+
+	// claims of any access token carry its id and issue time
+	introspection, err := o.tokenManager.IntrospectToken(accessToken)
+	if err != nil {
+		return nil, oauth.NewError(oauth.ErrInvalidToken, "Invalid or expired access token")
+	}
+	revoked, err := o.revocations.IsRevoked(ctx, introspection.AccessToken())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, oauth.NewError(oauth.ErrInvalidToken, "Access token was revoked")
+	}
+	return grant, nil
+}
+
+// Validate authorization request, returns the client, requested scopes and redirect uri.
+// Unknown client and unregistered redirect uri are never redirected to,
+// other errors carry the redirect uri and state
+func (o *OAuthService) validateAuthorization(ctx context.Context, request *entity.AuthorizationRequest) (*entity.OAuthClient, []string, string, error) {
+	if request.ClientID == "" {
+		return nil, nil, "", httpe.NewBadRequestError(httpe.UnknownOAuthClient)
+	}
+	client, err := o.clients.GetClient(ctx, request.ClientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, "", httpe.NewBadRequestError(httpe.UnknownOAuthClient)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	redirectURI := request.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !oauth.MatchRedirectURI(client.RedirectURIs, redirectURI) {
+		return nil, nil, "", httpe.NewBadRequestError(httpe.InvalidRedirectURI)
+	}
+
+	if request.ResponseType != oauth.ResponseTypeCode {
+		return nil, nil, "", o.redirectErr(redirectURI, request.State, oauth.ErrUnsupportedResponseType, "response_type must be code")
+	}
+	if !oauth.ValidChallenge(request.CodeChallenge) {
+		return nil, nil, "", o.redirectErr(redirectURI, request.State, oauth.ErrInvalidRequest, "code_challenge is required")
+	}
+	if request.CodeChallengeMethod != oauth.MethodS256 {
+		return nil, nil, "", o.redirectErr(redirectURI, request.State, oauth.ErrInvalidRequest, "code_challenge_method must be S256")
+	}
+
+	scopes := oauth.ParseScope(request.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauth.ScopeAllowed(scopes, client.Scopes) {
+		return nil, nil, "", o.redirectErr(redirectURI, request.State, oauth.ErrInvalidScope, "scope is not allowed for the client")
+	}
+
+	return client, scopes, redirectURI, nil
+}
+
+// Authenticate client of the token request, public clients send only their id
+func (o *OAuthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*entity.OAuthClient, error) {
+	if clientID == "" {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	client, err := o.clients.GetClient(ctx, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashClientSecret(clientSecret)), []byte(*client.SecretHash)) != 1 {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	return client, nil
+}
+
+// Caller may introspect or revoke the access token: tokens of oauth clients and
+// service accounts belong to their holder, first-party tokens of the users need
+// a service account with the permission
+func canAccessToken(introspection *entity.Introspection, callerID string, account *entity.ServiceAccount, permission string) bool {
+	if introspection.ClientID != "" {
+		return introspection.ClientID == callerID
+	}
+	return account != nil && account.HasPermission(permission)
+}
+
+// Authenticate oauth client or service account calling introspection and
+// revocation, returns its client id and the service account when the caller is one.
+// Public clients may only revoke their tokens
+func (o *OAuthService) authenticateCaller(ctx context.Context, clientID, clientSecret string, allowPublic bool) (string, *entity.ServiceAccount, error) {
+	client, err := o.authenticateClient(ctx, clientID, clientSecret)
+	if err == nil {
+		if client.IsPublic() && !allowPublic {
+			return "", nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+		}
+		return client.ID, nil, nil
+	}
+	var oauthErr *oauth.Error
+	if !errors.As(err, &oauthErr) {
+		return "", nil, err
+	}
+
+	account, err := authenticateServiceAccount(ctx, o.accounts, clientID, clientSecret)
+	if err != nil {
+		return "", nil, err
+	}
+	return account.ClientID, account, nil
+}
+
+func (o *OAuthService) redirectErr(redirectURI, state, code, description string) error {
+	return oauth.NewRedirectError(oauth.NewError(code, description), redirectURI, state, o.config.OAuth.Issuer)
+}
+
+// Client secrets are random, a plain digest is enough
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,611 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/oauth"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/go-redis/redis/v9"
+	jwtgo "github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mJ92K9ZkmbqlbPW6nx5hRfbYcmXUo5rR8hE1aC3"
+
+func testOAuthConfig() *config.Config {
+	return &config.Config{
+		OAuth: config.OAuth{
+			Issuer:        "https://auth.example.com",
+			CodeExpire:    60,
+			ConsentExpire: 600,
+			RefreshExpire: 3600,
+		},
+	}
+}
+
+func TestService_OAuthAuthorize(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _ := jwt.NewManager("secret")
+	mockClientStorage := mockstorage.NewMockOAuthPsql(ctrl)
+	mockGrantStorage := mockredis.NewMockOAuthRedis(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	audit := &auditRecorder{}
+	oauthService := NewOAuthService(testOAuthConfig(), mockClientStorage, nil, mockGrantStorage, mockTokenStorage, nil, nil, mockUserStorage, manager, audit)
+
+	client := &entity.OAuthClient{
+		ID:           "client",
+		Name:         "App",
+		RedirectURIs: entity.SpaceList{"https://app.example.com/callback"},
+		Scopes:       entity.SpaceList{"profile", "email"},
+	}
+	userID := uuid.New()
+	authTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	validRequest := func() *entity.AuthorizationRequest {
+		return &entity.AuthorizationRequest{
+			ResponseType:        "code",
+			ClientID:            "client",
+			RedirectURI:         "https://app.example.com/callback",
+			Scope:               "profile",
+			State:               "xyz",
+			CodeChallenge:       oauth.Challenge(testVerifier),
+			CodeChallengeMethod: "S256",
+		}
+	}
+
+	t.Run("UnknownClient", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "unknown").Return(nil, sql.ErrNoRows)
+
+		request := validRequest()
+		request.ClientID = "unknown"
+		_, err := oauthService.Authorize(context.Background(), userID, request)
+		require.ErrorContains(t, err, httpe.UnknownOAuthClient.Error())
+	})
+
+	t.Run("UnregisteredRedirectURI", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+
+		request := validRequest()
+		request.RedirectURI = "https://evil.example.com/callback"
+		_, err := oauthService.Authorize(context.Background(), userID, request)
+		require.ErrorContains(t, err, httpe.InvalidRedirectURI.Error())
+
+		var redirect *oauth.RedirectError
+		require.False(t, errors.As(err, &redirect), "unregistered redirect uri must not be redirected to")
+	})
+
+	for name, tc := range map[string]struct {
+		modify func(request *entity.AuthorizationRequest)
+		code   string
+	}{
+		"ResponseType":    {func(r *entity.AuthorizationRequest) { r.ResponseType = "token" }, oauth.ErrUnsupportedResponseType},
+		"MissingPKCE":     {func(r *entity.AuthorizationRequest) { r.CodeChallenge = "" }, oauth.ErrInvalidRequest},
+		"PlainPKCE":       {func(r *entity.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, oauth.ErrInvalidRequest},
+		"ScopeNotAllowed": {func(r *entity.AuthorizationRequest) { r.Scope = "profile admin" }, oauth.ErrInvalidScope},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+
+			request := validRequest()
+			tc.modify(request)
+			_, err := oauthService.Authorize(context.Background(), userID, request)
+
+			var redirect *oauth.RedirectError
+			require.True(t, errors.As(err, &redirect))
+			require.Equal(t, tc.code, redirect.Err.Code)
+
+			location, err := url.Parse(redirect.URL)
+			require.NoError(t, err)
+			require.Equal(t, "app.example.com", location.Host)
+			require.Equal(t, tc.code, location.Query().Get("error"))
+			require.Equal(t, "xyz", location.Query().Get("state"))
+			require.Equal(t, "https://auth.example.com", location.Query().Get("iss"))
+		})
+	}
+
+	t.Run("DefaultScope", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionOAuthConsent, gomock.Any(), userID, 600).Return(nil)
+
+		request := validRequest()
+		request.Scope = ""
+		request.RedirectURI = ""
+		consent, err := oauthService.Authorize(context.Background(), userID, request)
+		require.NoError(t, err)
+		require.Equal(t, []string{"profile", "email"}, consent.Scopes)
+		require.NotEmpty(t, consent.Token)
+	})
+
+	t.Run("ConsentOfAnotherUser", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionOAuthConsent, consentTokenID("consent", validRequest())).Return(uuid.New(), nil)
+
+		_, err := oauthService.Consent(context.Background(), userID, authTime, "consent", validRequest(), true)
+		require.ErrorContains(t, err, httpe.InvalidConsent.Error())
+	})
+
+	t.Run("ConsentOfAnotherRequest", func(t *testing.T) {
+		var tokenID string
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionOAuthConsent, gomock.Any(), userID, 600).
+			DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+				tokenID = id
+				return nil
+			})
+		consent, err := oauthService.Authorize(context.Background(), userID, validRequest())
+		require.NoError(t, err)
+		require.NotEqual(t, consent.Token, tokenID)
+
+		// consent for the profile scope does not approve the email scope
+		broader := validRequest()
+		broader.Scope = "profile email"
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionOAuthConsent, gomock.Not(tokenID)).Return(uuid.Nil, redis.Nil)
+
+		_, err = oauthService.Consent(context.Background(), userID, authTime, consent.Token, broader, true)
+		require.ErrorContains(t, err, httpe.InvalidConsent.Error())
+
+		other := validRequest()
+		other.ClientID = "other"
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionOAuthConsent, gomock.Not(tokenID)).Return(uuid.Nil, redis.Nil)
+
+		_, err = oauthService.Consent(context.Background(), userID, authTime, consent.Token, other, true)
+		require.ErrorContains(t, err, httpe.InvalidConsent.Error())
+		require.Equal(t, consentTokenID(consent.Token, validRequest()), tokenID)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionOAuthConsent, consentTokenID("consent", validRequest())).Return(userID, nil)
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+
+		redirectURL, err := oauthService.Consent(context.Background(), userID, authTime, "consent", validRequest(), false)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(redirectURL, "https://app.example.com/callback?"))
+		require.Contains(t, redirectURL, "error=access_denied")
+		require.Equal(t, entity.AuditFailure, audit.events[len(audit.events)-1].Outcome)
+	})
+
+	t.Run("Approved", func(t *testing.T) {
+		request := validRequest()
+		request.Nonce = "n-0S6_WzA2Mj"
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionOAuthConsent, consentTokenID("consent", request)).Return(userID, nil)
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().CreateCode(gomock.Any(), &entity.OAuthGrant{
+			ClientID:            "client",
+			UserID:              userID,
+			Scopes:              []string{"profile"},
+			RedirectURI:         "https://app.example.com/callback",
+			CodeChallenge:       oauth.Challenge(testVerifier),
+			CodeChallengeMethod: "S256",
+			Nonce:               "n-0S6_WzA2Mj",
+			AuthTime:            authTime,
+		}, 60).Return("code", nil)
+
+		redirectURL, err := oauthService.Consent(context.Background(), userID, authTime, "consent", request, true)
+		require.NoError(t, err)
+
+		location, err := url.Parse(redirectURL)
+		require.NoError(t, err)
+		require.Equal(t, "code", location.Query().Get("code"))
+		require.Equal(t, "xyz", location.Query().Get("state"))
+		require.Equal(t, entity.AuditOAuthConsent, audit.events[len(audit.events)-1].Event)
+		require.Equal(t, entity.AuditSuccess, audit.events[len(audit.events)-1].Outcome)
+	})
+}
+
+func TestService_OAuthExchange(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _ := jwt.NewManager("secret")
+	mockClientStorage := mockstorage.NewMockOAuthPsql(ctrl)
+	mockGrantStorage := mockredis.NewMockOAuthRedis(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	oauthService := NewOAuthService(testOAuthConfig(), mockClientStorage, nil, mockGrantStorage, mockTokenStorage, nil, mockRevocationStorage, mockUserStorage, manager, &auditRecorder{})
+
+	secretHash := hashClientSecret("client secret")
+	client := &entity.OAuthClient{
+		ID:           "client",
+		SecretHash:   &secretHash,
+		Name:         "App",
+		RedirectURIs: entity.SpaceList{"https://app.example.com/callback"},
+		Scopes:       entity.SpaceList{"profile", "email"},
+	}
+	user := &entity.User{ID: uuid.New(), Email: "edbeermtn@gmail.com"}
+	grant := &entity.OAuthGrant{
+		ClientID:            "client",
+		UserID:              user.ID,
+		Scopes:              []string{"profile", "email"},
+		RedirectURI:         "https://app.example.com/callback",
+		CodeChallenge:       oauth.Challenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+	codeRequest := func() *entity.TokenRequest {
+		return &entity.TokenRequest{
+			GrantType:    "authorization_code",
+			Code:         "code",
+			RedirectURI:  "https://app.example.com/callback",
+			CodeVerifier: testVerifier,
+			ClientID:     "client",
+			ClientSecret: "client secret",
+		}
+	}
+	requireCode := func(t *testing.T, err error, code string) {
+		var oauthErr *oauth.Error
+		require.True(t, errors.As(err, &oauthErr), err)
+		require.Equal(t, code, oauthErr.Code)
+	}
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+
+		request := codeRequest()
+		request.ClientSecret = "wrong"
+		_, err := oauthService.Exchange(context.Background(), request)
+		requireCode(t, err, oauth.ErrInvalidClient)
+	})
+
+	t.Run("WrongVerifier", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(grant, nil)
+
+		request := codeRequest()
+		request.CodeVerifier = strings.Repeat("a", 43)
+		_, err := oauthService.Exchange(context.Background(), request)
+		requireCode(t, err, oauth.ErrInvalidGrant)
+	})
+
+	t.Run("RedirectURIMismatch", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(grant, nil)
+
+		request := codeRequest()
+		request.RedirectURI = ""
+		_, err := oauthService.Exchange(context.Background(), request)
+		requireCode(t, err, oauth.ErrInvalidGrant)
+	})
+
+	t.Run("UsedCode", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(nil, redis.Nil)
+
+		_, err := oauthService.Exchange(context.Background(), codeRequest())
+		requireCode(t, err, oauth.ErrInvalidGrant)
+	})
+
+	t.Run("SuspendedUser", func(t *testing.T) {
+		suspendedAt := time.Now()
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(grant, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&entity.User{ID: user.ID, SuspendedAt: &suspendedAt}, nil)
+
+		_, err := oauthService.Exchange(context.Background(), codeRequest())
+		requireCode(t, err, oauth.ErrInvalidGrant)
+	})
+
+	t.Run("ExchangeCode", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(grant, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockGrantStorage.EXPECT().CreateRefreshToken(gomock.Any(), &entity.OAuthGrant{
+			ClientID: "client",
+			UserID:   user.ID,
+			Scopes:   []string{"profile", "email"},
+		}, 3600).Return("refresh token", nil)
+
+		token, err := oauthService.Exchange(context.Background(), codeRequest())
+		require.NoError(t, err)
+		require.Equal(t, "Bearer", token.TokenType)
+		require.Equal(t, "refresh token", token.RefreshToken)
+		require.Equal(t, "profile email", token.Scope)
+		require.NotEmpty(t, token.AccessToken)
+		require.Empty(t, token.IDToken)
+	})
+
+	t.Run("ExchangeCodeOpenID", func(t *testing.T) {
+		authTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+		openIDGrant := *grant
+		openIDGrant.Scopes = []string{"openid", "email"}
+		openIDGrant.Nonce = "n-0S6_WzA2Mj"
+		openIDGrant.AuthTime = authTime
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeCode(gomock.Any(), "code").Return(&openIDGrant, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		// refresh grant keeps auth time, nonce belongs to the code only
+		mockGrantStorage.EXPECT().CreateRefreshToken(gomock.Any(), &entity.OAuthGrant{
+			ClientID: "client",
+			UserID:   user.ID,
+			Scopes:   []string{"openid", "email"},
+			AuthTime: authTime,
+		}, 3600).Return("refresh token", nil)
+
+		token, err := oauthService.Exchange(context.Background(), codeRequest())
+		require.NoError(t, err)
+		require.NotEmpty(t, token.IDToken)
+
+		claims := &jwt.IDClaims{}
+		_, err = jwtgo.ParseWithClaims(token.IDToken, claims, manager.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, user.ID.String(), claims.Subject)
+		require.Equal(t, "client", claims.Audience)
+		require.Equal(t, "https://auth.example.com", claims.Issuer)
+		require.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+		require.Equal(t, authTime.Unix(), claims.AuthTime)
+		require.Equal(t, user.Email, claims.Email)
+		require.NotNil(t, claims.EmailVerified)
+		require.False(t, *claims.EmailVerified)
+		require.Empty(t, claims.Name)
+
+		mockRevocationStorage.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		grant, err := oauthService.ValidateAccessToken(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, grant.UserID)
+		require.Equal(t, []string{"openid", "email"}, grant.Scopes)
+
+		mockRevocationStorage.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(true, nil)
+		_, err = oauthService.ValidateAccessToken(context.Background(), token.AccessToken)
+		requireCode(t, err, oauth.ErrInvalidToken)
+
+		// ID token is not an access token
+		_, err = oauthService.ValidateAccessToken(context.Background(), token.IDToken)
+		requireCode(t, err, oauth.ErrInvalidToken)
+	})
+
+	t.Run("RefreshNarrowScope", func(t *testing.T) {
+		refreshGrant := &entity.OAuthGrant{ClientID: "client", UserID: user.ID, Scopes: []string{"profile", "email"}}
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeRefreshToken(gomock.Any(), "refresh token").Return(refreshGrant, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockGrantStorage.EXPECT().CreateRefreshToken(gomock.Any(), refreshGrant, 3600).Return("next refresh token", nil)
+
+		token, err := oauthService.Exchange(context.Background(), &entity.TokenRequest{
+			GrantType:    "refresh_token",
+			RefreshToken: "refresh token",
+			Scope:        "email",
+			ClientID:     "client",
+			ClientSecret: "client secret",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "email", token.Scope)
+		require.Equal(t, "next refresh token", token.RefreshToken)
+	})
+
+	t.Run("RefreshWiderScope", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+		mockGrantStorage.EXPECT().ConsumeRefreshToken(gomock.Any(), "refresh token").
+			Return(&entity.OAuthGrant{ClientID: "client", UserID: user.ID, Scopes: []string{"profile"}}, nil)
+
+		_, err := oauthService.Exchange(context.Background(), &entity.TokenRequest{
+			GrantType:    "refresh_token",
+			RefreshToken: "refresh token",
+			Scope:        "profile email",
+			ClientID:     "client",
+			ClientSecret: "client secret",
+		})
+		requireCode(t, err, oauth.ErrInvalidScope)
+	})
+
+	t.Run("UnsupportedGrant", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil)
+
+		request := codeRequest()
+		request.GrantType = "password"
+		_, err := oauthService.Exchange(context.Background(), request)
+		requireCode(t, err, oauth.ErrUnsupportedGrantType)
+	})
+}
+
+func TestService_OAuthIntrospect(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _ := jwt.NewManager("secret")
+	mockClientStorage := mockstorage.NewMockOAuthPsql(ctrl)
+	mockAccountStorage := mockstorage.NewMockServiceAccountPsql(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	oauthService := NewOAuthService(testOAuthConfig(), mockClientStorage, mockAccountStorage, nil, nil, mockSessionStorage, mockRevocationStorage, mockUserStorage, manager, &auditRecorder{})
+
+	secretHash := hashClientSecret("client secret")
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(&entity.OAuthClient{ID: "client", SecretHash: &secretHash}, nil).AnyTimes()
+	// service account of a resource server reading users
+	reader := &entity.ServiceAccount{ClientID: "session-reader", SecretHash: secretHash, Scopes: entity.SpaceList{entity.PermissionUsersRead}}
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "session-reader").Return(nil, sql.ErrNoRows).AnyTimes()
+	mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "session-reader").Return(reader, nil).AnyTimes()
+	user := &entity.User{ID: uuid.New(), Email: "edbeermtn@gmail.com"}
+	introspectAs := func(t *testing.T, clientID, tokenString string) *entity.Introspection {
+		introspection, err := oauthService.Introspect(context.Background(), &entity.TokenHintRequest{
+			Token:        tokenString,
+			ClientID:     clientID,
+			ClientSecret: "client secret",
+		})
+		require.NoError(t, err)
+		return introspection
+	}
+	introspect := func(t *testing.T, tokenString string) *entity.Introspection {
+		return introspectAs(t, "client", tokenString)
+	}
+
+	t.Run("PublicClient", func(t *testing.T) {
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "public").Return(&entity.OAuthClient{ID: "public"}, nil)
+
+		_, err := oauthService.Introspect(context.Background(), &entity.TokenHintRequest{Token: "token", ClientID: "public"})
+		var oauthErr *oauth.Error
+		require.True(t, errors.As(err, &oauthErr))
+		require.Equal(t, oauth.ErrInvalidClient, oauthErr.Code)
+	})
+
+	t.Run("SessionOfSuspendedUser", func(t *testing.T) {
+		suspendedAt := time.Now()
+		sessionToken, err := token.New(token.RefreshToken)
+		require.NoError(t, err)
+		mockSessionStorage.EXPECT().GetSession(gomock.Any(), sessionToken).Return(&entity.Session{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}, nil).Times(2)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+
+		introspection := introspectAs(t, "session-reader", sessionToken)
+		require.True(t, introspection.Active)
+		require.Equal(t, user.Email, introspection.Username)
+		require.Equal(t, oauth.TokenTypeRefreshToken, introspection.TokenType)
+
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&entity.User{ID: user.ID, SuspendedAt: &suspendedAt}, nil)
+		require.Equal(t, &entity.Introspection{}, introspectAs(t, "session-reader", sessionToken))
+	})
+
+	t.Run("SessionOfFirstParty", func(t *testing.T) {
+		sessionToken, err := token.New(token.RefreshToken)
+		require.NoError(t, err)
+
+		// oauth clients are not told about sessions of the first-party sign in
+		require.Equal(t, &entity.Introspection{}, introspect(t, sessionToken))
+	})
+
+	t.Run("DeletedServiceAccount", func(t *testing.T) {
+		account := &entity.ServiceAccount{ClientID: "backup-job", SecretHash: secretHash, Scopes: entity.SpaceList{"users:read"}}
+		accessToken, err := manager.GenerateServiceToken("https://auth.example.com", account, account.Scopes)
+		require.NoError(t, err)
+		mockClientStorage.EXPECT().GetClient(gomock.Any(), "backup-job").Return(nil, sql.ErrNoRows).Times(2)
+		mockRevocationStorage.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(account, nil).Times(3)
+
+		introspection := introspectAs(t, "backup-job", accessToken)
+		require.True(t, introspection.Active)
+		require.Equal(t, entity.PrincipalService, introspection.PrincipalType)
+		require.Equal(t, "users:read", introspection.Scope)
+
+		// deleted after the caller was authenticated
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(nil, sql.ErrNoRows)
+		require.False(t, introspectAs(t, "backup-job", accessToken).Active)
+
+		// tokens of service accounts are described only to their holder
+		require.False(t, introspect(t, accessToken).Active)
+	})
+
+	t.Run("RevokedAccessToken", func(t *testing.T) {
+		accessToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+		mockRevocationStorage.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+
+		introspection := introspectAs(t, "session-reader", accessToken)
+		require.True(t, introspection.Active)
+		require.NotEmpty(t, introspection.TokenID)
+		require.Equal(t, user.ID.String(), introspection.Subject)
+
+		mockRevocationStorage.EXPECT().IsRevoked(gomock.Any(), &entity.AccessToken{
+			ID:        introspection.TokenID,
+			Subject:   user.ID.String(),
+			IssuedAt:  time.UnixMilli(introspection.IssuedAtMs),
+			ExpiresAt: time.Unix(introspection.ExpiresAt, 0),
+		}).Return(true, nil)
+		require.False(t, introspectAs(t, "session-reader", accessToken).Active)
+	})
+
+	t.Run("AccessTokenOfFirstParty", func(t *testing.T) {
+		accessToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+
+		// oauth clients are not told about users signed in first-party
+		require.Equal(t, &entity.Introspection{}, introspect(t, accessToken))
+	})
+
+	t.Run("AccessTokenOfOtherClient", func(t *testing.T) {
+		accessToken, err := manager.GenerateOAuthToken("https://auth.example.com", &entity.OAuthGrant{ClientID: "other", UserID: user.ID})
+		require.NoError(t, err)
+
+		require.Equal(t, &entity.Introspection{}, introspect(t, accessToken))
+		require.Equal(t, &entity.Introspection{}, introspectAs(t, "session-reader", accessToken))
+	})
+
+	t.Run("ActionToken", func(t *testing.T) {
+		actionToken, err := manager.GenerateActionToken(&entity.ActionToken{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Action:    "verify_email",
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		require.False(t, introspect(t, actionToken).Active)
+	})
+}
+
+func TestService_OAuthRevoke(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _ := jwt.NewManager("secret")
+	mockClientStorage := mockstorage.NewMockOAuthPsql(ctrl)
+	mockAccountStorage := mockstorage.NewMockServiceAccountPsql(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	oauthService := NewOAuthService(testOAuthConfig(), mockClientStorage, mockAccountStorage, nil, nil, nil, mockRevocationStorage, nil, manager, &auditRecorder{})
+
+	secretHash := hashClientSecret("client secret")
+	writer := &entity.ServiceAccount{ClientID: "user-admin", SecretHash: secretHash, Scopes: entity.SpaceList{entity.PermissionUsersWrite}}
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(&entity.OAuthClient{ID: "client", SecretHash: &secretHash}, nil).AnyTimes()
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "public").Return(&entity.OAuthClient{ID: "public"}, nil).AnyTimes()
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "user-admin").Return(nil, sql.ErrNoRows).AnyTimes()
+	mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "user-admin").Return(writer, nil).AnyTimes()
+	user := &entity.User{ID: uuid.New(), Email: "edbeermtn@gmail.com"}
+	revoke := func(t *testing.T, clientID, clientSecret, tokenString string) {
+		err := oauthService.Revoke(context.Background(), &entity.TokenHintRequest{
+			Token:        tokenString,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("AccessTokenOfFirstParty", func(t *testing.T) {
+		accessToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+
+		// public and unrelated clients are ignored
+		revoke(t, "public", "", accessToken)
+		revoke(t, "client", "client secret", accessToken)
+
+		mockRevocationStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, token *entity.AccessToken) error {
+				require.Equal(t, user.ID.String(), token.Subject)
+				return nil
+			})
+		revoke(t, "user-admin", "client secret", accessToken)
+	})
+
+	t.Run("AccessTokenOfClient", func(t *testing.T) {
+		accessToken, err := manager.GenerateOAuthToken("https://auth.example.com", &entity.OAuthGrant{ClientID: "client", UserID: user.ID})
+		require.NoError(t, err)
+
+		// tokens of a client are revoked only by the client
+		revoke(t, "public", "", accessToken)
+		revoke(t, "user-admin", "client secret", accessToken)
+
+		mockRevocationStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Return(nil)
+		revoke(t, "client", "client secret", accessToken)
+	})
+}
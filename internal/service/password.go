@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/password"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Send password reset letter, neither the response nor its time
+// depend on email existence
+func (u *UserService) ForgotPassword(ctx context.Context, user *entity.User) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ForgotPassword")
+	defer span.Finish()
+
+	foundUser, err := u.psql.FindUserByEmail(ctx, user)
+	if err != nil {
+		return nil
+	}
+
+	// letter is sent in background, failures are logged and never returned
+	parent := span.Context()
+	go func() {
+		span := opentracing.StartSpan("UserService.sendPasswordReset", opentracing.FollowsFrom(parent))
+		defer span.Finish()
+
+		if err := u.sendPasswordReset(opentracing.ContextWithSpan(context.Background(), span), foundUser); err != nil {
+			u.logger.Errorf("password reset letter, user_id: %s, error: %v", foundUser.ID, err)
+		}
+	}()
+	return nil
+}
+
+func (u *UserService) sendPasswordReset(ctx context.Context, user *entity.User) error {
+	resetToken, err := token.New(token.PasswordReset)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.CreateToken(ctx, entity.ActionResetPassword, hashToken(resetToken), user.ID, u.config.PasswordReset.Expire); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Follow the link to set a new password: %s?token=%s", u.config.PasswordReset.URL, resetToken),
+	})
+}
+
+// Set new password by reset token and revoke all user sessions and access tokens
+func (u *UserService) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ResetPassword")
+	defer span.Finish()
+
+	// link is used up only once the password passes the full policy,
+	// a rejected password can be corrected with the same link
+	userID, err := u.tokens.GetToken(ctx, entity.ActionResetPassword, hashToken(token))
+	if err != nil {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+	defer func() { u.audit.Record(ctx, entity.AuditPasswordReset, userID, err) }()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	newPassword = strings.TrimSpace(newPassword)
+	if err := u.checkPassword("password", newPassword, foundUser); err != nil {
+		return err
+	}
+
+	consumedID, err := u.tokens.ConsumeToken(ctx, entity.ActionResetPassword, hashToken(token))
+	if err != nil || consumedID != userID {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+
+	user := &entity.User{Password: newPassword}
+	if err := user.HashPassword(u.hasher); err != nil {
+		return err
+	}
+
+	if err := u.psql.UpdatePassword(ctx, userID, user.Password); err != nil {
+		return err
+	}
+	if err := revokeUserTokens(ctx, u.revocations, userID); err != nil {
+		return err
+	}
+
+	return u.sessions.DeleteUserSessions(ctx, userID)
+}
+
+// Only token hash is kept in storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Password policy interface
+type PasswordPolicy interface {
+	Check(password, email, name string) ([]password.Violation, error)
+}
+
+// Reject password violating the policy, user is nil when it is not known yet
+func (u *UserService) checkPassword(field, newPassword string, user *entity.User) error {
+	var email, name string
+	if user != nil {
+		email, name = user.Email, user.Name
+	}
+
+	violations, err := u.policy.Check(newPassword, email, name)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fields := make([]httpe.FieldError, 0, len(violations))
+	for _, violation := range violations {
+		fields = append(fields, httpe.FieldError{
+			Field:   field,
+			Code:    violation.Code,
+			Message: violation.Message,
+		})
+	}
+	return httpe.NewValidationError(fields)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/password"
+	"github.com/go-redis/redis/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		PasswordReset: config.PasswordReset{
+			Expire: 60,
+			URL:    "http://localhost/reset-password",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, &auditRecorder{}, testLogger)
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		user := &entity.User{
+			Email: "unknown@gmail.com",
+		}
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(nil, sql.ErrNoRows)
+
+		err := userService.ForgotPassword(context.Background(), user)
+		require.NoError(t, err)
+		require.Nil(t, outbox.Last(user.Email))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		user := &entity.User{
+			ID:    uuid.New(),
+			Email: "edbeermtn@gmail.com",
+		}
+
+		var tokenHash string
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(user, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionResetPassword, gomock.Any(), user.ID, 60).
+			DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+				tokenHash = id
+				return nil
+			})
+
+		err := userService.ForgotPassword(context.Background(), user)
+		require.NoError(t, err)
+
+		// letter is sent in background
+		require.Eventually(t, func() bool { return outbox.Last(user.Email) != nil }, time.Second, 10*time.Millisecond)
+		letter := outbox.Last(user.Email)
+		link, err := url.Parse(letter.Body[strings.Index(letter.Body, "http"):])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+		require.NotEqual(t, token, "")
+		require.NotEqual(t, token, tokenHash)
+
+		mockTokenStorage.EXPECT().GetToken(gomock.Any(), entity.ActionResetPassword, tokenHash).Return(user.ID, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionResetPassword, tokenHash).Return(user.ID, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any()).Return(nil)
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), user.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), user.ID).Return(nil)
+
+		err = userService.ResetPassword(context.Background(), token, "87654321")
+		require.NoError(t, err)
+
+		mockTokenStorage.EXPECT().GetToken(gomock.Any(), entity.ActionResetPassword, tokenHash).Return(uuid.Nil, redis.Nil)
+
+		err = userService.ResetPassword(context.Background(), token, "87654321")
+		require.Error(t, err)
+	})
+
+	t.Run("StorageDown", func(t *testing.T) {
+		user := &entity.User{
+			ID:    uuid.New(),
+			Email: "down@gmail.com",
+		}
+
+		called := make(chan struct{})
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(user, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionResetPassword, gomock.Any(), user.ID, 60).
+			DoAndReturn(func(context.Context, string, string, uuid.UUID, int) error {
+				defer close(called)
+				return errors.New("storage is down")
+			})
+
+		// the response is the same as for an unknown email
+		err := userService.ForgotPassword(context.Background(), user)
+		require.NoError(t, err)
+		<-called
+		require.Nil(t, outbox.Last(user.Email))
+	})
+}
+
+func TestService_PasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// range file of breached "Tr0ub4dour&3"
+	sum := sha1.Sum([]byte("Tr0ub4dour&3"))
+	breachedHash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	breachedDir := t.TempDir()
+	rangeFile := "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + breachedHash[5:] + ":2253\n"
+	require.NoError(t, os.WriteFile(filepath.Join(breachedDir, breachedHash[:5]), []byte(rangeFile), 0o600))
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		PasswordPolicy: config.PasswordPolicy{
+			MinLength:    10,
+			MaxLength:    64,
+			RequireDigit: true,
+			Disallowed:   []string{"Correct1Horse"},
+			BreachedDir:  breachedDir,
+		},
+	}
+	policy, err := password.NewPolicyFromConfig(config)
+	require.NoError(t, err)
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, policy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
+
+	fieldCodes := func(t *testing.T, err error, field string) []string {
+		var validationErr httpe.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+
+		var codes []string
+		for _, fieldErr := range validationErr.Fields {
+			require.Equal(t, field, fieldErr.Field)
+			require.NotEmpty(t, fieldErr.Message)
+			codes = append(codes, fieldErr.Code)
+		}
+		return codes
+	}
+
+	t.Run("SignUp", func(t *testing.T) {
+		_, err := userService.SignUp(context.Background(), &entity.User{
+			Name:     "Pavel",
+			Email:    "Edbeermtn@gmail.com",
+			Password: "pavel-edbeermtn",
+		})
+		require.Equal(t, []string{
+			password.ViolationMissingDigit,
+			password.ViolationContainsEmail,
+			password.ViolationContainsName,
+		}, fieldCodes(t, err, "password"))
+	})
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		user := &entity.User{ID: uuid.New(), Email: "edbeermtn@gmail.com", Password: "current-password-1"}
+		require.NoError(t, user.HashPassword(testHasher))
+
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+
+		err := userService.ChangePassword(context.Background(), user.ID, "current-password-1", "correct1horse", "")
+		require.Equal(t, []string{password.ViolationDisallowed}, fieldCodes(t, err, "new_password"))
+	})
+
+	resetUser := &entity.User{ID: uuid.New(), Name: "Pavel", Email: "edbeermtn@gmail.com"}
+	resetHash := hashToken("reset-link")
+
+	t.Run("ResetBreached", func(t *testing.T) {
+		mockTokenStorage.EXPECT().GetToken(gomock.Any(), entity.ActionResetPassword, resetHash).Return(resetUser.ID, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), resetUser.ID).Return(resetUser, nil)
+
+		// rejected before the reset link is used up
+		err := userService.ResetPassword(context.Background(), "reset-link", "Tr0ub4dour&3")
+		require.Equal(t, []string{password.ViolationBreached}, fieldCodes(t, err, "password"))
+	})
+
+	t.Run("ResetTooShort", func(t *testing.T) {
+		mockTokenStorage.EXPECT().GetToken(gomock.Any(), entity.ActionResetPassword, resetHash).Return(resetUser.ID, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), resetUser.ID).Return(resetUser, nil)
+
+		err := userService.ResetPassword(context.Background(), "reset-link", "short1")
+		require.Equal(t, []string{password.ViolationTooShort}, fieldCodes(t, err, "password"))
+	})
+
+	t.Run("ResetContainsEmail", func(t *testing.T) {
+		mockTokenStorage.EXPECT().GetToken(gomock.Any(), entity.ActionResetPassword, resetHash).Return(resetUser.ID, nil).Times(2)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), resetUser.ID).Return(resetUser, nil).Times(2)
+
+		// rules of the user are checked before the link is used up too
+		err := userService.ResetPassword(context.Background(), "reset-link", "edbeermtn-2024-pass")
+		require.Equal(t, []string{password.ViolationContainsEmail}, fieldCodes(t, err, "password"))
+
+		// so the same link works with a corrected password
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionResetPassword, resetHash).Return(resetUser.ID, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), resetUser.ID, gomock.Any()).Return(nil)
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), resetUser.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().DeleteUserSessions(gomock.Any(), resetUser.ID).Return(nil)
+
+		require.NoError(t, userService.ResetPassword(context.Background(), "reset-link", "battery-staple-2024"))
+	})
+}
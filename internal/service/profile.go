@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Update name of the user
+func (u *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, name string) (*entity.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.UpdateProfile")
+	defer span.Finish()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedUser, err := u.psql.UpdateUser(ctx, &entity.User{
+		ID:    userID,
+		Name:  strings.TrimSpace(name),
+		Email: foundUser.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+// Change password of the user checking the current one, access tokens and
+// every session except the one of refresh token are revoked
+func (u *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword, refreshToken string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ChangePassword")
+	defer span.Finish()
+	defer func() { u.audit.Record(ctx, entity.AuditPasswordChange, userID, err) }()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := foundUser.ComparePassword(u.hasher, currentPassword); err != nil {
+		return httpe.NewBadRequestError(httpe.WrongPassword)
+	}
+
+	newPassword = strings.TrimSpace(newPassword)
+	if err := u.checkPassword("new_password", newPassword, foundUser); err != nil {
+		return err
+	}
+
+	user := &entity.User{Password: newPassword}
+	if err := user.HashPassword(u.hasher); err != nil {
+		return err
+	}
+	if err := u.psql.UpdatePassword(ctx, userID, user.Password); err != nil {
+		return err
+	}
+	if err := revokeUserTokens(ctx, u.revocations, userID); err != nil {
+		return err
+	}
+
+	keepSessionID := uuid.Nil
+	if refreshToken != "" {
+		if session, err := u.sessions.GetSession(ctx, refreshToken); err == nil && session.UserID == userID {
+			keepSessionID = session.FamilyID
+		}
+	}
+	if err := u.sessions.DeleteOtherSessions(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, &mail.Message{
+		To:      foundUser.Email,
+		Subject: "Your password was changed",
+		Body:    "The password of your account was changed and other devices were signed out. If it was not you, reset your password.",
+	})
+}
+
+// Send confirmation link to the new email and notify the current one,
+// email changes only after the link is followed
+func (u *UserService) RequestEmailChange(ctx context.Context, userID uuid.UUID, email, password string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.RequestEmailChange")
+	defer span.Finish()
+
+	foundUser, err := u.psql.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := foundUser.ComparePassword(u.hasher, password); err != nil {
+		return httpe.NewBadRequestError(httpe.WrongPassword)
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(email))
+	if newEmail == foundUser.Email {
+		return httpe.NewBadRequestError(httpe.ExistsEmailError)
+	}
+	if _, err := u.psql.FindUserByEmail(ctx, &entity.User{Email: newEmail}); err == nil {
+		return httpe.NewBadRequestError(httpe.ExistsEmailError)
+	}
+
+	expire := u.config.EmailChange.Expire
+	actionToken := &entity.ActionToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Email:     newEmail,
+		Action:    entity.ActionChangeEmail,
+		ExpiresAt: time.Now().Add(time.Second * time.Duration(expire)),
+	}
+
+	token, err := u.tokenManager.GenerateActionToken(actionToken)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.CreateToken(ctx, entity.ActionChangeEmail, actionToken.ID, userID, expire); err != nil {
+		return err
+	}
+
+	if err := u.mailer.Send(ctx, &mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body:    fmt.Sprintf("Follow the link to use this email for your account: %s?token=%s", u.config.EmailChange.URL, token),
+	}); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, &mail.Message{
+		To:      foundUser.Email,
+		Subject: "Email change requested",
+		Body:    fmt.Sprintf("A change of your account email to %s was requested. If it was not you, change your password.", newEmail),
+	})
+}
+
+// Change email of the user by token from confirmation letter
+func (u *UserService) ConfirmEmailChange(ctx context.Context, token string) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ConfirmEmailChange")
+	defer span.Finish()
+
+	actionToken, err := u.tokenManager.ParseActionToken(token, entity.ActionChangeEmail)
+	if err != nil {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+
+	userID, err := u.tokens.ConsumeToken(ctx, entity.ActionChangeEmail, actionToken.ID)
+	if err != nil || userID != actionToken.UserID {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+	defer func() { u.audit.Record(ctx, entity.AuditEmailChange, userID, err) }()
+
+	// the email could be registered while the letter was on its way
+	if _, err := u.psql.FindUserByEmail(ctx, &entity.User{Email: actionToken.Email}); err == nil {
+		return httpe.NewBadRequestError(httpe.ExistsEmailError)
+	}
+
+	return u.psql.UpdateEmail(ctx, userID, actionToken.Email)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/go-redis/redis/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	audit := &auditRecorder{}
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, audit, testLogger)
+
+	user := &entity.User{
+		ID:       uuid.New(),
+		Email:    "edbeermtn@gmail.com",
+		Password: "12345678",
+	}
+	require.NoError(t, user.HashPassword(testHasher))
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+
+		err := userService.ChangePassword(context.Background(), user.ID, "wrong password", "87654321", "")
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+		require.Equal(t, entity.AuditFailure, audit.Last(entity.AuditPasswordChange).Outcome)
+	})
+
+	t.Run("KeepsCurrentSession", func(t *testing.T) {
+		familyID := uuid.New()
+		var newHash string
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, password string) error {
+				newHash = password
+				return nil
+			})
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), user.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().GetSession(gomock.Any(), "refresh token").
+			Return(&entity.Session{UserID: user.ID, FamilyID: familyID}, nil)
+		mockSessionStorage.EXPECT().DeleteOtherSessions(gomock.Any(), user.ID, familyID).Return(nil)
+
+		err := userService.ChangePassword(context.Background(), user.ID, "12345678", "87654321", "refresh token")
+		require.NoError(t, err)
+		require.Equal(t, entity.AuditSuccess, audit.Last(entity.AuditPasswordChange).Outcome)
+		_, err = (&entity.User{Password: newHash}).ComparePassword(testHasher, "87654321")
+		require.NoError(t, err)
+		require.NotNil(t, outbox.Last(user.Email))
+	})
+
+	t.Run("WithoutSession", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any()).Return(nil)
+		mockRevocationStorage.EXPECT().RevokeUserTokens(gomock.Any(), user.ID, gomock.Any(), 900).Return(nil)
+		mockSessionStorage.EXPECT().DeleteOtherSessions(gomock.Any(), user.ID, uuid.Nil).Return(nil)
+
+		err := userService.ChangePassword(context.Background(), user.ID, "12345678", "87654321", "")
+		require.NoError(t, err)
+	})
+}
+
+func TestService_ChangeEmail(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		EmailChange: config.EmailChange{
+			Expire: 60,
+			URL:    "http://localhost/confirm-email-change",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		ID:       uuid.New(),
+		Email:    "edbeermtn@gmail.com",
+		Password: "12345678",
+	}
+	require.NoError(t, user.HashPassword(testHasher))
+	newEmail := "new@gmail.com"
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), &entity.User{Email: "taken@gmail.com"}).
+			Return(&entity.User{ID: uuid.New()}, nil)
+
+		err := userService.RequestEmailChange(context.Background(), user.ID, "Taken@gmail.com", "12345678")
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("RequestAndConfirm", func(t *testing.T) {
+		var tokenID string
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), &entity.User{Email: newEmail}).Return(nil, sql.ErrNoRows)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionChangeEmail, gomock.Any(), user.ID, 60).
+			DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+				tokenID = id
+				return nil
+			})
+
+		err := userService.RequestEmailChange(context.Background(), user.ID, newEmail, "12345678")
+		require.NoError(t, err)
+
+		notice := outbox.Last(user.Email)
+		require.NotNil(t, notice)
+		require.Contains(t, notice.Body, newEmail)
+
+		letter := outbox.Last(newEmail)
+		require.NotNil(t, letter)
+		link, err := url.Parse(letter.Body[strings.Index(letter.Body, "http"):])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionChangeEmail, tokenID).Return(user.ID, nil)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), &entity.User{Email: newEmail}).Return(nil, sql.ErrNoRows)
+		mockUserStorage.EXPECT().UpdateEmail(gomock.Any(), user.ID, newEmail).Return(nil)
+
+		err = userService.ConfirmEmailChange(context.Background(), token)
+		require.NoError(t, err)
+	})
+
+	t.Run("UsedToken", func(t *testing.T) {
+		token, err := manager.GenerateActionToken(&entity.ActionToken{
+			ID:        uuid.New().String(),
+			UserID:    user.ID,
+			Email:     newEmail,
+			Action:    entity.ActionChangeEmail,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionChangeEmail, gomock.Any()).Return(uuid.Nil, redis.Nil)
+
+		err = userService.ConfirmEmailChange(context.Background(), token)
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, httpe.ParseErrors(err).Status())
+	})
+}
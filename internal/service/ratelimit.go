@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Distributed rate limit storage interface
+type RateLimitStorage interface {
+	Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error)
+}
+
+// Rate limit service, quotas are shared by all replicas
+type RateLimitService struct {
+	storage RateLimitStorage
+}
+
+// New rate limit service constructor
+func newRateLimitService(storage RateLimitStorage) *RateLimitService {
+	return &RateLimitService{
+		storage: storage,
+	}
+}
+
+// Take one request from the key quota
+func (r *RateLimitService) Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RateLimitService.Allow")
+	defer span.Finish()
+
+	return r.storage.Allow(ctx, key, policy)
+}
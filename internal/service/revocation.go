@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/google/uuid"
+)
+
+// Access token revocation storage interface
+type RevocationStorage interface {
+	RevokeToken(ctx context.Context, token *entity.AccessToken) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, expire int) error
+	IsRevoked(ctx context.Context, token *entity.AccessToken) (bool, error)
+}
+
+// Invalidate every access token of the user issued so far, the watermark
+// is kept as long as the last of them can live
+func revokeUserTokens(ctx context.Context, revocations RevocationStorage, userID uuid.UUID) error {
+	return revocations.RevokeUserTokens(ctx, userID, time.Now(), int(jwt.AccessTokenTTL.Seconds()))
+}
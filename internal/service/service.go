@@ -2,14 +2,23 @@ package service
 
 import (
 	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
 	"github.com/Edbeer/Project/internal/storage/psql"
 	"github.com/Edbeer/Project/internal/storage/redis"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/mail"
 )
 
 // Services
 type Services struct {
-	User    *UserService
-	Session *SessionService
+	User      *UserService
+	Session   *SessionService
+	WebAuthn  *WebAuthnService
+	Export    *ExportService
+	Audit     *AuditService
+	RateLimit *RateLimitService
+	OAuth     *OAuthService
+	Service   *ServiceAccountService
 }
 
 // Dependencies
@@ -18,14 +27,30 @@ type Deps struct {
 	PsqlStorage  *psql.Storage
 	RedisStorage *redisrepo.Storage
 	TokenManager Manager
+	Hasher       entity.PasswordHasher
+	Policy       PasswordPolicy
+	Mailer       mail.Sender
+	Logger       logger.Logger
 }
 
 // New services constructor
 func NewServices(deps Deps) *Services {
-	userService := newUserService(deps.Config, deps.PsqlStorage.User, deps.TokenManager)
-	sessionService := NewSessionService(deps.Config, deps.RedisStorage.Session)
+	auditService := NewAuditService(deps.PsqlStorage.Audit, deps.Logger)
+	userService := newUserService(deps.Config, deps.PsqlStorage.User, deps.RedisStorage.Token, deps.RedisStorage.Session, deps.RedisStorage.Revocation, deps.RedisStorage.Throttle, deps.Hasher, deps.Policy, deps.TokenManager, deps.Mailer, auditService, deps.Logger)
+	sessionService := NewSessionService(deps.Config, deps.RedisStorage.Session, deps.RedisStorage.Revocation, deps.Logger)
+	webAuthnService := newWebAuthnService(deps.Config, deps.PsqlStorage.WebAuthn, deps.PsqlStorage.User, deps.RedisStorage.Token, deps.TokenManager)
+	exportService := newExportService(deps.PsqlStorage.User, deps.PsqlStorage.WebAuthn, deps.RedisStorage.Session, deps.PsqlStorage.Audit)
+	rateLimitService := newRateLimitService(deps.RedisStorage.RateLimit)
+	oauthService := NewOAuthService(deps.Config, deps.PsqlStorage.OAuth, deps.PsqlStorage.Service, deps.RedisStorage.OAuth, deps.RedisStorage.Token, deps.RedisStorage.Session, deps.RedisStorage.Revocation, deps.PsqlStorage.User, deps.TokenManager, auditService)
+	serviceAccountService := NewServiceAccountService(deps.Config, deps.PsqlStorage.Service, deps.PsqlStorage.User, deps.TokenManager)
 	return &Services{
-		User:    userService,
-		Session: sessionService,
+		User:      userService,
+		Session:   sessionService,
+		WebAuthn:  webAuthnService,
+		Export:    exportService,
+		Audit:     auditService,
+		RateLimit: rateLimitService,
+		OAuth:     oauthService,
+		Service:   serviceAccountService,
 	}
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/oauth"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Service accounts psql storage interface
+type ServiceAccountPsql interface {
+	CreateServiceAccount(ctx context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]*entity.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, clientID string) error
+}
+
+// Service account access token issuer interface
+type ServiceTokenManager interface {
+	GenerateServiceToken(issuer string, account *entity.ServiceAccount, scopes []string) (string, error)
+}
+
+// Service accounts of backend jobs, they sign in with the client
+// credentials grant and act with their own scopes, not as their owner
+type ServiceAccountService struct {
+	config       *config.Config
+	accounts     ServiceAccountPsql
+	users        UserPsql
+	tokenManager ServiceTokenManager
+}
+
+// New service account service constructor
+func NewServiceAccountService(config *config.Config, accounts ServiceAccountPsql, users UserPsql, tokenManager ServiceTokenManager) *ServiceAccountService {
+	return &ServiceAccountService{
+		config:       config,
+		accounts:     accounts,
+		users:        users,
+		tokenManager: tokenManager,
+	}
+}
+
+// Create service account of the owner, the secret is shown only once
+func (s *ServiceAccountService) CreateServiceAccount(ctx context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountService.CreateServiceAccount")
+	defer span.Finish()
+
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		return nil, "", errors.New("service account name is required")
+	}
+	owner, err := s.users.GetUserByID(ctx, account.OwnerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if owner.IsSuspended() || owner.IsDeleted() {
+		return nil, "", errors.New("owner is suspended or deleted")
+	}
+	account.Scopes = oauth.ParseScope(oauth.JoinScope(account.Scopes))
+	if account.ClientID == "" {
+		account.ClientID = uuid.New().String()
+	}
+
+	secret, err := token.New(token.ClientSecret)
+	if err != nil {
+		return nil, "", err
+	}
+	account.SecretHash = hashClientSecret(secret)
+
+	created, err := s.accounts.CreateServiceAccount(ctx, account)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, secret, nil
+}
+
+// Get service account by client id
+func (s *ServiceAccountService) GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountService.GetServiceAccount")
+	defer span.Finish()
+
+	return s.accounts.GetServiceAccount(ctx, clientID)
+}
+
+// Get all service accounts
+func (s *ServiceAccountService) ListServiceAccounts(ctx context.Context) ([]*entity.ServiceAccount, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountService.ListServiceAccounts")
+	defer span.Finish()
+
+	return s.accounts.ListServiceAccounts(ctx)
+}
+
+// Delete service account, its access tokens stop working as the account is unknown
+func (s *ServiceAccountService) DeleteServiceAccount(ctx context.Context, clientID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountService.DeleteServiceAccount")
+	defer span.Finish()
+
+	return s.accounts.DeleteServiceAccount(ctx, clientID)
+}
+
+// Issue access token of the client credentials grant, there is no
+// refresh token as the service account can always sign in again
+func (s *ServiceAccountService) IssueToken(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountService.IssueToken")
+	defer span.Finish()
+
+	account, err := authenticateServiceAccount(ctx, s.accounts, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.users.GetUserByID(ctx, account.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if owner.IsSuspended() || owner.IsDeleted() {
+		return nil, oauth.NewError(oauth.ErrUnauthorizedClient, "Owner of the service account is not active")
+	}
+
+	scopes := account.Scopes
+	if request.Scope != "" {
+		scopes = oauth.ParseScope(request.Scope)
+		if !oauth.ScopeAllowed(scopes, account.Scopes) {
+			return nil, oauth.NewError(oauth.ErrInvalidScope, "Scope is not allowed for the service account")
+		}
+	}
+
+	accessToken, err := s.tokenManager.GenerateServiceToken(s.config.OAuth.Issuer, account, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   oauth.TokenTypeBearer,
+		ExpiresIn:   int(jwt.AccessTokenTTL.Seconds()),
+		Scope:       oauth.JoinScope(scopes),
+	}, nil
+}
+
+func authenticateServiceAccount(ctx context.Context, accounts ServiceAccountPsql, clientID, clientSecret string) (*entity.ServiceAccount, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	account, err := accounts.GetServiceAccount(ctx, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashClientSecret(clientSecret)), []byte(account.SecretHash)) != 1 {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "Client authentication failed")
+	}
+	return account, nil
+}
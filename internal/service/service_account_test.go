@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/oauth"
+	jwtgo "github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CreateServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccountStorage := mockstorage.NewMockServiceAccountPsql(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	accountService := NewServiceAccountService(testOAuthConfig(), mockAccountStorage, mockUserStorage, nil)
+
+	owner := &entity.User{ID: uuid.New()}
+
+	t.Run("CreateServiceAccount", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(owner, nil)
+		mockAccountStorage.EXPECT().CreateServiceAccount(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, error) {
+				return account, nil
+			})
+
+		account, secret, err := accountService.CreateServiceAccount(context.Background(), &entity.ServiceAccount{
+			Name:    " Backup job ",
+			OwnerID: owner.ID,
+			Scopes:  entity.SpaceList{"users:read", "users:read"},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, account.ClientID)
+		require.Equal(t, "Backup job", account.Name)
+		require.Equal(t, []string{"users:read"}, []string(account.Scopes))
+		require.Equal(t, hashClientSecret(secret), account.SecretHash)
+	})
+
+	t.Run("SuspendedOwner", func(t *testing.T) {
+		suspendedAt := time.Now()
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(&entity.User{ID: owner.ID, SuspendedAt: &suspendedAt}, nil)
+
+		_, _, err := accountService.CreateServiceAccount(context.Background(), &entity.ServiceAccount{
+			Name:    "Backup job",
+			OwnerID: owner.ID,
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestService_IssueServiceToken(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _ := jwt.NewManager("secret")
+	mockAccountStorage := mockstorage.NewMockServiceAccountPsql(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	accountService := NewServiceAccountService(testOAuthConfig(), mockAccountStorage, mockUserStorage, manager)
+
+	owner := &entity.User{ID: uuid.New()}
+	account := &entity.ServiceAccount{
+		ClientID:   "backup-job",
+		SecretHash: hashClientSecret("client secret"),
+		Name:       "Backup job",
+		OwnerID:    owner.ID,
+		Scopes:     entity.SpaceList{"users:read", "audit:read"},
+	}
+	tokenRequest := func(scope string) *entity.TokenRequest {
+		return &entity.TokenRequest{
+			GrantType:    "client_credentials",
+			Scope:        scope,
+			ClientID:     "backup-job",
+			ClientSecret: "client secret",
+		}
+	}
+	requireCode := func(t *testing.T, err error, code string) {
+		var oauthErr *oauth.Error
+		require.True(t, errors.As(err, &oauthErr), err)
+		require.Equal(t, code, oauthErr.Code)
+	}
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(account, nil)
+
+		request := tokenRequest("")
+		request.ClientSecret = "wrong"
+		_, err := accountService.IssueToken(context.Background(), request)
+		requireCode(t, err, oauth.ErrInvalidClient)
+	})
+
+	t.Run("SuspendedOwner", func(t *testing.T) {
+		suspendedAt := time.Now()
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(account, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(&entity.User{ID: owner.ID, SuspendedAt: &suspendedAt}, nil)
+
+		_, err := accountService.IssueToken(context.Background(), tokenRequest(""))
+		requireCode(t, err, oauth.ErrUnauthorizedClient)
+	})
+
+	t.Run("WiderScope", func(t *testing.T) {
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(account, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(owner, nil)
+
+		_, err := accountService.IssueToken(context.Background(), tokenRequest("users:write"))
+		requireCode(t, err, oauth.ErrInvalidScope)
+	})
+
+	t.Run("IssueToken", func(t *testing.T) {
+		mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").Return(account, nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(owner, nil)
+
+		token, err := accountService.IssueToken(context.Background(), tokenRequest("audit:read"))
+		require.NoError(t, err)
+		require.Equal(t, "Bearer", token.TokenType)
+		require.Equal(t, "audit:read", token.Scope)
+		require.Empty(t, token.RefreshToken)
+
+		claims := &jwt.ServiceClaims{}
+		_, err = jwtgo.ParseWithClaims(token.AccessToken, claims, manager.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, entity.PrincipalService, claims.Principal)
+		require.Equal(t, "backup-job", claims.Subject)
+		require.Equal(t, "https://auth.example.com", claims.Issuer)
+	})
+}
@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/storage/redis"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/go-redis/redis/v9"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 )
@@ -13,20 +19,30 @@ import (
 type SessionStorage interface {
 	CreateSession(ctx context.Context, session *entity.Session, expire int) (string, error)
 	GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error)
+	GetSession(ctx context.Context, refreshToken string) (*entity.Session, error)
+	RotateSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error)
 	DeleteSession(ctx context.Context, refreshToken string) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*entity.SessionInfo, error)
+	DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error
 }
 
 // User service
 type SessionService struct {
-	config  *config.Config
-	session SessionStorage
+	config      *config.Config
+	session     SessionStorage
+	revocations RevocationStorage
+	logger      logger.Logger
 }
 
 // New user service constructor
-func NewSessionService(config *config.Config, session SessionStorage) *SessionService {
+func NewSessionService(config *config.Config, session SessionStorage, revocations RevocationStorage, logger logger.Logger) *SessionService {
 	return &SessionService{
-		config:  config,
-		session: session,
+		config:      config,
+		session:     session,
+		revocations: revocations,
+		logger:      logger,
 	}
 }
 
@@ -42,8 +58,108 @@ func (s *SessionService) GetUserID(ctx context.Context, refreshToken string) (uu
 	return s.session.GetUserID(ctx, refreshToken)
 }
 
+func (s *SessionService) GetSession(ctx context.Context, refreshToken string) (*entity.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.GetSession")
+	defer span.Finish()
+	return s.session.GetSession(ctx, refreshToken)
+}
+
+// Rotate refresh token, reuse of an already rotated token revokes the session family
+func (s *SessionService) RefreshSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.RefreshSession")
+	defer span.Finish()
+
+	session, err := s.session.RotateSession(ctx, session, expire)
+	switch {
+	case errors.Is(err, redisrepo.ErrSessionReused):
+		s.logger.Warnf("security event: refresh token reuse, session family revoked, user_id: %s, family_id: %s, request_id: %s",
+			session.UserID, session.FamilyID, utils.GetRequestIDFromCtx(ctx))
+		return nil, httpe.NewUnauthorizedError(httpe.SessionRevoked)
+	case errors.Is(err, redis.Nil):
+		return nil, httpe.NewUnauthorizedError(httpe.Unauthorized)
+	case err != nil:
+		return nil, err
+	}
+	return session, nil
+}
+
 func (s *SessionService) DeleteSession(ctx context.Context, refreshToken string) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.DeleteSession")
 	defer span.Finish()
 	return s.session.DeleteSession(ctx, refreshToken)
-}
\ No newline at end of file
+}
+
+func (s *SessionService) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.DeleteUserSessions")
+	defer span.Finish()
+	return s.session.DeleteUserSessions(ctx, userID)
+}
+
+// List active sessions of the user, the session of refresh token is marked as current
+func (s *SessionService) GetUserSessions(ctx context.Context, userID uuid.UUID, refreshToken string) ([]*entity.SessionInfo, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.GetUserSessions")
+	defer span.Finish()
+
+	sessions, err := s.session.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshToken != "" {
+		current, err := s.session.GetSession(ctx, refreshToken)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for _, session := range sessions {
+			session.Current = current != nil && current.FamilyID == session.ID
+		}
+	}
+	return sessions, nil
+}
+
+// Revoke session of the user by id
+func (s *SessionService) DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.DeleteSessionByID")
+	defer span.Finish()
+
+	if err := s.session.DeleteSessionByID(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return httpe.NewNotFoundError(httpe.SessionNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// Revoke all sessions of the user except the session of refresh token
+func (s *SessionService) DeleteOtherSessions(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.DeleteOtherSessions")
+	defer span.Finish()
+
+	current, err := s.session.GetSession(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return httpe.NewUnauthorizedError(httpe.Unauthorized)
+		}
+		return err
+	}
+	if current.UserID != userID {
+		return httpe.NewUnauthorizedError(httpe.Unauthorized)
+	}
+
+	return s.session.DeleteOtherSessions(ctx, userID, current.FamilyID)
+}
+
+// Revoke access token at once, it is denied until it expires
+func (s *SessionService) RevokeAccessToken(ctx context.Context, token *entity.AccessToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.RevokeAccessToken")
+	defer span.Finish()
+	return s.revocations.RevokeToken(ctx, token)
+}
+
+// Check that access token was revoked by itself or with all tokens of its subject
+func (s *SessionService) IsAccessTokenRevoked(ctx context.Context, token *entity.AccessToken) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionService.IsAccessTokenRevoked")
+	defer span.Finish()
+	return s.revocations.IsRevoked(ctx, token)
+}
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
+	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/storage/redis"
 	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/go-redis/redis/v9"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -18,7 +24,7 @@ func TestService_CreateSession(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSessionRedis := mockredis.NewMockSessionRedis(ctrl)
-	sessionService := NewSessionService(nil, mockSessionRedis)
+	sessionService := NewSessionService(nil, mockSessionRedis, nil, nil)
 
 	ctx := context.Background()
 	session := &entity.Session{}
@@ -39,7 +45,7 @@ func TestService_GetUserID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSessionRedis := mockredis.NewMockSessionRedis(ctrl)
-	sessionService := NewSessionService(nil, mockSessionRedis)
+	sessionService := NewSessionService(nil, mockSessionRedis, nil, nil)
 
 	ctx := context.Background()
 	session := &entity.Session{
@@ -63,7 +69,7 @@ func TestService_DeleteSessionByID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSessionRedis := mockredis.NewMockSessionRedis(ctrl)
-	sessionService := NewSessionService(nil, mockSessionRedis)
+	sessionService := NewSessionService(nil, mockSessionRedis, nil, nil)
 
 	ctx := context.Background()
 	rT := "refresh token"
@@ -73,4 +79,51 @@ func TestService_DeleteSessionByID(t *testing.T) {
 	err := sessionService.DeleteSession(ctx, rT)
 	require.NoError(t, err)
 	require.Nil(t, err)
-}
\ No newline at end of file
+}
+func TestService_RefreshSession(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{}
+	apiLogger := logger.NewApiLogger(config)
+	apiLogger.InitLogger()
+
+	mockSessionRedis := mockredis.NewMockSessionRedis(ctrl)
+	sessionService := NewSessionService(config, mockSessionRedis, nil, apiLogger)
+
+	ctx := context.Background()
+	presented := &entity.Session{
+		RefreshToken: "refresh token",
+	}
+	session := &entity.Session{
+		RefreshToken: "next refresh token",
+		UserID:       uuid.New(),
+		FamilyID:     uuid.New(),
+	}
+
+	t.Run("Rotate", func(t *testing.T) {
+		mockSessionRedis.EXPECT().RotateSession(gomock.Any(), presented, 10).Return(session, nil)
+
+		rotated, err := sessionService.RefreshSession(ctx, presented, 10)
+		require.NoError(t, err)
+		require.Equal(t, session.RefreshToken, rotated.RefreshToken)
+	})
+
+	t.Run("Reuse", func(t *testing.T) {
+		mockSessionRedis.EXPECT().RotateSession(gomock.Any(), presented, 10).Return(session, redisrepo.ErrSessionReused)
+
+		rotated, err := sessionService.RefreshSession(ctx, presented, 10)
+		require.Nil(t, rotated)
+		require.Equal(t, http.StatusUnauthorized, err.(httpe.RestErr).Status())
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		unknown := &entity.Session{RefreshToken: "unknown"}
+		mockSessionRedis.EXPECT().RotateSession(gomock.Any(), unknown, 10).Return(nil, redis.Nil)
+
+		_, err := sessionService.RefreshSession(ctx, unknown, 10)
+		require.Equal(t, http.StatusUnauthorized, err.(httpe.RestErr).Status())
+	})
+}
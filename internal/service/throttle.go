@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// Failed sign-in throttling storage interface
+type ThrottleStorage interface {
+	GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error)
+	RegisterFailure(ctx context.Context, key string, policy *entity.ThrottlePolicy) (*entity.LoginThrottle, error)
+	ResetThrottle(ctx context.Context, key string) error
+}
+
+// Brute-force protection is disabled without counting window
+func (u *UserService) throttleEnabled() bool {
+	return u.config.BruteForce.Window > 0
+}
+
+// Email failures back off exponentially and lock the account
+func (u *UserService) emailPolicy() *entity.ThrottlePolicy {
+	cfg := u.config.BruteForce
+	return &entity.ThrottlePolicy{
+		MaxFailures: cfg.MaxEmailFailures,
+		Window:      time.Duration(cfg.Window) * time.Second,
+		BaseDelay:   time.Duration(cfg.BaseDelay) * time.Second,
+		MaxDelay:    time.Duration(cfg.MaxDelay) * time.Second,
+		Lockout:     time.Duration(cfg.Lockout) * time.Second,
+	}
+}
+
+// IP failures only block the IP at the limit,
+// backoff would slow down every user behind a shared address
+func (u *UserService) ipPolicy() *entity.ThrottlePolicy {
+	cfg := u.config.BruteForce
+	return &entity.ThrottlePolicy{
+		MaxFailures: cfg.MaxIPFailures,
+		Window:      time.Duration(cfg.Window) * time.Second,
+		Lockout:     time.Duration(cfg.Lockout) * time.Second,
+	}
+}
+
+// Reject sign-in while email is locked or email or IP backs off
+func (u *UserService) checkSignInThrottle(ctx context.Context, email string) error {
+	if !u.throttleEnabled() {
+		return nil
+	}
+
+	emailThrottle, err := u.throttle.GetThrottle(ctx, emailThrottleKey(email))
+	if err != nil {
+		return err
+	}
+	if emailThrottle.Locked {
+		return httpe.NewLockedError(httpe.AccountLocked, emailThrottle.RetryAfter)
+	}
+
+	retryAfter := emailThrottle.RetryAfter
+	if ip := clientIP(ctx); ip != "" {
+		ipThrottle, err := u.throttle.GetThrottle(ctx, ipThrottleKey(ip))
+		if err != nil {
+			return err
+		}
+		if ipThrottle.RetryAfter > retryAfter {
+			retryAfter = ipThrottle.RetryAfter
+		}
+	}
+	if retryAfter > 0 {
+		return httpe.NewTooManyRequestsError(httpe.TooManyAttempts, retryAfter)
+	}
+
+	return nil
+}
+
+// Count failed sign-in of email and IP, user is nil for unknown email
+func (u *UserService) registerSignInFailure(ctx context.Context, email string, userID uuid.UUID) error {
+	if !u.throttleEnabled() {
+		return nil
+	}
+
+	emailThrottle, err := u.throttle.RegisterFailure(ctx, emailThrottleKey(email), u.emailPolicy())
+	if err != nil {
+		return err
+	}
+	if emailThrottle.Locked {
+		u.audit.Record(ctx, entity.AuditAccountLock, userID, nil)
+	}
+
+	if ip := clientIP(ctx); ip != "" {
+		if _, err := u.throttle.RegisterFailure(ctx, ipThrottleKey(ip), u.ipPolicy()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Forget failed sign-ins of email after successful one
+func (u *UserService) resetSignInThrottle(ctx context.Context, email string) error {
+	if !u.throttleEnabled() {
+		return nil
+	}
+	return u.throttle.ResetThrottle(ctx, emailThrottleKey(email))
+}
+
+func emailThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Client IP of the request, resolved from trusted proxies
+func clientIP(ctx context.Context) string {
+	return utils.GetClientFromCtx(ctx).IP
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_SignInThrottle(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		BruteForce: config.BruteForce{
+			Window:           900,
+			BaseDelay:        1,
+			MaxDelay:         60,
+			Lockout:          900,
+			MaxEmailFailures: 10,
+			MaxIPFailures:    100,
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	audit := &auditRecorder{}
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), audit, testLogger)
+
+	ctx := context.WithValue(context.Background(), utils.ClientCtxKey{}, utils.Client{IP: "10.0.0.1"})
+	user := &entity.User{
+		Email:    "Edbeermtn@gmail.com",
+		Password: "12345678",
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		mockThrottleStorage.EXPECT().GetThrottle(gomock.Any(), "email:edbeermtn@gmail.com").Return(&entity.LoginThrottle{
+			Locked:     true,
+			RetryAfter: time.Minute,
+		}, nil)
+
+		_, _, err := userService.SignIn(ctx, user)
+		require.Error(t, err)
+		require.Equal(t, http.StatusLocked, httpe.ParseErrors(err).Status())
+		require.Equal(t, 60, httpe.RetryAfter(err))
+	})
+
+	t.Run("BackoffIP", func(t *testing.T) {
+		mockThrottleStorage.EXPECT().GetThrottle(gomock.Any(), "email:edbeermtn@gmail.com").Return(&entity.LoginThrottle{
+			RetryAfter: time.Second,
+		}, nil)
+		mockThrottleStorage.EXPECT().GetThrottle(gomock.Any(), "ip:10.0.0.1").Return(&entity.LoginThrottle{
+			RetryAfter: 1500 * time.Millisecond,
+		}, nil)
+
+		_, _, err := userService.SignIn(ctx, user)
+		require.Error(t, err)
+		require.Equal(t, http.StatusTooManyRequests, httpe.ParseErrors(err).Status())
+		require.Equal(t, 2, httpe.RetryAfter(err))
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		mockThrottleStorage.EXPECT().GetThrottle(gomock.Any(), gomock.Any()).Return(&entity.LoginThrottle{}, nil).Times(2)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(nil, sql.ErrNoRows)
+		mockThrottleStorage.EXPECT().RegisterFailure(gomock.Any(), "email:edbeermtn@gmail.com", userService.emailPolicy()).Return(&entity.LoginThrottle{
+			Failures: 10,
+			Locked:   true,
+		}, nil)
+		mockThrottleStorage.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", userService.ipPolicy()).Return(&entity.LoginThrottle{
+			Failures: 10,
+		}, nil)
+
+		_, _, err := userService.SignIn(ctx, user)
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, httpe.ParseErrors(err).Status())
+		require.Equal(t, entity.AuditSuccess, audit.Last(entity.AuditAccountLock).Outcome)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		hashedPassword, err := testHasher.Hash(user.Password)
+		require.NoError(t, err)
+		mockUser := &entity.User{
+			ID:       uuid.New(),
+			Email:    "edbeermtn@gmail.com",
+			Password: hashedPassword,
+		}
+
+		mockThrottleStorage.EXPECT().GetThrottle(gomock.Any(), gomock.Any()).Return(&entity.LoginThrottle{}, nil).Times(2)
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(mockUser, nil)
+		mockThrottleStorage.EXPECT().ResetThrottle(gomock.Any(), "email:edbeermtn@gmail.com").Return(nil)
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), mockUser.ID).Return(nil, sql.ErrNoRows)
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), mockUser.ID).Return(&entity.Access{}, nil)
+
+		userWithToken, _, err := userService.SignIn(ctx, user)
+		require.NoError(t, err)
+		require.Equal(t, mockUser.ID, userWithToken.User.ID)
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), userID).Return(&entity.User{
+			ID:    userID,
+			Email: "Edbeermtn@gmail.com",
+		}, nil)
+		mockThrottleStorage.EXPECT().ResetThrottle(gomock.Any(), "email:edbeermtn@gmail.com").Return(nil)
+
+		err := userService.UnlockUser(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, entity.AuditSuccess, audit.Last(entity.AuditAdminUserUnlock).Outcome)
+	})
+}
@@ -2,7 +2,17 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Edbeer/Project/pkg/hash"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/mail"
 	"github.com/Edbeer/Project/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
@@ -16,7 +26,13 @@ import (
 type Manager interface {
 	GenerateJWTToken(user *entity.User) (string, error)
 	Parse(accessToken string) (string, error)
-	NewRefreshToken() string
+	GenerateActionToken(token *entity.ActionToken) (string, error)
+	ParseActionToken(tokenString, action string) (*entity.ActionToken, error)
+	GenerateOAuthToken(issuer string, grant *entity.OAuthGrant) (string, error)
+	ParseOAuthToken(issuer, tokenString string) (*entity.OAuthGrant, error)
+	GenerateIDToken(issuer string, grant *entity.OAuthGrant, user *entity.UserInfo) (string, error)
+	GenerateServiceToken(issuer string, account *entity.ServiceAccount, scopes []string) (string, error)
+	IntrospectToken(tokenString string) (*entity.Introspection, error)
 }
 
 // User psql storage interface
@@ -24,21 +40,64 @@ type UserPsql interface {
 	Create(ctx context.Context, user *entity.User) (*entity.User, error)
 	FindUserByEmail(ctx context.Context, user *entity.User) (*entity.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	MarkVerified(ctx context.Context, userID uuid.UUID) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error
+	UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error
+	CreateTOTP(ctx context.Context, userID uuid.UUID, secret string) error
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteTOTP(ctx context.Context, userID uuid.UUID) error
+	UseBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	GetUserAccess(ctx context.Context, userID uuid.UUID) (*entity.Access, error)
+	ListUsers(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, int, error)
+	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	SetDeleted(ctx context.Context, userID uuid.UUID, deleted bool) error
+	PurgeDeletedUsers(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+}
+
+// Single-use token storage interface
+type TokenStorage interface {
+	CreateToken(ctx context.Context, kind, tokenID string, userID uuid.UUID, expire int) error
+	GetToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error)
+	ConsumeToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error)
 }
 
 // User service
 type UserService struct {
 	config       *config.Config
 	psql         UserPsql
+	tokens       TokenStorage
+	sessions     SessionStorage
+	revocations  RevocationStorage
+	throttle     ThrottleStorage
+	hasher       entity.PasswordHasher
+	policy       PasswordPolicy
 	tokenManager Manager
+	mailer       mail.Sender
+	audit        Auditor
+	logger       logger.Logger
+	dummyOnce    sync.Once
+	dummyHash    string
 }
 
 // New user service constructor
-func newUserService(config *config.Config, psql UserPsql, tokenManager Manager) *UserService {
+func newUserService(config *config.Config, psql UserPsql, tokens TokenStorage, sessions SessionStorage, revocations RevocationStorage, throttle ThrottleStorage, hasher entity.PasswordHasher, policy PasswordPolicy, tokenManager Manager, mailer mail.Sender, audit Auditor, logger logger.Logger) *UserService {
 	return &UserService{
 		config:       config,
 		psql:         psql,
+		tokens:       tokens,
+		sessions:     sessions,
+		revocations:  revocations,
+		throttle:     throttle,
+		hasher:       hasher,
+		policy:       policy,
 		tokenManager: tokenManager,
+		mailer:       mailer,
+		audit:        audit,
+		logger:       logger,
 	}
 }
 
@@ -47,7 +106,12 @@ func (u *UserService) SignUp(ctx context.Context, user *entity.User) (*entity.Us
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.SignUp")
 	defer span.Finish()
 
-	if err := user.PrepareCreate(); err != nil {
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	if err := u.checkPassword("password", strings.TrimSpace(user.Password), user); err != nil {
+		return nil, err
+	}
+
+	if err := user.PrepareCreate(u.hasher); err != nil {
 		return nil, err
 	}
 
@@ -65,6 +129,11 @@ func (u *UserService) SignUp(ctx context.Context, user *entity.User) (*entity.Us
 		return nil, err
 	}
 
+	// account exists already, the letter can be requested again
+	if err := u.sendVerification(ctx, createdUser); err != nil {
+		u.logger.Errorf("verification letter, user_id: %s, error: %v", createdUser.ID, err)
+	}
+
 	accessToken, err := u.tokenManager.GenerateJWTToken(createdUser)
 	if err != nil {
 		return nil, err
@@ -76,25 +145,96 @@ func (u *UserService) SignUp(ctx context.Context, user *entity.User) (*entity.Us
 	}, nil
 }
 
-// Sign-in user
-func (u *UserService) SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, error) {
+// Sign-in user, returns MFA challenge instead of token when user has two-factor authentication enabled
+func (u *UserService) SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, *entity.MFAChallenge, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.SignIn")
 	defer span.Finish()
 	
+	if err := u.checkSignInThrottle(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+
+	// unknown email and wrong password are not distinguished
 	foundUser, err := u.psql.FindUserByEmail(ctx, user)
+	if errors.Is(err, sql.ErrNoRows) {
+		u.verifyDummyPassword(user.Password)
+		if err := u.registerSignInFailure(ctx, user.Email, uuid.Nil); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	accessToken, err := u.tokenManager.GenerateJWTToken(foundUser)
+	rehash, err := foundUser.ComparePassword(u.hasher, user.Password)
+	if errors.Is(err, hash.ErrMismatchedPassword) {
+		if err := u.registerSignInFailure(ctx, user.Email, foundUser.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if rehash {
+		u.upgradePasswordHash(ctx, foundUser, user.Password)
+	}
+
+	if err := u.resetSignInThrottle(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+
+	if u.config.Verification.Required && !foundUser.IsVerified() {
+		return nil, nil, httpe.NewForbiddenError(httpe.EmailNotVerified)
+	}
+
+	enabled, err := u.mfaEnabled(ctx, foundUser.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enabled {
+		challenge, err := u.newMFAChallenge(ctx, foundUser.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
+	}
+
+	accessToken, err := generateAccessToken(ctx, u.psql, u.tokenManager, foundUser)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return &entity.UserWithToken{
 		User:        foundUser,
 		AccessToken: accessToken,
-	}, nil
+	}, nil, nil
+}
+
+// Check password against a hash of the current algorithm on unknown email,
+// so sign-in time does not tell which emails are registered
+func (u *UserService) verifyDummyPassword(password string) {
+	u.dummyOnce.Do(func() {
+		u.dummyHash, _ = u.hasher.Hash("dummy password")
+	})
+	u.hasher.Verify(u.dummyHash, password)
+}
+
+// Replace outdated password hash after successful sign-in,
+// failed upgrade is retried on the next sign-in
+func (u *UserService) upgradePasswordHash(ctx context.Context, user *entity.User, password string) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.upgradePasswordHash")
+	defer span.Finish()
+
+	hashedPassword, err := u.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+	if err := u.psql.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return
+	}
+	user.Password = hashedPassword
 }
 
 // Get user by id
@@ -107,7 +247,7 @@ func (u *UserService) GetUserByID(ctx context.Context, userId uuid.UUID) (*entit
 		return nil, err
 	}
 
-	accessToken, err := u.tokenManager.GenerateJWTToken(foundUser)
+	accessToken, err := generateAccessToken(ctx, u.psql, u.tokenManager, foundUser)
 	if err != nil {
 		return nil, err
 	}
@@ -117,3 +257,79 @@ func (u *UserService) GetUserByID(ctx context.Context, userId uuid.UUID) (*entit
 		AccessToken: accessToken,
 	}, nil
 }
+
+// Generate access token with roles and permissions of the user, suspended and deleted users get no tokens
+func generateAccessToken(ctx context.Context, users UserPsql, tokenManager Manager, user *entity.User) (string, error) {
+	if user.IsSuspended() {
+		return "", httpe.NewForbiddenError(httpe.UserSuspended)
+	}
+	if user.IsDeleted() {
+		return "", httpe.NewForbiddenError(httpe.AccountDeleted)
+	}
+
+	access, err := users.GetUserAccess(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	user.Access = *access
+
+	return tokenManager.GenerateJWTToken(user)
+}
+
+// Verify user email by token from verification letter
+func (u *UserService) VerifyEmail(ctx context.Context, token string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.VerifyEmail")
+	defer span.Finish()
+
+	actionToken, err := u.tokenManager.ParseActionToken(token, entity.ActionVerifyEmail)
+	if err != nil {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+
+	userID, err := u.tokens.ConsumeToken(ctx, entity.ActionVerifyEmail, actionToken.ID)
+	if err != nil || userID != actionToken.UserID {
+		return httpe.NewBadRequestError(httpe.InvalidActionToken)
+	}
+
+	return u.psql.MarkVerified(ctx, userID)
+}
+
+// Send verification letter again
+func (u *UserService) ResendVerification(ctx context.Context, user *entity.User) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserService.ResendVerification")
+	defer span.Finish()
+
+	foundUser, err := u.psql.FindUserByEmail(ctx, user)
+	if err != nil || foundUser.IsVerified() {
+		// do not disclose whether the email is registered
+		return nil
+	}
+
+	return u.sendVerification(ctx, foundUser)
+}
+
+func (u *UserService) sendVerification(ctx context.Context, user *entity.User) error {
+	expire := u.config.Verification.Expire
+	actionToken := &entity.ActionToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		Action:    entity.ActionVerifyEmail,
+		ExpiresAt: time.Now().Add(time.Second * time.Duration(expire)),
+	}
+
+	token, err := u.tokenManager.GenerateActionToken(actionToken)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.CreateToken(ctx, entity.ActionVerifyEmail, actionToken.ID, user.ID, expire); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body:    fmt.Sprintf("Follow the link to confirm your email: %s?token=%s", u.config.Verification.URL, token),
+	})
+}
@@ -3,18 +3,46 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
 	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/hash"
+	"github.com/Edbeer/Project/pkg/httpe"
 	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/password"
+	"github.com/go-redis/redis/v9"
+	gojwt "github.com/golang-jwt/jwt"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Hasher of the test users, bcrypt hashes of the default cost are not upgraded
+var testHasher = hash.NewPasswordHasher(hash.NewBcrypt(bcrypt.DefaultCost), hash.NewArgon2id(hash.DefaultArgon2idParams()))
+
+// Password policy of the test users
+var testPolicy = password.NewPolicy(password.Rules{MinLength: 6}, nil)
+
+// Logger of background work failures
+var testLogger = newTestLogger()
+
+func newTestLogger() logger.Logger {
+	apiLogger := logger.NewApiLogger(&config.Config{})
+	apiLogger.InitLogger()
+	return apiLogger
+}
+
 func TestService_Register(t *testing.T) {
 	t.Parallel()
 
@@ -29,7 +57,11 @@ func TestService_Register(t *testing.T) {
 
 	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
 	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
-	userService := newUserService(config, mockUserStorage, manager)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
 
 	user := &entity.User{
 		Name:     "PavelV",
@@ -43,11 +75,26 @@ func TestService_Register(t *testing.T) {
 
 	mockUserStorage.EXPECT().FindUserByEmail(ctxWithTrace, gomock.Eq(user)).Return(nil, sql.ErrNoRows)
 	mockUserStorage.EXPECT().Create(ctxWithTrace, gomock.Eq(user)).Return(user, nil)
+	mockTokenStorage.EXPECT().CreateToken(ctxWithTrace, entity.ActionVerifyEmail, gomock.Any(), user.ID, 0).Return(nil)
 
 	createdUser, err := userService.SignUp(ctx, user)
 	require.NoError(t, err)
 	require.NotNil(t, createdUser)
 	require.Nil(t, err)
+
+	// created user is returned when the letter is not sent
+	user = &entity.User{
+		Name:     "PavelV",
+		Password: "12345678",
+		Email:    "nomail@gmail.com",
+	}
+	mockUserStorage.EXPECT().FindUserByEmail(ctxWithTrace, gomock.Eq(user)).Return(nil, sql.ErrNoRows)
+	mockUserStorage.EXPECT().Create(ctxWithTrace, gomock.Eq(user)).Return(user, nil)
+	mockTokenStorage.EXPECT().CreateToken(ctxWithTrace, entity.ActionVerifyEmail, gomock.Any(), user.ID, 0).Return(errors.New("redis is down"))
+
+	createdUser, err = userService.SignUp(ctx, user)
+	require.NoError(t, err)
+	require.NotNil(t, createdUser)
 }
 
 func TestService_SignIn(t *testing.T) {
@@ -64,7 +111,11 @@ func TestService_SignIn(t *testing.T) {
 
 	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
 	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
-	userService := newUserService(config, mockUserStorage, manager)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
 
 	user := &entity.User{
 		Password: "12345678",
@@ -84,11 +135,129 @@ func TestService_SignIn(t *testing.T) {
 	}
 
 	mockUserStorage.EXPECT().FindUserByEmail(ctxWithTrace, gomock.Eq(user)).Return(mockUser, nil)
+	mockUserStorage.EXPECT().GetTOTP(ctxWithTrace, mockUser.ID).Return(nil, sql.ErrNoRows)
+	mockUserStorage.EXPECT().GetUserAccess(ctxWithTrace, mockUser.ID).Return(&entity.Access{}, nil)
 
-	userWithToken, err := userService.SignIn(ctx, user)
+	userWithToken, challenge, err := userService.SignIn(ctx, user)
 	require.NoError(t, err)
 	require.Nil(t, err)
 	require.NotNil(t, userWithToken)
+	require.Nil(t, challenge)
+}
+
+func TestService_SignInPassword(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	argon := hash.NewArgon2id(hash.Argon2idParams{Memory: 16 * 1024, Time: 2, Threads: 1})
+	hasher := hash.NewPasswordHasher(argon, hash.NewBcrypt(bcrypt.DefaultCost))
+	counting := &countingHasher{PasswordHasher: hasher}
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, counting, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
+
+	login := &entity.User{
+		Email:    "edbeermtn@gmail.com",
+		Password: "12345678",
+	}
+	signIn := func(t *testing.T, storedHash string) (*entity.UserWithToken, error) {
+		mockUser := &entity.User{
+			ID:       uuid.New(),
+			Email:    login.Email,
+			Password: storedHash,
+		}
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(login)).Return(mockUser, nil)
+		mockUserStorage.EXPECT().GetTOTP(gomock.Any(), mockUser.ID).Return(nil, sql.ErrNoRows).AnyTimes()
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), mockUser.ID).Return(&entity.Access{}, nil).AnyTimes()
+
+		userWithToken, _, err := userService.SignIn(context.Background(), login)
+		return userWithToken, err
+	}
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(login)).Return(nil, sql.ErrNoRows)
+
+		// the password is checked as for a registered email
+		verified := counting.verified
+		_, _, err := userService.SignIn(context.Background(), login)
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, httpe.ParseErrors(err).Status())
+		require.Equal(t, verified+1, counting.verified)
+		require.True(t, strings.HasPrefix(userService.dummyHash, "$argon2id$v=19$m=16384,t=2,p=1$"))
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		storedHash, err := argon.Hash("87654321")
+		require.NoError(t, err)
+
+		userWithToken, err := signIn(t, storedHash)
+		require.Error(t, err)
+		require.Nil(t, userWithToken)
+		require.Equal(t, http.StatusUnauthorized, httpe.ParseErrors(err).Status())
+	})
+
+	t.Run("CurrentHash", func(t *testing.T) {
+		storedHash, err := argon.Hash(login.Password)
+		require.NoError(t, err)
+
+		userWithToken, err := signIn(t, storedHash)
+		require.NoError(t, err)
+		require.Equal(t, storedHash, userWithToken.User.Password)
+	})
+
+	t.Run("UpgradeAlgorithm", func(t *testing.T) {
+		storedHash, err := bcrypt.GenerateFromPassword([]byte(login.Password), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		var upgradedHash string
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, password string) error {
+				upgradedHash = password
+				return nil
+			})
+
+		_, err = signIn(t, string(storedHash))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(upgradedHash, "$argon2id$v=19$m=16384,t=2,p=1$"))
+
+		rehash, err := hasher.Verify(upgradedHash, login.Password)
+		require.NoError(t, err)
+		require.False(t, rehash)
+	})
+
+	t.Run("UpgradeParams", func(t *testing.T) {
+		weak := hash.NewArgon2id(hash.Argon2idParams{Memory: 8 * 1024, Time: 1, Threads: 1})
+		storedHash, err := weak.Hash(login.Password)
+		require.NoError(t, err)
+
+		mockUserStorage.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Not(storedHash)).Return(nil)
+
+		_, err = signIn(t, storedHash)
+		require.NoError(t, err)
+	})
+}
+
+// Hasher counting password checks
+type countingHasher struct {
+	entity.PasswordHasher
+	verified int
+}
+
+func (h *countingHasher) Verify(encoded, password string) (bool, error) {
+	h.verified++
+	return h.PasswordHasher.Verify(encoded, password)
 }
 
 func TestService_GetUserByID(t *testing.T) {
@@ -105,7 +274,11 @@ func TestService_GetUserByID(t *testing.T) {
 
 	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
 	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
-	userService := newUserService(config, mockUserStorage, manager)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
 
 	user := &entity.User{
 		Password: "12345678",
@@ -116,10 +289,130 @@ func TestService_GetUserByID(t *testing.T) {
 	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "UserService.SignUp")
 	defer span.Finish()
 
+	access := &entity.Access{
+		Roles:       []string{entity.RoleAdmin},
+		Permissions: []string{entity.PermissionUsersRead, entity.PermissionUsersWrite},
+	}
 	mockUserStorage.EXPECT().GetUserByID(ctxWithTrace, gomock.Eq(user.ID)).Return(user, nil)
+	mockUserStorage.EXPECT().GetUserAccess(ctxWithTrace, gomock.Eq(user.ID)).Return(access, nil)
 
 	u, err := userService.GetUserByID(ctx, user.ID)
 	require.NoError(t, err)
 	require.Nil(t, err)
 	require.NotNil(t, u)
+	require.True(t, u.User.HasPermission(entity.PermissionUsersRead))
+
+	// roles and permissions are embedded into the access token
+	claims := &jwt.Claims{}
+	_, err = gojwt.ParseWithClaims(u.AccessToken, claims, manager.Keyfunc)
+	require.NoError(t, err)
+	require.Equal(t, access.Roles, claims.Roles)
+	require.Equal(t, access.Permissions, claims.Permissions)
+}
+
+func TestService_SignInNotVerified(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		Verification: config.Verification{
+			Required: true,
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, mail.NewOutbox(), &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		Password: "12345678",
+		Email:    "edbeermtn@gmail.com",
+	}
+
+	mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(&entity.User{
+		Email: user.Email,
+	}, nil)
+
+	userWithToken, challenge, err := userService.SignIn(context.Background(), user)
+	require.Error(t, err)
+	require.Nil(t, userWithToken)
+	require.Nil(t, challenge)
+}
+
+func TestService_VerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		Verification: config.Verification{
+			Expire: 60,
+			URL:    "http://localhost/verify-email",
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	mockSessionStorage := mockredis.NewMockSessionRedis(ctrl)
+	mockRevocationStorage := mockredis.NewMockRevocationRedis(ctrl)
+	mockThrottleStorage := mockredis.NewMockThrottleRedis(ctrl)
+	outbox := mail.NewOutbox()
+	userService := newUserService(config, mockUserStorage, mockTokenStorage, mockSessionStorage, mockRevocationStorage, mockThrottleStorage, testHasher, testPolicy, manager, outbox, &auditRecorder{}, testLogger)
+
+	user := &entity.User{
+		ID:    uuid.New(),
+		Email: "edbeermtn@gmail.com",
+	}
+
+	var tokenID string
+	mockUserStorage.EXPECT().FindUserByEmail(gomock.Any(), gomock.Eq(user)).Return(user, nil)
+	mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionVerifyEmail, gomock.Any(), user.ID, 60).
+		DoAndReturn(func(_ context.Context, _, id string, _ uuid.UUID, _ int) error {
+			tokenID = id
+			return nil
+		})
+
+	err := userService.ResendVerification(context.Background(), user)
+	require.NoError(t, err)
+
+	letter := outbox.Last(user.Email)
+	require.NotNil(t, letter)
+	link, err := url.Parse(letter.Body[strings.Index(letter.Body, "http"):])
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+	require.NotEqual(t, token, "")
+
+	t.Run("Verify", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionVerifyEmail, tokenID).Return(user.ID, nil)
+		mockUserStorage.EXPECT().MarkVerified(gomock.Any(), user.ID).Return(nil)
+
+		err := userService.VerifyEmail(context.Background(), token)
+		require.NoError(t, err)
+	})
+
+	t.Run("AlreadyUsed", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionVerifyEmail, tokenID).Return(uuid.Nil, redis.Nil)
+
+		err := userService.VerifyEmail(context.Background(), token)
+		require.Error(t, err)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		err := userService.VerifyEmail(context.Background(), token+"x")
+		require.Error(t, err)
+	})
 }
\ No newline at end of file
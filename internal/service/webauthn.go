@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/webauthn"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// WebAuthn psql storage interface
+type WebAuthnPsql interface {
+	CreateCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error)
+	GetCredential(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error)
+	GetUserCredentials(ctx context.Context, userID uuid.UUID) ([]*entity.WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, credentialID []byte, oldCount, newCount int64) error
+}
+
+// WebAuthn relying party service
+type WebAuthnService struct {
+	config       *config.Config
+	rp           *webauthn.RelyingParty
+	psql         WebAuthnPsql
+	users        UserPsql
+	tokens       TokenStorage
+	tokenManager Manager
+}
+
+// New webauthn service constructor
+func newWebAuthnService(config *config.Config, psql WebAuthnPsql, users UserPsql, tokens TokenStorage, tokenManager Manager) *WebAuthnService {
+	return &WebAuthnService{
+		config: config,
+		rp: &webauthn.RelyingParty{
+			ID:               config.WebAuthn.RPID,
+			Name:             config.WebAuthn.RPName,
+			Origins:          config.WebAuthn.Origins,
+			UserVerification: config.WebAuthn.UserVerification,
+			Timeout:          config.WebAuthn.Timeout,
+		},
+		psql:         psql,
+		users:        users,
+		tokens:       tokens,
+		tokenManager: tokenManager,
+	}
+}
+
+// Start passkey registration for signed in user
+func (w *WebAuthnService) BeginRegistration(ctx context.Context, userID uuid.UUID) (*webauthn.CreationOptions, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnService.BeginRegistration")
+	defer span.Finish()
+
+	user, err := w.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := w.psql.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.tokens.CreateToken(ctx, entity.ActionWebAuthnReg, challenge, userID, w.rp.Timeout); err != nil {
+		return nil, err
+	}
+
+	return w.rp.CreationOptions(challenge, webauthn.UserEntity{
+		ID:          webauthn.Encode(userID[:]),
+		Name:        user.Email,
+		DisplayName: user.Name,
+	}, descriptors(credentials)), nil
+}
+
+// Finish passkey registration, verifies attestation response and stores credential
+func (w *WebAuthnService) FinishRegistration(ctx context.Context, userID uuid.UUID, name string, response *webauthn.AttestationResponse) (*entity.WebAuthnCredential, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnService.FinishRegistration")
+	defer span.Finish()
+
+	rawClientData, err := webauthn.Decode(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, httpe.NewBadRequestError(webauthn.ErrInvalidClientData)
+	}
+	clientData, err := webauthn.ParseClientData(rawClientData)
+	if err != nil {
+		return nil, httpe.NewBadRequestError(err)
+	}
+
+	challengeUserID, err := w.tokens.ConsumeToken(ctx, entity.ActionWebAuthnReg, clientData.Challenge)
+	if err != nil || challengeUserID != userID {
+		return nil, httpe.NewBadRequestError(httpe.InvalidChallenge)
+	}
+
+	if err := w.rp.VerifyClientData(clientData, webauthn.TypeCreate); err != nil {
+		return nil, httpe.NewBadRequestError(err)
+	}
+
+	rawAttestation, err := webauthn.Decode(response.Response.AttestationObject)
+	if err != nil {
+		return nil, httpe.NewBadRequestError(webauthn.ErrInvalidCBOR)
+	}
+	_, authData, err := webauthn.ParseAttestationObject(rawAttestation)
+	if err != nil {
+		return nil, httpe.NewBadRequestError(err)
+	}
+	if err := w.rp.VerifyAuthenticatorData(authData); err != nil {
+		return nil, httpe.NewBadRequestError(err)
+	}
+	if !authData.Has(webauthn.FlagAttestedCredential) {
+		return nil, httpe.NewBadRequestError(webauthn.ErrInvalidAuthData)
+	}
+	if _, _, err := webauthn.ParsePublicKey(authData.PublicKey); err != nil {
+		return nil, httpe.NewBadRequestError(err)
+	}
+
+	if _, err := w.psql.GetCredential(ctx, authData.CredentialID); err == nil {
+		return nil, httpe.NewBadRequestError(httpe.CredentialExists)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return w.psql.CreateCredential(ctx, &entity.WebAuthnCredential{
+		ID:        authData.CredentialID,
+		UserID:    userID,
+		PublicKey: authData.PublicKey,
+		SignCount: int64(authData.SignCount),
+		AAGUID:    authData.AAGUID,
+		Name:      strings.TrimSpace(name),
+	})
+}
+
+// Start passkey login, email is optional for discoverable credentials
+func (w *WebAuthnService) BeginLogin(ctx context.Context, user *entity.User) (*webauthn.RequestOptions, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnService.BeginLogin")
+	defer span.Finish()
+
+	userID := uuid.Nil
+	allow := []webauthn.CredentialDescriptor{}
+	if user.Email != "" {
+		// unknown email gets the same response as a user without passkeys
+		if foundUser, err := w.users.FindUserByEmail(ctx, user); err == nil {
+			credentials, err := w.psql.GetUserCredentials(ctx, foundUser.ID)
+			if err != nil {
+				return nil, err
+			}
+			userID = foundUser.ID
+			allow = descriptors(credentials)
+		}
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.tokens.CreateToken(ctx, entity.ActionWebAuthnLogin, challenge, userID, w.rp.Timeout); err != nil {
+		return nil, err
+	}
+
+	return w.rp.RequestOptions(challenge, allow), nil
+}
+
+// Finish passkey login, verifies assertion and returns user with access token
+func (w *WebAuthnService) FinishLogin(ctx context.Context, response *webauthn.AssertionResponse) (*entity.UserWithToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnService.FinishLogin")
+	defer span.Finish()
+
+	rawClientData, err := webauthn.Decode(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(webauthn.ErrInvalidClientData)
+	}
+	clientData, err := webauthn.ParseClientData(rawClientData)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(err)
+	}
+
+	challengeUserID, err := w.tokens.ConsumeToken(ctx, entity.ActionWebAuthnLogin, clientData.Challenge)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(httpe.InvalidChallenge)
+	}
+
+	if err := w.rp.VerifyClientData(clientData, webauthn.TypeGet); err != nil {
+		return nil, httpe.NewUnauthorizedError(err)
+	}
+
+	credentialID, err := webauthn.Decode(response.ID)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+	}
+	credential, err := w.psql.GetCredential(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+		}
+		return nil, err
+	}
+	if challengeUserID != uuid.Nil && challengeUserID != credential.UserID {
+		return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+	}
+	if response.Response.UserHandle != "" {
+		userHandle, err := webauthn.Decode(response.Response.UserHandle)
+		if err != nil || string(userHandle) != string(credential.UserID[:]) {
+			return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+		}
+	}
+
+	rawAuthData, err := webauthn.Decode(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(webauthn.ErrInvalidAuthData)
+	}
+	authData, err := webauthn.ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(err)
+	}
+	if err := w.rp.VerifyAuthenticatorData(authData); err != nil {
+		return nil, httpe.NewUnauthorizedError(err)
+	}
+
+	signature, err := webauthn.Decode(response.Response.Signature)
+	if err != nil {
+		return nil, httpe.NewUnauthorizedError(webauthn.ErrBadSignature)
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := make([]byte, 0, len(rawAuthData)+len(clientDataHash))
+	signed = append(append(signed, rawAuthData...), clientDataHash[:]...)
+	if err := webauthn.VerifySignature(credential.PublicKey, signed, signature); err != nil {
+		return nil, httpe.NewUnauthorizedError(err)
+	}
+
+	// a counter that does not grow means a cloned authenticator,
+	// authenticators without counter always report zero
+	signCount := int64(authData.SignCount)
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+	}
+	if err := w.psql.UpdateSignCount(ctx, credential.ID, credential.SignCount, signCount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpe.NewUnauthorizedError(httpe.WrongCredentials)
+		}
+		return nil, err
+	}
+
+	foundUser, err := w.users.GetUserByID(ctx, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if w.config.Verification.Required && !foundUser.IsVerified() {
+		return nil, httpe.NewForbiddenError(httpe.EmailNotVerified)
+	}
+
+	accessToken, err := generateAccessToken(ctx, w.users, w.tokenManager, foundUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.UserWithToken{
+		User:        foundUser,
+		AccessToken: accessToken,
+	}, nil
+}
+
+func descriptors(credentials []*entity.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	result := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		result = append(result, webauthn.CredentialDescriptor{
+			Type: "public-key",
+			ID:   webauthn.Encode(c.ID),
+		})
+	}
+	return result
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	mockredis "github.com/Edbeer/Project/internal/storage/redis/mock"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/webauthn"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// software authenticator with a P-256 key
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &testAuthenticator{key: key, credentialID: credentialID}
+}
+
+func cborHead(major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 256:
+		return []byte{major<<5 | 24, byte(n)}
+	default:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	}
+}
+
+func cborInt(n int) []byte {
+	if n < 0 {
+		return cborHead(1, -1-n)
+	}
+	return cborHead(0, n)
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, len(b)), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, len(s)), s...)
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	key := cborHead(5, 5)
+	key = append(key, cborInt(1)...)
+	key = append(key, cborInt(2)...)
+	key = append(key, cborInt(3)...)
+	key = append(key, cborInt(-7)...)
+	key = append(key, cborInt(-1)...)
+	key = append(key, cborInt(1)...)
+	key = append(key, cborInt(-2)...)
+	key = append(key, cborBytes(x)...)
+	key = append(key, cborInt(-3)...)
+	key = append(key, cborBytes(y)...)
+	return key
+}
+
+func (a *testAuthenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := webauthn.FlagUserPresent | webauthn.FlagUserVerified
+	if attested {
+		flags |= webauthn.FlagAttestedCredential
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, origin string) []byte {
+	raw, err := json.Marshal(&webauthn.ClientData{
+		Type:      ceremony,
+		Challenge: challenge,
+		Origin:    origin,
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+func (a *testAuthenticator) create(t *testing.T, rpID, origin, challenge string) *webauthn.AttestationResponse {
+	attestation := cborHead(5, 3)
+	attestation = append(attestation, cborText("fmt")...)
+	attestation = append(attestation, cborText("none")...)
+	attestation = append(attestation, cborText("attStmt")...)
+	attestation = append(attestation, cborHead(5, 0)...)
+	attestation = append(attestation, cborText("authData")...)
+	attestation = append(attestation, cborBytes(a.authData(rpID, true))...)
+
+	response := &webauthn.AttestationResponse{
+		ID:   webauthn.Encode(a.credentialID),
+		Type: "public-key",
+	}
+	response.Response.ClientDataJSON = webauthn.Encode(clientDataJSON(t, webauthn.TypeCreate, challenge, origin))
+	response.Response.AttestationObject = webauthn.Encode(attestation)
+	return response
+}
+
+func (a *testAuthenticator) get(t *testing.T, rpID, origin, challenge string) *webauthn.AssertionResponse {
+	a.signCount++
+	authData := a.authData(rpID, false)
+	clientData := clientDataJSON(t, webauthn.TypeGet, challenge, origin)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response := &webauthn.AssertionResponse{
+		ID:   webauthn.Encode(a.credentialID),
+		Type: "public-key",
+	}
+	response.Response.ClientDataJSON = webauthn.Encode(clientData)
+	response.Response.AuthenticatorData = webauthn.Encode(authData)
+	response.Response.Signature = webauthn.Encode(signature)
+	return response
+}
+
+func TestService_WebAuthn(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Server: config.Server{
+			JwtSecretKey: "secret",
+		},
+		WebAuthn: config.WebAuthn{
+			RPID:             "localhost",
+			RPName:           "Auth App",
+			Origins:          []string{"http://localhost:8080"},
+			UserVerification: webauthn.VerificationPreferred,
+			Timeout:          300,
+		},
+	}
+
+	manager, _ := jwt.NewManager(config.Server.JwtSecretKey)
+	mockWebAuthnStorage := mockstorage.NewMockWebAuthnPsql(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockTokenStorage := mockredis.NewMockTokenRedis(ctrl)
+	webauthnService := newWebAuthnService(config, mockWebAuthnStorage, mockUserStorage, mockTokenStorage, manager)
+
+	user := &entity.User{
+		ID:    uuid.New(),
+		Name:  "Pavel",
+		Email: "edbeermtn@gmail.com",
+	}
+	authenticator := newTestAuthenticator(t)
+	origin := config.WebAuthn.Origins[0]
+	var credential *entity.WebAuthnCredential
+
+	t.Run("Register", func(t *testing.T) {
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockWebAuthnStorage.EXPECT().GetUserCredentials(gomock.Any(), user.ID).Return(nil, nil)
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionWebAuthnReg, gomock.Any(), user.ID, 300).Return(nil)
+
+		options, err := webauthnService.BeginRegistration(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, "localhost", options.RP.ID)
+
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionWebAuthnReg, options.Challenge).Return(user.ID, nil)
+		mockWebAuthnStorage.EXPECT().GetCredential(gomock.Any(), authenticator.credentialID).Return(nil, sql.ErrNoRows)
+		mockWebAuthnStorage.EXPECT().CreateCredential(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, c *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+				credential = c
+				return c, nil
+			})
+
+		response := authenticator.create(t, "localhost", origin, options.Challenge)
+		created, err := webauthnService.FinishRegistration(context.Background(), user.ID, " laptop ", response)
+		require.NoError(t, err)
+		require.Equal(t, "laptop", created.Name)
+		require.Equal(t, authenticator.credentialID, created.ID)
+	})
+
+	t.Run("WrongOrigin", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionWebAuthnReg, "challenge").Return(user.ID, nil)
+
+		response := authenticator.create(t, "localhost", "http://evil.com", "challenge")
+		_, err := webauthnService.FinishRegistration(context.Background(), user.ID, "laptop", response)
+		require.Error(t, err)
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		mockTokenStorage.EXPECT().CreateToken(gomock.Any(), entity.ActionWebAuthnLogin, gomock.Any(), uuid.Nil, 300).Return(nil)
+
+		options, err := webauthnService.BeginLogin(context.Background(), &entity.User{})
+		require.NoError(t, err)
+
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionWebAuthnLogin, options.Challenge).Return(uuid.Nil, nil)
+		mockWebAuthnStorage.EXPECT().GetCredential(gomock.Any(), authenticator.credentialID).Return(credential, nil)
+		mockWebAuthnStorage.EXPECT().UpdateSignCount(gomock.Any(), authenticator.credentialID, int64(0), int64(1)).Return(nil)
+		mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserStorage.EXPECT().GetUserAccess(gomock.Any(), user.ID).Return(&entity.Access{}, nil)
+
+		response := authenticator.get(t, "localhost", origin, options.Challenge)
+		userWithToken, err := webauthnService.FinishLogin(context.Background(), response)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, userWithToken.User.ID)
+		require.NotEqual(t, "", userWithToken.AccessToken)
+		credential.SignCount = 1
+	})
+
+	t.Run("ClonedAuthenticator", func(t *testing.T) {
+		authenticator.signCount = 0
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionWebAuthnLogin, "challenge").Return(uuid.Nil, nil)
+		mockWebAuthnStorage.EXPECT().GetCredential(gomock.Any(), authenticator.credentialID).Return(credential, nil)
+
+		response := authenticator.get(t, "localhost", origin, "challenge")
+		_, err := webauthnService.FinishLogin(context.Background(), response)
+		require.Error(t, err)
+	})
+
+	t.Run("BadSignature", func(t *testing.T) {
+		mockTokenStorage.EXPECT().ConsumeToken(gomock.Any(), entity.ActionWebAuthnLogin, "challenge").Return(uuid.Nil, nil)
+		mockWebAuthnStorage.EXPECT().GetCredential(gomock.Any(), authenticator.credentialID).Return(credential, nil)
+
+		response := authenticator.get(t, "localhost", origin, "challenge")
+		response.Response.Signature = webauthn.Encode([]byte("signature"))
+		_, err := webauthnService.FinishLogin(context.Background(), response)
+		require.Error(t, err)
+	})
+}
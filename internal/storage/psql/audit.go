@@ -0,0 +1,144 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Audit log psql storage, the table is append-only
+type AuditStorage struct {
+	psql *sqlx.DB
+}
+
+// New audit storage constructor
+func newAuditStorage(psql *sqlx.DB) *AuditStorage {
+	return &AuditStorage{psql: psql}
+}
+
+const auditColumns = `seq, event, user_id, ip, user_agent, request_id, outcome, created_at, prev_hash, hash`
+
+// Append event chained to the newest one, sets sequence number, time and hashes of the event
+func (r *AuditStorage) AppendEvent(ctx context.Context, event *entity.AuditEvent) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditPsql.AppendEvent")
+	defer span.Finish()
+
+	tx, err := r.psql.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "AuditStoragePsql.AppendEvent.BeginTxx")
+	}
+	defer tx.Rollback()
+
+	// appends are serialized so every event is chained to the one before it, reads are not blocked
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_log IN EXCLUSIVE MODE`); err != nil {
+		return errors.Wrap(err, "AuditStoragePsql.AppendEvent.Lock")
+	}
+
+	head := &entity.AuditEvent{Hash: entity.AuditGenesisHash}
+	query := `SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`
+	if err := tx.GetContext(ctx, head, query); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrap(err, "AuditStoragePsql.AppendEvent.Head")
+	}
+
+	event.Seq = head.Seq + 1
+	event.PrevHash = head.Hash
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	event.Hash = event.Digest()
+
+	query = `INSERT INTO audit_log (` + auditColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	if _, err := tx.ExecContext(ctx, query,
+		event.Seq, event.Event, event.UserID, event.IP, event.UserAgent, event.RequestID,
+		event.Outcome, event.CreatedAt, event.PrevHash, event.Hash,
+	); err != nil {
+		return errors.Wrap(err, "AuditStoragePsql.AppendEvent.Insert")
+	}
+
+	return errors.Wrap(tx.Commit(), "AuditStoragePsql.AppendEvent.Commit")
+}
+
+// List events page by filter, newest first, with total count of matching events
+func (r *AuditStorage) ListEvents(ctx context.Context, filter *entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditPsql.ListEvents")
+	defer span.Finish()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Event != "" {
+		args = append(args, filter.Event)
+		conditions = append(conditions, fmt.Sprintf("event = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.psql.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_log"+where, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "AuditStoragePsql.ListEvents.Count")
+	}
+
+	args = append(args, filter.Size, (filter.Page-1)*filter.Size)
+	query := fmt.Sprintf(`SELECT %s
+		FROM audit_log%s
+		ORDER BY seq DESC
+		LIMIT $%d OFFSET $%d`, auditColumns, where, len(args)-1, len(args))
+	events := []*entity.AuditEvent{}
+	if err := r.psql.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "AuditStoragePsql.ListEvents.SelectContext")
+	}
+	return events, total, nil
+}
+
+// Get all events of the user, oldest first
+func (r *AuditStorage) GetUserEvents(ctx context.Context, userID uuid.UUID) ([]*entity.AuditEvent, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditPsql.GetUserEvents")
+	defer span.Finish()
+
+	query := `SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE user_id = $1
+		ORDER BY seq`
+	events := []*entity.AuditEvent{}
+	if err := r.psql.SelectContext(ctx, &events, query, userID); err != nil {
+		return nil, errors.Wrap(err, "AuditStoragePsql.GetUserEvents.SelectContext")
+	}
+	return events, nil
+}
+
+// Get events after the sequence number in chain order
+func (r *AuditStorage) ScanEvents(ctx context.Context, afterSeq int64, limit int) ([]*entity.AuditEvent, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditPsql.ScanEvents")
+	defer span.Finish()
+
+	query := `SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2`
+	events := []*entity.AuditEvent{}
+	if err := r.psql.SelectContext(ctx, &events, query, afterSeq, limit); err != nil {
+		return nil, errors.Wrap(err, "AuditStoragePsql.ScanEvents.SelectContext")
+	}
+	return events, nil
+}
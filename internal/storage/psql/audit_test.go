@@ -0,0 +1,97 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AppendEvent(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	auditStorage := newAuditStorage(sqlxDB)
+
+	headQuery := `SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`
+	insertQuery := `INSERT INTO audit_log (seq, event, user_id, ip, user_agent, request_id, outcome, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	t.Run("First", func(t *testing.T) {
+		event := &entity.AuditEvent{Event: entity.AuditSignUp, Outcome: entity.AuditSuccess}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE audit_log IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(headQuery).WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(insertQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := auditStorage.AppendEvent(context.Background(), event)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), event.Seq)
+		require.Equal(t, entity.AuditGenesisHash, event.PrevHash)
+		require.Equal(t, event.Digest(), event.Hash)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Chained", func(t *testing.T) {
+		userID := uuid.New()
+		event := &entity.AuditEvent{Event: entity.AuditSignIn, UserID: &userID, Outcome: entity.AuditSuccess}
+		headHash := "ab" + entity.AuditGenesisHash[2:]
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE audit_log IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(headQuery).WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}).AddRow(41, headHash))
+		mock.ExpectExec(insertQuery).
+			WithArgs(int64(42), entity.AuditSignIn, &userID, "", "", "", entity.AuditSuccess, sqlmock.AnyArg(), headHash, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := auditStorage.AppendEvent(context.Background(), event)
+		require.NoError(t, err)
+		require.Equal(t, int64(42), event.Seq)
+		require.Equal(t, headHash, event.PrevHash)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_ScanEvents(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	auditStorage := newAuditStorage(sqlxDB)
+
+	t.Run("ScanEvents", func(t *testing.T) {
+		query := `SELECT seq, event, user_id, ip, user_agent, request_id, outcome, created_at, prev_hash, hash
+		FROM audit_log
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2`
+		rows := sqlmock.NewRows([]string{"seq", "event", "outcome"}).
+			AddRow(11, entity.AuditSignIn, entity.AuditSuccess).
+			AddRow(12, entity.AuditSignOut, entity.AuditSuccess)
+		mock.ExpectQuery(query).WithArgs(int64(10), 100).WillReturnRows(rows)
+
+		events, err := auditStorage.ScanEvents(context.Background(), 10, 100)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, int64(12), events[1].Seq)
+	})
+}
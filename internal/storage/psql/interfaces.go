@@ -3,6 +3,7 @@ package psql
 
 import (
 	"context"
+	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/google/uuid"
@@ -11,6 +12,55 @@ import (
 // User psql storage interface
 type UserPsql interface {
 	Create(ctx context.Context, user *entity.User) (*entity.User, error)
+	ImportUser(ctx context.Context, user *entity.User) (bool, error)
 	FindUserByEmail(ctx context.Context, user *entity.User) (*entity.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	MarkVerified(ctx context.Context, userID uuid.UUID) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error
+	UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error
+	CreateTOTP(ctx context.Context, userID uuid.UUID, secret string) error
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteTOTP(ctx context.Context, userID uuid.UUID) error
+	UseBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	GetUserAccess(ctx context.Context, userID uuid.UUID) (*entity.Access, error)
+	ListUsers(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, int, error)
+	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	SetDeleted(ctx context.Context, userID uuid.UUID, deleted bool) error
+	PurgeDeletedUsers(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+}
+
+// WebAuthn psql storage interface
+type WebAuthnPsql interface {
+	CreateCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error)
+	GetCredential(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error)
+	GetUserCredentials(ctx context.Context, userID uuid.UUID) ([]*entity.WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, credentialID []byte, oldCount, newCount int64) error
+}
+
+// Audit log psql storage interface
+type AuditPsql interface {
+	AppendEvent(ctx context.Context, event *entity.AuditEvent) error
+	ListEvents(ctx context.Context, filter *entity.AuditFilter) ([]*entity.AuditEvent, int, error)
+	GetUserEvents(ctx context.Context, userID uuid.UUID) ([]*entity.AuditEvent, error)
+	ScanEvents(ctx context.Context, afterSeq int64, limit int) ([]*entity.AuditEvent, error)
+}
+
+// OAuth clients psql storage interface
+type OAuthPsql interface {
+	CreateClient(ctx context.Context, client *entity.OAuthClient) (*entity.OAuthClient, error)
+	GetClient(ctx context.Context, clientID string) (*entity.OAuthClient, error)
+	ListClients(ctx context.Context) ([]*entity.OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID string) error
+}
+
+// Service accounts psql storage interface
+type ServiceAccountPsql interface {
+	CreateServiceAccount(ctx context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]*entity.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, clientID string) error
 }
\ No newline at end of file
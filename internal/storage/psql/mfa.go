@@ -0,0 +1,149 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Create or replace not confirmed user TOTP secret
+func (r *UserStorage) CreateTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.CreateTOTP")
+	defer span.Finish()
+
+	query := `INSERT INTO user_totp (user_id, secret, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+		WHERE user_totp.confirmed_at IS NULL`
+	result, err := r.psql.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.CreateTOTP.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.CreateTOTP.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.New("UserStoragePsql.CreateTOTP: totp already enabled")
+	}
+	return nil
+}
+
+// Get user TOTP secret
+func (r *UserStorage) GetTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.GetTOTP")
+	defer span.Finish()
+
+	totp := &entity.TOTP{}
+	query := `SELECT user_id, secret, confirmed_at, last_step, created_at
+		FROM user_totp
+		WHERE user_id = $1`
+	if err := r.psql.QueryRowxContext(ctx, query, userID).StructScan(totp); err != nil {
+		return nil, errors.Wrap(err, "UserStoragePsql.GetTOTP.StructScan")
+	}
+	return totp, nil
+}
+
+// Confirm user TOTP and replace backup codes
+func (r *UserStorage) ConfirmTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.ConfirmTOTP")
+	defer span.Finish()
+
+	tx, err := r.psql.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.ConfirmTOTP.BeginTxx")
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE user_totp
+		SET confirmed_at = now()
+		WHERE user_id = $1`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return errors.Wrap(err, "UserStoragePsql.ConfirmTOTP.Update")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_backup_codes WHERE user_id = $1`, userID); err != nil {
+		return errors.Wrap(err, "UserStoragePsql.ConfirmTOTP.Delete")
+	}
+
+	query = `INSERT INTO user_backup_codes (user_id, code_hash, created_at)
+		VALUES ($1, $2, now())`
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+			return errors.Wrap(err, "UserStoragePsql.ConfirmTOTP.Insert")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "UserStoragePsql.ConfirmTOTP.Commit")
+}
+
+// Accept TOTP time step once, returns sql.ErrNoRows for a step
+// not greater than the last accepted one
+func (r *UserStorage) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.UseTOTPStep")
+	defer span.Finish()
+
+	query := `UPDATE user_totp
+		SET last_step = $2
+		WHERE user_id = $1 AND last_step < $2`
+	result, err := r.psql.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UseTOTPStep.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UseTOTPStep.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.UseTOTPStep.RowsAffected")
+	}
+	return nil
+}
+
+// Delete user TOTP with backup codes
+func (r *UserStorage) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.DeleteTOTP")
+	defer span.Finish()
+
+	tx, err := r.psql.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.DeleteTOTP.BeginTxx")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_backup_codes WHERE user_id = $1`, userID); err != nil {
+		return errors.Wrap(err, "UserStoragePsql.DeleteTOTP.DeleteCodes")
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return errors.Wrap(err, "UserStoragePsql.DeleteTOTP.DeleteTOTP")
+	}
+
+	return errors.Wrap(tx.Commit(), "UserStoragePsql.DeleteTOTP.Commit")
+}
+
+// Mark backup code as used, returns sql.ErrNoRows for unknown or used code
+func (r *UserStorage) UseBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.UseBackupCode")
+	defer span.Finish()
+
+	query := `UPDATE user_backup_codes
+		SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.psql.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UseBackupCode.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UseBackupCode.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.UseBackupCode.RowsAffected")
+	}
+	return nil
+}
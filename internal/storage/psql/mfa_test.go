@@ -0,0 +1,147 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetTOTP(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("GetTOTP", func(t *testing.T) {
+		uid := uuid.New()
+
+		columns := []string{
+			"user_id",
+			"secret",
+			"confirmed_at",
+			"last_step",
+			"created_at",
+		}
+		rows := sqlmock.NewRows(columns).AddRow(
+			uid,
+			"SECRET",
+			nil,
+			int64(55000000),
+			time.Now(),
+		)
+
+		query := `SELECT user_id, secret, confirmed_at, last_step, created_at
+		FROM user_totp
+		WHERE user_id = $1`
+		mock.ExpectQuery(query).WithArgs(uid).WillReturnRows(rows)
+
+		totp, err := userStorage.GetTOTP(context.Background(), uid)
+		require.NoError(t, err)
+		require.Equal(t, totp.Secret, "SECRET")
+		require.False(t, totp.IsEnabled())
+		require.Equal(t, int64(55000000), totp.LastStep)
+	})
+}
+
+func Test_ConfirmTOTP(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("ConfirmTOTP", func(t *testing.T) {
+		uid := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_totp
+		SET confirmed_at = now()
+		WHERE user_id = $1`).WithArgs(uid).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM user_backup_codes WHERE user_id = $1`).WithArgs(uid).WillReturnResult(sqlmock.NewResult(0, 0))
+		insert := `INSERT INTO user_backup_codes (user_id, code_hash, created_at)
+		VALUES ($1, $2, now())`
+		mock.ExpectExec(insert).WithArgs(uid, "first").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insert).WithArgs(uid, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := userStorage.ConfirmTOTP(context.Background(), uid, []string{"first", "second"})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_UseBackupCode(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("UseBackupCode", func(t *testing.T) {
+		uid := uuid.New()
+
+		query := `UPDATE user_backup_codes
+		SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+		mock.ExpectExec(query).WithArgs(uid, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(query).WithArgs(uid, "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := userStorage.UseBackupCode(context.Background(), uid, "hash")
+		require.NoError(t, err)
+
+		err = userStorage.UseBackupCode(context.Background(), uid, "hash")
+		require.Error(t, err)
+	})
+}
+
+func Test_UseTOTPStep(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("UseTOTPStep", func(t *testing.T) {
+		uid := uuid.New()
+
+		query := `UPDATE user_totp
+		SET last_step = $2
+		WHERE user_id = $1 AND last_step < $2`
+		mock.ExpectExec(query).WithArgs(uid, int64(55000000)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(query).WithArgs(uid, int64(55000000)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := userStorage.UseTOTPStep(context.Background(), uid, 55000000)
+		require.NoError(t, err)
+
+		err = userStorage.UseTOTPStep(context.Background(), uid, 55000000)
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/Edbeer/Project/internal/entity"
 	gomock "github.com/golang/mock/gomock"
@@ -36,6 +37,20 @@ func (m *MockUserPsql) EXPECT() *MockUserPsqlMockRecorder {
 	return m.recorder
 }
 
+// ConfirmTOTP mocks base method.
+func (m *MockUserPsql) ConfirmTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockUserPsqlMockRecorder) ConfirmTOTP(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockUserPsql)(nil).ConfirmTOTP), ctx, userID, codeHashes)
+}
+
 // Create mocks base method.
 func (m *MockUserPsql) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
 	m.ctrl.T.Helper()
@@ -51,6 +66,48 @@ func (mr *MockUserPsqlMockRecorder) Create(ctx, user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserPsql)(nil).Create), ctx, user)
 }
 
+// CreateTOTP mocks base method.
+func (m *MockUserPsql) CreateTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTOTP", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTOTP indicates an expected call of CreateTOTP.
+func (mr *MockUserPsqlMockRecorder) CreateTOTP(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTOTP", reflect.TypeOf((*MockUserPsql)(nil).CreateTOTP), ctx, userID, secret)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockUserPsql) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockUserPsqlMockRecorder) DeleteTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockUserPsql)(nil).DeleteTOTP), ctx, userID)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserPsql) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserPsqlMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserPsql)(nil).DeleteUser), ctx, userID)
+}
+
 // FindUserByEmail mocks base method.
 func (m *MockUserPsql) FindUserByEmail(ctx context.Context, user *entity.User) (*entity.User, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +123,36 @@ func (mr *MockUserPsqlMockRecorder) FindUserByEmail(ctx, user interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserPsql)(nil).FindUserByEmail), ctx, user)
 }
 
+// GetTOTP mocks base method.
+func (m *MockUserPsql) GetTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", ctx, userID)
+	ret0, _ := ret[0].(*entity.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockUserPsqlMockRecorder) GetTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockUserPsql)(nil).GetTOTP), ctx, userID)
+}
+
+// GetUserAccess mocks base method.
+func (m *MockUserPsql) GetUserAccess(ctx context.Context, userID uuid.UUID) (*entity.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAccess", ctx, userID)
+	ret0, _ := ret[0].(*entity.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAccess indicates an expected call of GetUserAccess.
+func (mr *MockUserPsqlMockRecorder) GetUserAccess(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccess", reflect.TypeOf((*MockUserPsql)(nil).GetUserAccess), ctx, userID)
+}
+
 // GetUserByID mocks base method.
 func (m *MockUserPsql) GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
 	m.ctrl.T.Helper()
@@ -80,3 +167,491 @@ func (mr *MockUserPsqlMockRecorder) GetUserByID(ctx, userID interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserPsql)(nil).GetUserByID), ctx, userID)
 }
+
+// ImportUser mocks base method.
+func (m *MockUserPsql) ImportUser(ctx context.Context, user *entity.User) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUser", ctx, user)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUser indicates an expected call of ImportUser.
+func (mr *MockUserPsqlMockRecorder) ImportUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUser", reflect.TypeOf((*MockUserPsql)(nil).ImportUser), ctx, user)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserPsql) ListUsers(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*entity.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserPsqlMockRecorder) ListUsers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserPsql)(nil).ListUsers), ctx, filter)
+}
+
+// MarkVerified mocks base method.
+func (m *MockUserPsql) MarkVerified(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkVerified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkVerified indicates an expected call of MarkVerified.
+func (mr *MockUserPsqlMockRecorder) MarkVerified(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkVerified", reflect.TypeOf((*MockUserPsql)(nil).MarkVerified), ctx, userID)
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockUserPsql) PurgeDeletedUsers(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", ctx, before)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUserPsqlMockRecorder) PurgeDeletedUsers(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUserPsql)(nil).PurgeDeletedUsers), ctx, before)
+}
+
+// SetDeleted mocks base method.
+func (m *MockUserPsql) SetDeleted(ctx context.Context, userID uuid.UUID, deleted bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeleted", ctx, userID, deleted)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDeleted indicates an expected call of SetDeleted.
+func (mr *MockUserPsqlMockRecorder) SetDeleted(ctx, userID, deleted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleted", reflect.TypeOf((*MockUserPsql)(nil).SetDeleted), ctx, userID, deleted)
+}
+
+// SetSuspended mocks base method.
+func (m *MockUserPsql) SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSuspended", ctx, userID, suspended)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSuspended indicates an expected call of SetSuspended.
+func (mr *MockUserPsqlMockRecorder) SetSuspended(ctx, userID, suspended interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSuspended", reflect.TypeOf((*MockUserPsql)(nil).SetSuspended), ctx, userID, suspended)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockUserPsql) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockUserPsqlMockRecorder) UpdateEmail(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockUserPsql)(nil).UpdateEmail), ctx, userID, email)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserPsql) UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserPsqlMockRecorder) UpdatePassword(ctx, userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserPsql)(nil).UpdatePassword), ctx, userID, password)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserPsql) UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(*entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserPsqlMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserPsql)(nil).UpdateUser), ctx, user)
+}
+
+// UseBackupCode mocks base method.
+func (m *MockUserPsql) UseBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseBackupCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseBackupCode indicates an expected call of UseBackupCode.
+func (mr *MockUserPsqlMockRecorder) UseBackupCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseBackupCode", reflect.TypeOf((*MockUserPsql)(nil).UseBackupCode), ctx, userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockUserPsql) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockUserPsqlMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockUserPsql)(nil).UseTOTPStep), ctx, userID, step)
+}
+
+// MockWebAuthnPsql is a mock of WebAuthnPsql interface.
+type MockWebAuthnPsql struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnPsqlMockRecorder
+}
+
+// MockWebAuthnPsqlMockRecorder is the mock recorder for MockWebAuthnPsql.
+type MockWebAuthnPsqlMockRecorder struct {
+	mock *MockWebAuthnPsql
+}
+
+// NewMockWebAuthnPsql creates a new mock instance.
+func NewMockWebAuthnPsql(ctrl *gomock.Controller) *MockWebAuthnPsql {
+	mock := &MockWebAuthnPsql{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnPsqlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnPsql) EXPECT() *MockWebAuthnPsqlMockRecorder {
+	return m.recorder
+}
+
+// CreateCredential mocks base method.
+func (m *MockWebAuthnPsql) CreateCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCredential", ctx, credential)
+	ret0, _ := ret[0].(*entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCredential indicates an expected call of CreateCredential.
+func (mr *MockWebAuthnPsqlMockRecorder) CreateCredential(ctx, credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCredential", reflect.TypeOf((*MockWebAuthnPsql)(nil).CreateCredential), ctx, credential)
+}
+
+// GetCredential mocks base method.
+func (m *MockWebAuthnPsql) GetCredential(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredential", ctx, credentialID)
+	ret0, _ := ret[0].(*entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCredential indicates an expected call of GetCredential.
+func (mr *MockWebAuthnPsqlMockRecorder) GetCredential(ctx, credentialID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredential", reflect.TypeOf((*MockWebAuthnPsql)(nil).GetCredential), ctx, credentialID)
+}
+
+// GetUserCredentials mocks base method.
+func (m *MockWebAuthnPsql) GetUserCredentials(ctx context.Context, userID uuid.UUID) ([]*entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserCredentials", ctx, userID)
+	ret0, _ := ret[0].([]*entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserCredentials indicates an expected call of GetUserCredentials.
+func (mr *MockWebAuthnPsqlMockRecorder) GetUserCredentials(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCredentials", reflect.TypeOf((*MockWebAuthnPsql)(nil).GetUserCredentials), ctx, userID)
+}
+
+// UpdateSignCount mocks base method.
+func (m *MockWebAuthnPsql) UpdateSignCount(ctx context.Context, credentialID []byte, oldCount, newCount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSignCount", ctx, credentialID, oldCount, newCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSignCount indicates an expected call of UpdateSignCount.
+func (mr *MockWebAuthnPsqlMockRecorder) UpdateSignCount(ctx, credentialID, oldCount, newCount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSignCount", reflect.TypeOf((*MockWebAuthnPsql)(nil).UpdateSignCount), ctx, credentialID, oldCount, newCount)
+}
+
+// MockAuditPsql is a mock of AuditPsql interface.
+type MockAuditPsql struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditPsqlMockRecorder
+}
+
+// MockAuditPsqlMockRecorder is the mock recorder for MockAuditPsql.
+type MockAuditPsqlMockRecorder struct {
+	mock *MockAuditPsql
+}
+
+// NewMockAuditPsql creates a new mock instance.
+func NewMockAuditPsql(ctrl *gomock.Controller) *MockAuditPsql {
+	mock := &MockAuditPsql{ctrl: ctrl}
+	mock.recorder = &MockAuditPsqlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditPsql) EXPECT() *MockAuditPsqlMockRecorder {
+	return m.recorder
+}
+
+// AppendEvent mocks base method.
+func (m *MockAuditPsql) AppendEvent(ctx context.Context, event *entity.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendEvent indicates an expected call of AppendEvent.
+func (mr *MockAuditPsqlMockRecorder) AppendEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendEvent", reflect.TypeOf((*MockAuditPsql)(nil).AppendEvent), ctx, event)
+}
+
+// GetUserEvents mocks base method.
+func (m *MockAuditPsql) GetUserEvents(ctx context.Context, userID uuid.UUID) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserEvents", ctx, userID)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserEvents indicates an expected call of GetUserEvents.
+func (mr *MockAuditPsqlMockRecorder) GetUserEvents(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserEvents", reflect.TypeOf((*MockAuditPsql)(nil).GetUserEvents), ctx, userID)
+}
+
+// ListEvents mocks base method.
+func (m *MockAuditPsql) ListEvents(ctx context.Context, filter *entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, filter)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockAuditPsqlMockRecorder) ListEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockAuditPsql)(nil).ListEvents), ctx, filter)
+}
+
+// ScanEvents mocks base method.
+func (m *MockAuditPsql) ScanEvents(ctx context.Context, afterSeq int64, limit int) ([]*entity.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanEvents", ctx, afterSeq, limit)
+	ret0, _ := ret[0].([]*entity.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanEvents indicates an expected call of ScanEvents.
+func (mr *MockAuditPsqlMockRecorder) ScanEvents(ctx, afterSeq, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanEvents", reflect.TypeOf((*MockAuditPsql)(nil).ScanEvents), ctx, afterSeq, limit)
+}
+
+// MockOAuthPsql is a mock of OAuthPsql interface.
+type MockOAuthPsql struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthPsqlMockRecorder
+}
+
+// MockOAuthPsqlMockRecorder is the mock recorder for MockOAuthPsql.
+type MockOAuthPsqlMockRecorder struct {
+	mock *MockOAuthPsql
+}
+
+// NewMockOAuthPsql creates a new mock instance.
+func NewMockOAuthPsql(ctrl *gomock.Controller) *MockOAuthPsql {
+	mock := &MockOAuthPsql{ctrl: ctrl}
+	mock.recorder = &MockOAuthPsqlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthPsql) EXPECT() *MockOAuthPsqlMockRecorder {
+	return m.recorder
+}
+
+// CreateClient mocks base method.
+func (m *MockOAuthPsql) CreateClient(ctx context.Context, client *entity.OAuthClient) (*entity.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", ctx, client)
+	ret0, _ := ret[0].(*entity.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockOAuthPsqlMockRecorder) CreateClient(ctx, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockOAuthPsql)(nil).CreateClient), ctx, client)
+}
+
+// DeleteClient mocks base method.
+func (m *MockOAuthPsql) DeleteClient(ctx context.Context, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockOAuthPsqlMockRecorder) DeleteClient(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockOAuthPsql)(nil).DeleteClient), ctx, clientID)
+}
+
+// GetClient mocks base method.
+func (m *MockOAuthPsql) GetClient(ctx context.Context, clientID string) (*entity.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", ctx, clientID)
+	ret0, _ := ret[0].(*entity.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockOAuthPsqlMockRecorder) GetClient(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockOAuthPsql)(nil).GetClient), ctx, clientID)
+}
+
+// ListClients mocks base method.
+func (m *MockOAuthPsql) ListClients(ctx context.Context) ([]*entity.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx)
+	ret0, _ := ret[0].([]*entity.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockOAuthPsqlMockRecorder) ListClients(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockOAuthPsql)(nil).ListClients), ctx)
+}
+
+// MockServiceAccountPsql is a mock of ServiceAccountPsql interface.
+type MockServiceAccountPsql struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountPsqlMockRecorder
+}
+
+// MockServiceAccountPsqlMockRecorder is the mock recorder for MockServiceAccountPsql.
+type MockServiceAccountPsqlMockRecorder struct {
+	mock *MockServiceAccountPsql
+}
+
+// NewMockServiceAccountPsql creates a new mock instance.
+func NewMockServiceAccountPsql(ctrl *gomock.Controller) *MockServiceAccountPsql {
+	mock := &MockServiceAccountPsql{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountPsqlMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountPsql) EXPECT() *MockServiceAccountPsqlMockRecorder {
+	return m.recorder
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockServiceAccountPsql) CreateServiceAccount(ctx context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, account)
+	ret0, _ := ret[0].(*entity.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockServiceAccountPsqlMockRecorder) CreateServiceAccount(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockServiceAccountPsql)(nil).CreateServiceAccount), ctx, account)
+}
+
+// DeleteServiceAccount mocks base method.
+func (m *MockServiceAccountPsql) DeleteServiceAccount(ctx context.Context, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceAccount", ctx, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceAccount indicates an expected call of DeleteServiceAccount.
+func (mr *MockServiceAccountPsqlMockRecorder) DeleteServiceAccount(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceAccount", reflect.TypeOf((*MockServiceAccountPsql)(nil).DeleteServiceAccount), ctx, clientID)
+}
+
+// GetServiceAccount mocks base method.
+func (m *MockServiceAccountPsql) GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccount", ctx, clientID)
+	ret0, _ := ret[0].(*entity.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccount indicates an expected call of GetServiceAccount.
+func (mr *MockServiceAccountPsqlMockRecorder) GetServiceAccount(ctx, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccount", reflect.TypeOf((*MockServiceAccountPsql)(nil).GetServiceAccount), ctx, clientID)
+}
+
+// ListServiceAccounts mocks base method.
+func (m *MockServiceAccountPsql) ListServiceAccounts(ctx context.Context) ([]*entity.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccounts", ctx)
+	ret0, _ := ret[0].([]*entity.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccounts indicates an expected call of ListServiceAccounts.
+func (mr *MockServiceAccountPsqlMockRecorder) ListServiceAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccounts", reflect.TypeOf((*MockServiceAccountPsql)(nil).ListServiceAccounts), ctx)
+}
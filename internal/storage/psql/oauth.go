@@ -0,0 +1,88 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// OAuth clients psql storage
+type OAuthStorage struct {
+	psql *sqlx.DB
+}
+
+// New oauth storage constructor
+func newOAuthStorage(psql *sqlx.DB) *OAuthStorage {
+	return &OAuthStorage{psql: psql}
+}
+
+// Create client
+func (r *OAuthStorage) CreateClient(ctx context.Context, client *entity.OAuthClient) (*entity.OAuthClient, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthPsql.CreateClient")
+	defer span.Finish()
+
+	c := &entity.OAuthClient{}
+	query := `INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		RETURNING *`
+	if err := r.psql.QueryRowxContext(ctx, query,
+		client.ID, client.SecretHash, client.Name, client.RedirectURIs, client.Scopes,
+	).StructScan(c); err != nil {
+		return nil, errors.Wrap(err, "OAuthStoragePsql.CreateClient.StructScan")
+	}
+	return c, nil
+}
+
+// Get client by id
+func (r *OAuthStorage) GetClient(ctx context.Context, clientID string) (*entity.OAuthClient, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthPsql.GetClient")
+	defer span.Finish()
+
+	c := &entity.OAuthClient{}
+	query := `SELECT client_id, secret_hash, name, redirect_uris, scopes, created_at
+		FROM oauth_clients
+		WHERE client_id = $1`
+	if err := r.psql.QueryRowxContext(ctx, query, clientID).StructScan(c); err != nil {
+		return nil, errors.Wrap(err, "OAuthStoragePsql.GetClient.StructScan")
+	}
+	return c, nil
+}
+
+// Get all clients
+func (r *OAuthStorage) ListClients(ctx context.Context) ([]*entity.OAuthClient, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthPsql.ListClients")
+	defer span.Finish()
+
+	clients := []*entity.OAuthClient{}
+	query := `SELECT client_id, secret_hash, name, redirect_uris, scopes, created_at
+		FROM oauth_clients
+		ORDER BY created_at`
+	if err := r.psql.SelectContext(ctx, &clients, query); err != nil {
+		return nil, errors.Wrap(err, "OAuthStoragePsql.ListClients.SelectContext")
+	}
+	return clients, nil
+}
+
+// Delete client
+func (r *OAuthStorage) DeleteClient(ctx context.Context, clientID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthPsql.DeleteClient")
+	defer span.Finish()
+
+	query := `DELETE FROM oauth_clients WHERE client_id = $1`
+	result, err := r.psql.ExecContext(ctx, query, clientID)
+	if err != nil {
+		return errors.Wrap(err, "OAuthStoragePsql.DeleteClient.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "OAuthStoragePsql.DeleteClient.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "OAuthStoragePsql.DeleteClient.RowsAffected")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetClient(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	oauthStorage := newOAuthStorage(sqlxDB)
+
+	query := `SELECT client_id, secret_hash, name, redirect_uris, scopes, created_at
+		FROM oauth_clients
+		WHERE client_id = $1`
+	columns := []string{"client_id", "secret_hash", "name", "redirect_uris", "scopes", "created_at"}
+
+	t.Run("PublicClient", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(
+			"client",
+			nil,
+			"App",
+			"https://app.example.com/callback http://127.0.0.1/callback",
+			"profile email",
+			time.Now(),
+		)
+		mock.ExpectQuery(query).WithArgs("client").WillReturnRows(rows)
+
+		client, err := oauthStorage.GetClient(context.Background(), "client")
+		require.NoError(t, err)
+		require.True(t, client.IsPublic())
+		require.Equal(t, []string{"https://app.example.com/callback", "http://127.0.0.1/callback"}, []string(client.RedirectURIs))
+		require.Equal(t, []string{"profile", "email"}, []string(client.Scopes))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("unknown").WillReturnError(sql.ErrNoRows)
+
+		_, err := oauthStorage.GetClient(context.Background(), "unknown")
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
+
+func Test_DeleteClient(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	oauthStorage := newOAuthStorage(sqlxDB)
+
+	query := `DELETE FROM oauth_clients WHERE client_id = $1`
+
+	t.Run("DeleteClient", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs("client").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := oauthStorage.DeleteClient(context.Background(), "client")
+		require.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs("client").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := oauthStorage.DeleteClient(context.Background(), "client")
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
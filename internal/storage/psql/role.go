@@ -0,0 +1,51 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Get roles of the user and permissions granted by them
+func (r *UserStorage) GetUserAccess(ctx context.Context, userID uuid.UUID) (*entity.Access, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.GetUserAccess")
+	defer span.Finish()
+
+	query := `SELECT r.name, COALESCE(p.name, '')
+		FROM user_roles ur
+		JOIN roles r ON r.role_id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.role_id
+		LEFT JOIN permissions p ON p.permission_id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name, p.name`
+	rows, err := r.psql.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "UserStoragePsql.GetUserAccess.QueryContext")
+	}
+	defer rows.Close()
+
+	access := &entity.Access{}
+	roles := map[string]bool{}
+	permissions := map[string]bool{}
+	for rows.Next() {
+		var role, permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, errors.Wrap(err, "UserStoragePsql.GetUserAccess.Scan")
+		}
+		if !roles[role] {
+			roles[role] = true
+			access.Roles = append(access.Roles, role)
+		}
+		if permission != "" && !permissions[permission] {
+			permissions[permission] = true
+			access.Permissions = append(access.Permissions, permission)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "UserStoragePsql.GetUserAccess.Rows")
+	}
+	return access, nil
+}
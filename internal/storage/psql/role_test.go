@@ -0,0 +1,58 @@
+package psql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetUserAccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	query := `SELECT r.name, COALESCE(p.name, '')
+		FROM user_roles ur
+		JOIN roles r ON r.role_id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.role_id
+		LEFT JOIN permissions p ON p.permission_id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name, p.name`
+
+	t.Run("GetUserAccess", func(t *testing.T) {
+		uid := uuid.New()
+		rows := sqlmock.NewRows([]string{"name", "coalesce"}).
+			AddRow(entity.RoleAdmin, entity.PermissionUsersRead).
+			AddRow(entity.RoleAdmin, entity.PermissionUsersWrite).
+			AddRow("support", entity.PermissionUsersRead).
+			AddRow("viewer", "")
+		mock.ExpectQuery(query).WithArgs(uid).WillReturnRows(rows)
+
+		access, err := userStorage.GetUserAccess(context.Background(), uid)
+		require.NoError(t, err)
+		require.Equal(t, []string{entity.RoleAdmin, "support", "viewer"}, access.Roles)
+		require.Equal(t, []string{entity.PermissionUsersRead, entity.PermissionUsersWrite}, access.Permissions)
+	})
+
+	t.Run("NoRoles", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectQuery(query).WithArgs(uid).WillReturnRows(sqlmock.NewRows([]string{"name", "coalesce"}))
+
+		access, err := userStorage.GetUserAccess(context.Background(), uid)
+		require.NoError(t, err)
+		require.Empty(t, access.Roles)
+		require.False(t, access.HasPermission(entity.PermissionUsersRead))
+	})
+}
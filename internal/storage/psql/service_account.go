@@ -0,0 +1,88 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Service accounts psql storage
+type ServiceAccountStorage struct {
+	psql *sqlx.DB
+}
+
+// New service account storage constructor
+func newServiceAccountStorage(psql *sqlx.DB) *ServiceAccountStorage {
+	return &ServiceAccountStorage{psql: psql}
+}
+
+// Create service account
+func (r *ServiceAccountStorage) CreateServiceAccount(ctx context.Context, account *entity.ServiceAccount) (*entity.ServiceAccount, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountPsql.CreateServiceAccount")
+	defer span.Finish()
+
+	a := &entity.ServiceAccount{}
+	query := `INSERT INTO service_accounts (client_id, secret_hash, name, owner_id, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		RETURNING *`
+	if err := r.psql.QueryRowxContext(ctx, query,
+		account.ClientID, account.SecretHash, account.Name, account.OwnerID, account.Scopes,
+	).StructScan(a); err != nil {
+		return nil, errors.Wrap(err, "ServiceAccountStoragePsql.CreateServiceAccount.StructScan")
+	}
+	return a, nil
+}
+
+// Get service account by client id
+func (r *ServiceAccountStorage) GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountPsql.GetServiceAccount")
+	defer span.Finish()
+
+	a := &entity.ServiceAccount{}
+	query := `SELECT client_id, secret_hash, name, owner_id, scopes, created_at
+		FROM service_accounts
+		WHERE client_id = $1`
+	if err := r.psql.QueryRowxContext(ctx, query, clientID).StructScan(a); err != nil {
+		return nil, errors.Wrap(err, "ServiceAccountStoragePsql.GetServiceAccount.StructScan")
+	}
+	return a, nil
+}
+
+// Get all service accounts
+func (r *ServiceAccountStorage) ListServiceAccounts(ctx context.Context) ([]*entity.ServiceAccount, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountPsql.ListServiceAccounts")
+	defer span.Finish()
+
+	accounts := []*entity.ServiceAccount{}
+	query := `SELECT client_id, secret_hash, name, owner_id, scopes, created_at
+		FROM service_accounts
+		ORDER BY created_at`
+	if err := r.psql.SelectContext(ctx, &accounts, query); err != nil {
+		return nil, errors.Wrap(err, "ServiceAccountStoragePsql.ListServiceAccounts.SelectContext")
+	}
+	return accounts, nil
+}
+
+// Delete service account
+func (r *ServiceAccountStorage) DeleteServiceAccount(ctx context.Context, clientID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ServiceAccountPsql.DeleteServiceAccount")
+	defer span.Finish()
+
+	query := `DELETE FROM service_accounts WHERE client_id = $1`
+	result, err := r.psql.ExecContext(ctx, query, clientID)
+	if err != nil {
+		return errors.Wrap(err, "ServiceAccountStoragePsql.DeleteServiceAccount.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "ServiceAccountStoragePsql.DeleteServiceAccount.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "ServiceAccountStoragePsql.DeleteServiceAccount.RowsAffected")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	serviceAccountStorage := newServiceAccountStorage(sqlxDB)
+
+	query := `INSERT INTO service_accounts (client_id, secret_hash, name, owner_id, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		RETURNING *`
+	columns := []string{"client_id", "secret_hash", "name", "owner_id", "scopes", "created_at"}
+
+	t.Run("CreateServiceAccount", func(t *testing.T) {
+		account := &entity.ServiceAccount{
+			ClientID:   "backup-job",
+			SecretHash: "hash",
+			Name:       "Backup job",
+			OwnerID:    uuid.New(),
+			Scopes:     entity.SpaceList{"users:read"},
+		}
+		rows := sqlmock.NewRows(columns).AddRow(
+			account.ClientID, account.SecretHash, account.Name, account.OwnerID, "users:read", time.Now(),
+		)
+		mock.ExpectQuery(query).
+			WithArgs(account.ClientID, account.SecretHash, account.Name, account.OwnerID, "users:read").
+			WillReturnRows(rows)
+
+		created, err := serviceAccountStorage.CreateServiceAccount(context.Background(), account)
+		require.NoError(t, err)
+		require.Equal(t, account.OwnerID, created.OwnerID)
+		require.Equal(t, []string{"users:read"}, []string(created.Scopes))
+	})
+}
+
+func Test_GetServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	serviceAccountStorage := newServiceAccountStorage(sqlxDB)
+
+	query := `SELECT client_id, secret_hash, name, owner_id, scopes, created_at
+		FROM service_accounts
+		WHERE client_id = $1`
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("unknown").WillReturnError(sql.ErrNoRows)
+
+		_, err := serviceAccountStorage.GetServiceAccount(context.Background(), "unknown")
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
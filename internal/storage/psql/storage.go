@@ -6,11 +6,19 @@ import (
 
 // Storage psql
 type Storage struct {
-	User *UserStorage
+	User     *UserStorage
+	WebAuthn *WebAuthnStorage
+	Audit    *AuditStorage
+	OAuth    *OAuthStorage
+	Service  *ServiceAccountStorage
 }
 
 func NewStorage(psql *sqlx.DB) *Storage {
 	return &Storage{
-		User: newUserStorage(psql),
+		User:     newUserStorage(psql),
+		WebAuthn: newWebAuthnStorage(psql),
+		Audit:    newAuditStorage(psql),
+		OAuth:    newOAuthStorage(psql),
+		Service:  newServiceAccountStorage(psql),
 	}
 }
\ No newline at end of file
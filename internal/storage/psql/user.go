@@ -2,6 +2,10 @@ package psql
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/google/uuid"
@@ -37,13 +41,33 @@ func (r *UserStorage) Create(ctx context.Context, user *entity.User) (*entity.Us
 	return u, nil
 }
 
+// Insert user imported from another system with its password hash,
+// returns false when the email is already taken
+func (r *UserStorage) ImportUser(ctx context.Context, user *entity.User) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.ImportUser")
+	defer span.Finish()
+
+	query := `INSERT INTO users (name, email, password, verified_at, created_at)
+		SELECT $1::varchar, $2::varchar, $3::varchar, $4::timestamp, now()
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE email = $2)`
+	result, err := r.psql.ExecContext(ctx, query, user.Name, user.Email, user.Password, user.VerifiedAt)
+	if err != nil {
+		return false, errors.Wrap(err, "UserStoragePsql.ImportUser.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "UserStoragePsql.ImportUser.RowsAffected")
+	}
+	return rows == 1, nil
+}
+
 // Find user by email
 func (r *UserStorage) FindUserByEmail(ctx context.Context, user *entity.User) (*entity.User, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.FindUserByEmail")
 	defer span.Finish()
 	
 	foundUser := &entity.User{}
-	query := `SELECT user_id, name, email, password, created_at
+	query := `SELECT user_id, name, email, password, verified_at, suspended_at, deleted_at, created_at
 			FROM users
 			WHERE email = $1`
 	if err := r.psql.QueryRowxContext(ctx, query, user.Email).StructScan(foundUser); err != nil {
@@ -58,7 +82,7 @@ func (r *UserStorage) GetUserByID(ctx context.Context, userID uuid.UUID) (*entit
 	defer span.Finish()
 	
 	u := &entity.User{}
-	query := `SELECT user_id, name, email, password, created_at
+	query := `SELECT user_id, name, email, password, verified_at, suspended_at, deleted_at, created_at
 		FROM users
 		WHERE user_id = $1`
 	if err := r.psql.QueryRowxContext(ctx, query, userID).StructScan(u); err != nil {
@@ -67,3 +91,213 @@ func (r *UserStorage) GetUserByID(ctx context.Context, userID uuid.UUID) (*entit
 
 	return u, nil
 }
+
+// Mark user email as verified
+func (r *UserStorage) MarkVerified(ctx context.Context, userID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.MarkVerified")
+	defer span.Finish()
+
+	query := `UPDATE users
+		SET verified_at = COALESCE(verified_at, now())
+		WHERE user_id = $1`
+	result, err := r.psql.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.MarkVerified.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.MarkVerified.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.MarkVerified.RowsAffected")
+	}
+	return nil
+}
+
+// Update user password hash
+func (r *UserStorage) UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.UpdatePassword")
+	defer span.Finish()
+
+	query := `UPDATE users
+		SET password = $1
+		WHERE user_id = $2`
+	result, err := r.psql.ExecContext(ctx, query, password, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UpdatePassword.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UpdatePassword.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.UpdatePassword.RowsAffected")
+	}
+	return nil
+}
+
+// Set confirmed new email of the user
+func (r *UserStorage) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.UpdateEmail")
+	defer span.Finish()
+
+	query := `UPDATE users
+		SET email = $1, verified_at = now()
+		WHERE user_id = $2`
+	result, err := r.psql.ExecContext(ctx, query, email, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UpdateEmail.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.UpdateEmail.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.UpdateEmail.RowsAffected")
+	}
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List users page by filter, newest first, with total count of matching users
+func (r *UserStorage) ListUsers(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.ListUsers")
+	defer span.Finish()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Email != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Email)+"%")
+		conditions = append(conditions, fmt.Sprintf("email ILIKE $%d", len(args)))
+	}
+	if filter.Name != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Name)+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.psql.GetContext(ctx, &total, "SELECT COUNT(*) FROM users"+where, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "UserStoragePsql.ListUsers.Count")
+	}
+
+	args = append(args, filter.Size, (filter.Page-1)*filter.Size)
+	query := fmt.Sprintf(`SELECT user_id, name, email, verified_at, suspended_at, deleted_at, created_at
+		FROM users%s
+		ORDER BY created_at DESC, user_id
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+	users := []*entity.User{}
+	if err := r.psql.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "UserStoragePsql.ListUsers.SelectContext")
+	}
+	return users, total, nil
+}
+
+// Update user name and email, email verification is reset when email changes
+func (r *UserStorage) UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.UpdateUser")
+	defer span.Finish()
+
+	u := &entity.User{}
+	query := `UPDATE users
+		SET name = $1,
+			verified_at = CASE WHEN email = $2 THEN verified_at END,
+			email = $2
+		WHERE user_id = $3
+		RETURNING user_id, name, email, verified_at, suspended_at, deleted_at, created_at`
+	if err := r.psql.QueryRowxContext(ctx, query, user.Name, user.Email, user.ID).StructScan(u); err != nil {
+		return nil, errors.Wrap(err, "UserStoragePsql.UpdateUser.StructScan")
+	}
+	return u, nil
+}
+
+// Suspend or unsuspend user
+func (r *UserStorage) SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.SetSuspended")
+	defer span.Finish()
+
+	query := `UPDATE users
+		SET suspended_at = CASE WHEN $1 THEN COALESCE(suspended_at, now()) END
+		WHERE user_id = $2`
+	result, err := r.psql.ExecContext(ctx, query, suspended, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.SetSuspended.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.SetSuspended.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.SetSuspended.RowsAffected")
+	}
+	return nil
+}
+
+// Delete user with all related rows
+func (r *UserStorage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.DeleteUser")
+	defer span.Finish()
+
+	query := `DELETE FROM users WHERE user_id = $1`
+	result, err := r.psql.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.DeleteUser.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.DeleteUser.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.DeleteUser.RowsAffected")
+	}
+	return nil
+}
+
+// Soft delete or restore user
+func (r *UserStorage) SetDeleted(ctx context.Context, userID uuid.UUID, deleted bool) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.SetDeleted")
+	defer span.Finish()
+
+	query := `UPDATE users
+		SET deleted_at = CASE WHEN $1 THEN COALESCE(deleted_at, now()) END
+		WHERE user_id = $2`
+	result, err := r.psql.ExecContext(ctx, query, deleted, userID)
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.SetDeleted.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "UserStoragePsql.SetDeleted.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "UserStoragePsql.SetDeleted.RowsAffected")
+	}
+	return nil
+}
+
+// Delete users soft deleted before the moment, returns ids of deleted users
+func (r *UserStorage) PurgeDeletedUsers(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserPsql.PurgeDeletedUsers")
+	defer span.Finish()
+
+	query := `DELETE FROM users
+		WHERE deleted_at < $1
+		RETURNING user_id`
+	userIDs := []uuid.UUID{}
+	if err := r.psql.SelectContext(ctx, &userIDs, query, before); err != nil {
+		return nil, errors.Wrap(err, "UserStoragePsql.PurgeDeletedUsers.SelectContext")
+	}
+	return userIDs, nil
+}
@@ -2,8 +2,10 @@ package psql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Edbeer/Project/internal/entity"
@@ -87,7 +89,7 @@ func Test_FindUserByEmail(t *testing.T) {
 			Email: "edbeermtn@gmail.com",
 		}
 
-		query := `SELECT user_id, name, email, password, created_at
+		query := `SELECT user_id, name, email, password, verified_at, suspended_at, deleted_at, created_at
 			FROM users
 			WHERE email = $1`
 		mock.ExpectQuery(query).WithArgs(&testUser.Email).WillReturnRows(rows)
@@ -131,7 +133,7 @@ func Test_GetUserByID(t *testing.T) {
 			Email: "edbeermtn@gmail.com",
 		}
 
-		query := `SELECT user_id, name, email, password, created_at
+		query := `SELECT user_id, name, email, password, verified_at, suspended_at, deleted_at, created_at
 			FROM users
 			WHERE user_id = $1`
 		mock.ExpectQuery(query).WithArgs(uid).WillReturnRows(rows)
@@ -143,3 +145,290 @@ func Test_GetUserByID(t *testing.T) {
 		fmt.Printf("user: %s \n", user.Name)
 	})
 }
+
+
+func Test_MarkVerified(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("MarkVerified", func(t *testing.T) {
+		uid := uuid.New()
+
+		query := `UPDATE users
+		SET verified_at = COALESCE(verified_at, now())
+		WHERE user_id = $1`
+		mock.ExpectExec(query).WithArgs(uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.MarkVerified(context.Background(), uid)
+		require.NoError(t, err)
+	})
+}
+
+func Test_UpdatePassword(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("UpdatePassword", func(t *testing.T) {
+		uid := uuid.New()
+
+		query := `UPDATE users
+		SET password = $1
+		WHERE user_id = $2`
+		mock.ExpectExec(query).WithArgs("hash", uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.UpdatePassword(context.Background(), uid, "hash")
+		require.NoError(t, err)
+	})
+}
+func Test_ListUsers(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("Filter", func(t *testing.T) {
+		after := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter := &entity.UserFilter{
+			Email:        "100%_",
+			CreatedAfter: &after,
+			Page:         2,
+			Size:         10,
+		}
+
+		mock.ExpectQuery(`SELECT COUNT(*) FROM users WHERE email ILIKE $1 AND created_at >= $2`).
+			WithArgs(`%100\%\_%`, after).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+
+		query := `SELECT user_id, name, email, verified_at, suspended_at, deleted_at, created_at
+		FROM users WHERE email ILIKE $1 AND created_at >= $2
+		ORDER BY created_at DESC, user_id
+		LIMIT $3 OFFSET $4`
+		uid := uuid.New()
+		rows := sqlmock.NewRows([]string{"user_id", "name", "email", "verified_at", "suspended_at", "deleted_at", "created_at"}).
+			AddRow(uid, "PavelV", "100%_@gmail.com", nil, nil, nil, after)
+		mock.ExpectQuery(query).WithArgs(`%100\%\_%`, after, 10, 10).WillReturnRows(rows)
+
+		users, total, err := userStorage.ListUsers(context.Background(), filter)
+		require.NoError(t, err)
+		require.Equal(t, 11, total)
+		require.Len(t, users, 1)
+		require.Equal(t, uid, users[0].ID)
+		require.Equal(t, "", users[0].Password)
+	})
+
+	t.Run("NoFilter", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT(*) FROM users`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		query := `SELECT user_id, name, email, verified_at, suspended_at, deleted_at, created_at
+		FROM users
+		ORDER BY created_at DESC, user_id
+		LIMIT $1 OFFSET $2`
+		mock.ExpectQuery(query).WithArgs(20, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "email", "verified_at", "suspended_at", "deleted_at", "created_at"}))
+
+		users, total, err := userStorage.ListUsers(context.Background(), &entity.UserFilter{Page: 1, Size: 20})
+		require.NoError(t, err)
+		require.Equal(t, 0, total)
+		require.Empty(t, users)
+	})
+}
+
+func Test_SetSuspended(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	query := `UPDATE users
+		SET suspended_at = CASE WHEN $1 THEN COALESCE(suspended_at, now()) END
+		WHERE user_id = $2`
+
+	t.Run("Suspend", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectExec(query).WithArgs(true, uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.SetSuspended(context.Background(), uid, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectExec(query).WithArgs(false, uid).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := userStorage.SetSuspended(context.Background(), uid, false)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_DeleteUser(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectExec(`DELETE FROM users WHERE user_id = $1`).WithArgs(uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.DeleteUser(context.Background(), uid)
+		require.NoError(t, err)
+	})
+}
+
+func Test_UpdateEmail(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("UpdateEmail", func(t *testing.T) {
+		uid := uuid.New()
+
+		query := `UPDATE users
+		SET email = $1, verified_at = now()
+		WHERE user_id = $2`
+		mock.ExpectExec(query).WithArgs("new@gmail.com", uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.UpdateEmail(context.Background(), uid, "new@gmail.com")
+		require.NoError(t, err)
+	})
+}
+
+func Test_SetDeleted(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	query := `UPDATE users
+		SET deleted_at = CASE WHEN $1 THEN COALESCE(deleted_at, now()) END
+		WHERE user_id = $2`
+
+	t.Run("Delete", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectExec(query).WithArgs(true, uid).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userStorage.SetDeleted(context.Background(), uid, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		uid := uuid.New()
+		mock.ExpectExec(query).WithArgs(false, uid).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := userStorage.SetDeleted(context.Background(), uid, false)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_PurgeDeletedUsers(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	t.Run("PurgeDeletedUsers", func(t *testing.T) {
+		uid := uuid.New()
+		before := time.Now().Add(-time.Hour)
+
+		query := `DELETE FROM users
+		WHERE deleted_at < $1
+		RETURNING user_id`
+		mock.ExpectQuery(query).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(uid))
+
+		userIDs, err := userStorage.PurgeDeletedUsers(context.Background(), before)
+		require.NoError(t, err)
+		require.Equal(t, []uuid.UUID{uid}, userIDs)
+	})
+}
+
+func Test_ImportUser(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	userStorage := newUserStorage(sqlxDB)
+
+	query := `INSERT INTO users (name, email, password, verified_at, created_at)
+		SELECT $1::varchar, $2::varchar, $3::varchar, $4::timestamp, now()
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE email = $2)`
+	user := &entity.User{
+		Name:     "Pavel",
+		Email:    "edbeermtn@gmail.com",
+		Password: "$sha1$00",
+	}
+
+	t.Run("Imported", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(user.Name, user.Email, user.Password, user.VerifiedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		imported, err := userStorage.ImportUser(context.Background(), user)
+		require.NoError(t, err)
+		require.True(t, imported)
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(user.Name, user.Email, user.Password, user.VerifiedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		imported, err := userStorage.ImportUser(context.Background(), user)
+		require.NoError(t, err)
+		require.False(t, imported)
+	})
+}
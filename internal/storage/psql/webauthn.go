@@ -0,0 +1,92 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// WebAuthn credentials psql storage
+type WebAuthnStorage struct {
+	psql *sqlx.DB
+}
+
+// New webauthn storage constructor
+func newWebAuthnStorage(psql *sqlx.DB) *WebAuthnStorage {
+	return &WebAuthnStorage{psql: psql}
+}
+
+// Create credential
+func (r *WebAuthnStorage) CreateCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnPsql.CreateCredential")
+	defer span.Finish()
+
+	c := &entity.WebAuthnCredential{}
+	query := `INSERT INTO webauthn_credentials (credential_id, user_id, public_key, sign_count, aaguid, name, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		RETURNING *`
+	if err := r.psql.QueryRowxContext(ctx, query,
+		credential.ID, credential.UserID, credential.PublicKey, credential.SignCount, credential.AAGUID, credential.Name,
+	).StructScan(c); err != nil {
+		return nil, errors.Wrap(err, "WebAuthnStoragePsql.CreateCredential.StructScan")
+	}
+	return c, nil
+}
+
+// Get credential by id
+func (r *WebAuthnStorage) GetCredential(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnPsql.GetCredential")
+	defer span.Finish()
+
+	c := &entity.WebAuthnCredential{}
+	query := `SELECT credential_id, user_id, public_key, sign_count, aaguid, name, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE credential_id = $1`
+	if err := r.psql.QueryRowxContext(ctx, query, credentialID).StructScan(c); err != nil {
+		return nil, errors.Wrap(err, "WebAuthnStoragePsql.GetCredential.StructScan")
+	}
+	return c, nil
+}
+
+// Get all user credentials
+func (r *WebAuthnStorage) GetUserCredentials(ctx context.Context, userID uuid.UUID) ([]*entity.WebAuthnCredential, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnPsql.GetUserCredentials")
+	defer span.Finish()
+
+	credentials := []*entity.WebAuthnCredential{}
+	query := `SELECT credential_id, user_id, public_key, sign_count, aaguid, name, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at`
+	if err := r.psql.SelectContext(ctx, &credentials, query, userID); err != nil {
+		return nil, errors.Wrap(err, "WebAuthnStoragePsql.GetUserCredentials.SelectContext")
+	}
+	return credentials, nil
+}
+
+// Update sign count, fails when the stored counter has changed concurrently
+func (r *WebAuthnStorage) UpdateSignCount(ctx context.Context, credentialID []byte, oldCount, newCount int64) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "WebAuthnPsql.UpdateSignCount")
+	defer span.Finish()
+
+	query := `UPDATE webauthn_credentials
+		SET sign_count = $3, last_used_at = now()
+		WHERE credential_id = $1 AND sign_count = $2`
+	result, err := r.psql.ExecContext(ctx, query, credentialID, oldCount, newCount)
+	if err != nil {
+		return errors.Wrap(err, "WebAuthnStoragePsql.UpdateSignCount.ExecContext")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "WebAuthnStoragePsql.UpdateSignCount.RowsAffected")
+	}
+	if rows == 0 {
+		return errors.Wrap(sql.ErrNoRows, "WebAuthnStoragePsql.UpdateSignCount.RowsAffected")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetCredential(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	webauthnStorage := newWebAuthnStorage(sqlxDB)
+
+	t.Run("GetCredential", func(t *testing.T) {
+		credentialID := []byte("credential")
+		uid := uuid.New()
+
+		columns := []string{
+			"credential_id",
+			"user_id",
+			"public_key",
+			"sign_count",
+			"aaguid",
+			"name",
+			"created_at",
+			"last_used_at",
+		}
+		rows := sqlmock.NewRows(columns).AddRow(
+			credentialID,
+			uid,
+			[]byte("key"),
+			5,
+			make([]byte, 16),
+			"laptop",
+			time.Now(),
+			nil,
+		)
+
+		query := `SELECT credential_id, user_id, public_key, sign_count, aaguid, name, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE credential_id = $1`
+		mock.ExpectQuery(query).WithArgs(credentialID).WillReturnRows(rows)
+
+		credential, err := webauthnStorage.GetCredential(context.Background(), credentialID)
+		require.NoError(t, err)
+		require.Equal(t, uid, credential.UserID)
+		require.Equal(t, int64(5), credential.SignCount)
+		require.Nil(t, credential.LastUsedAt)
+	})
+}
+
+func Test_UpdateSignCount(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	defer sqlxDB.Close()
+
+	webauthnStorage := newWebAuthnStorage(sqlxDB)
+
+	query := `UPDATE webauthn_credentials
+		SET sign_count = $3, last_used_at = now()
+		WHERE credential_id = $1 AND sign_count = $2`
+
+	t.Run("UpdateSignCount", func(t *testing.T) {
+		credentialID := []byte("credential")
+		mock.ExpectExec(query).WithArgs(credentialID, 5, 6).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := webauthnStorage.UpdateSignCount(context.Background(), credentialID, 5, 6)
+		require.NoError(t, err)
+	})
+
+	t.Run("ConcurrentUpdate", func(t *testing.T) {
+		credentialID := []byte("credential")
+		mock.ExpectExec(query).WithArgs(credentialID, 5, 6).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := webauthnStorage.UpdateSignCount(context.Background(), credentialID, 5, 6)
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
@@ -3,6 +3,7 @@ package redisrepo
 
 import (
 	"context"
+	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/google/uuid"
@@ -12,5 +13,46 @@ import (
 type SessionRedis interface {
 	CreateSession(ctx context.Context, session *entity.Session, expire int) (string, error)
 	GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error)
+	GetSession(ctx context.Context, refreshToken string) (*entity.Session, error)
+	RotateSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error)
 	DeleteSession(ctx context.Context, refreshToken string) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*entity.SessionInfo, error)
+	DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error
+}
+
+// Single-use token storage interface
+type TokenRedis interface {
+	CreateToken(ctx context.Context, kind, tokenID string, userID uuid.UUID, expire int) error
+	GetToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error)
+	ConsumeToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error)
+}
+
+// Failed sign-in throttling storage interface
+type ThrottleRedis interface {
+	GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error)
+	RegisterFailure(ctx context.Context, key string, policy *entity.ThrottlePolicy) (*entity.LoginThrottle, error)
+	ResetThrottle(ctx context.Context, key string) error
+}
+
+// Distributed rate limit storage interface
+type RateLimitRedis interface {
+	Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error)
+}
+
+// OAuth grants storage interface
+type OAuthRedis interface {
+	CreateCode(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error)
+	ConsumeCode(ctx context.Context, code string) (*entity.OAuthGrant, error)
+	CreateRefreshToken(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error)
+	ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error)
+	GetRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error)
+}
+
+// Access token revocation storage interface
+type RevocationRedis interface {
+	RevokeToken(ctx context.Context, token *entity.AccessToken) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, expire int) error
+	IsRevoked(ctx context.Context, token *entity.AccessToken) (bool, error)
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/Edbeer/Project/internal/entity"
 	gomock "github.com/golang/mock/gomock"
@@ -51,6 +52,20 @@ func (mr *MockSessionRedisMockRecorder) CreateSession(ctx, session, expire inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRedis)(nil).CreateSession), ctx, session, expire)
 }
 
+// DeleteOtherSessions mocks base method.
+func (m *MockSessionRedis) DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOtherSessions", ctx, userID, keepSessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOtherSessions indicates an expected call of DeleteOtherSessions.
+func (mr *MockSessionRedisMockRecorder) DeleteOtherSessions(ctx, userID, keepSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOtherSessions", reflect.TypeOf((*MockSessionRedis)(nil).DeleteOtherSessions), ctx, userID, keepSessionID)
+}
+
 // DeleteSession mocks base method.
 func (m *MockSessionRedis) DeleteSession(ctx context.Context, refreshToken string) error {
 	m.ctrl.T.Helper()
@@ -65,6 +80,49 @@ func (mr *MockSessionRedisMockRecorder) DeleteSession(ctx, refreshToken interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSessionRedis)(nil).DeleteSession), ctx, refreshToken)
 }
 
+// DeleteSessionByID mocks base method.
+func (m *MockSessionRedis) DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionByID", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSessionByID indicates an expected call of DeleteSessionByID.
+func (mr *MockSessionRedisMockRecorder) DeleteSessionByID(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionByID", reflect.TypeOf((*MockSessionRedis)(nil).DeleteSessionByID), ctx, userID, sessionID)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockSessionRedis) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockSessionRedisMockRecorder) DeleteUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockSessionRedis)(nil).DeleteUserSessions), ctx, userID)
+}
+
+// GetSession mocks base method.
+func (m *MockSessionRedis) GetSession(ctx context.Context, refreshToken string) (*entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, refreshToken)
+	ret0, _ := ret[0].(*entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockSessionRedisMockRecorder) GetSession(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockSessionRedis)(nil).GetSession), ctx, refreshToken)
+}
+
 // GetUserID mocks base method.
 func (m *MockSessionRedis) GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +137,369 @@ func (mr *MockSessionRedisMockRecorder) GetUserID(ctx, refreshToken interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*MockSessionRedis)(nil).GetUserID), ctx, refreshToken)
 }
+
+// GetUserSessions mocks base method.
+func (m *MockSessionRedis) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*entity.SessionInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessions", ctx, userID)
+	ret0, _ := ret[0].([]*entity.SessionInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessions indicates an expected call of GetUserSessions.
+func (mr *MockSessionRedisMockRecorder) GetUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessions", reflect.TypeOf((*MockSessionRedis)(nil).GetUserSessions), ctx, userID)
+}
+
+// RotateSession mocks base method.
+func (m *MockSessionRedis) RotateSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSession", ctx, session, expire)
+	ret0, _ := ret[0].(*entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSession indicates an expected call of RotateSession.
+func (mr *MockSessionRedisMockRecorder) RotateSession(ctx, session, expire interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MockSessionRedis)(nil).RotateSession), ctx, session, expire)
+}
+
+// MockTokenRedis is a mock of TokenRedis interface.
+type MockTokenRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRedisMockRecorder
+}
+
+// MockTokenRedisMockRecorder is the mock recorder for MockTokenRedis.
+type MockTokenRedisMockRecorder struct {
+	mock *MockTokenRedis
+}
+
+// NewMockTokenRedis creates a new mock instance.
+func NewMockTokenRedis(ctrl *gomock.Controller) *MockTokenRedis {
+	mock := &MockTokenRedis{ctrl: ctrl}
+	mock.recorder = &MockTokenRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRedis) EXPECT() *MockTokenRedisMockRecorder {
+	return m.recorder
+}
+
+// ConsumeToken mocks base method.
+func (m *MockTokenRedis) ConsumeToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeToken", ctx, kind, tokenID)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeToken indicates an expected call of ConsumeToken.
+func (mr *MockTokenRedisMockRecorder) ConsumeToken(ctx, kind, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeToken", reflect.TypeOf((*MockTokenRedis)(nil).ConsumeToken), ctx, kind, tokenID)
+}
+
+// CreateToken mocks base method.
+func (m *MockTokenRedis) CreateToken(ctx context.Context, kind, tokenID string, userID uuid.UUID, expire int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToken", ctx, kind, tokenID, userID, expire)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateToken indicates an expected call of CreateToken.
+func (mr *MockTokenRedisMockRecorder) CreateToken(ctx, kind, tokenID, userID, expire interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockTokenRedis)(nil).CreateToken), ctx, kind, tokenID, userID, expire)
+}
+
+// GetToken mocks base method.
+func (m *MockTokenRedis) GetToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetToken", ctx, kind, tokenID)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetToken indicates an expected call of GetToken.
+func (mr *MockTokenRedisMockRecorder) GetToken(ctx, kind, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToken", reflect.TypeOf((*MockTokenRedis)(nil).GetToken), ctx, kind, tokenID)
+}
+
+// MockThrottleRedis is a mock of ThrottleRedis interface.
+type MockThrottleRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockThrottleRedisMockRecorder
+}
+
+// MockThrottleRedisMockRecorder is the mock recorder for MockThrottleRedis.
+type MockThrottleRedisMockRecorder struct {
+	mock *MockThrottleRedis
+}
+
+// NewMockThrottleRedis creates a new mock instance.
+func NewMockThrottleRedis(ctrl *gomock.Controller) *MockThrottleRedis {
+	mock := &MockThrottleRedis{ctrl: ctrl}
+	mock.recorder = &MockThrottleRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockThrottleRedis) EXPECT() *MockThrottleRedisMockRecorder {
+	return m.recorder
+}
+
+// GetThrottle mocks base method.
+func (m *MockThrottleRedis) GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThrottle", ctx, key)
+	ret0, _ := ret[0].(*entity.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThrottle indicates an expected call of GetThrottle.
+func (mr *MockThrottleRedisMockRecorder) GetThrottle(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThrottle", reflect.TypeOf((*MockThrottleRedis)(nil).GetThrottle), ctx, key)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockThrottleRedis) RegisterFailure(ctx context.Context, key string, policy *entity.ThrottlePolicy) (*entity.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, key, policy)
+	ret0, _ := ret[0].(*entity.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockThrottleRedisMockRecorder) RegisterFailure(ctx, key, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockThrottleRedis)(nil).RegisterFailure), ctx, key, policy)
+}
+
+// ResetThrottle mocks base method.
+func (m *MockThrottleRedis) ResetThrottle(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetThrottle", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetThrottle indicates an expected call of ResetThrottle.
+func (mr *MockThrottleRedisMockRecorder) ResetThrottle(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetThrottle", reflect.TypeOf((*MockThrottleRedis)(nil).ResetThrottle), ctx, key)
+}
+
+// MockRateLimitRedis is a mock of RateLimitRedis interface.
+type MockRateLimitRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitRedisMockRecorder
+}
+
+// MockRateLimitRedisMockRecorder is the mock recorder for MockRateLimitRedis.
+type MockRateLimitRedisMockRecorder struct {
+	mock *MockRateLimitRedis
+}
+
+// NewMockRateLimitRedis creates a new mock instance.
+func NewMockRateLimitRedis(ctrl *gomock.Controller) *MockRateLimitRedis {
+	mock := &MockRateLimitRedis{ctrl: ctrl}
+	mock.recorder = &MockRateLimitRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitRedis) EXPECT() *MockRateLimitRedisMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockRateLimitRedis) Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, key, policy)
+	ret0, _ := ret[0].(*entity.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockRateLimitRedisMockRecorder) Allow(ctx, key, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockRateLimitRedis)(nil).Allow), ctx, key, policy)
+}
+
+// MockOAuthRedis is a mock of OAuthRedis interface.
+type MockOAuthRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthRedisMockRecorder
+}
+
+// MockOAuthRedisMockRecorder is the mock recorder for MockOAuthRedis.
+type MockOAuthRedisMockRecorder struct {
+	mock *MockOAuthRedis
+}
+
+// NewMockOAuthRedis creates a new mock instance.
+func NewMockOAuthRedis(ctrl *gomock.Controller) *MockOAuthRedis {
+	mock := &MockOAuthRedis{ctrl: ctrl}
+	mock.recorder = &MockOAuthRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthRedis) EXPECT() *MockOAuthRedisMockRecorder {
+	return m.recorder
+}
+
+// ConsumeCode mocks base method.
+func (m *MockOAuthRedis) ConsumeCode(ctx context.Context, code string) (*entity.OAuthGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeCode", ctx, code)
+	ret0, _ := ret[0].(*entity.OAuthGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeCode indicates an expected call of ConsumeCode.
+func (mr *MockOAuthRedisMockRecorder) ConsumeCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeCode", reflect.TypeOf((*MockOAuthRedis)(nil).ConsumeCode), ctx, code)
+}
+
+// ConsumeRefreshToken mocks base method.
+func (m *MockOAuthRedis) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(*entity.OAuthGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeRefreshToken indicates an expected call of ConsumeRefreshToken.
+func (mr *MockOAuthRedisMockRecorder) ConsumeRefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRefreshToken", reflect.TypeOf((*MockOAuthRedis)(nil).ConsumeRefreshToken), ctx, refreshToken)
+}
+
+// CreateCode mocks base method.
+func (m *MockOAuthRedis) CreateCode(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCode", ctx, grant, expire)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCode indicates an expected call of CreateCode.
+func (mr *MockOAuthRedisMockRecorder) CreateCode(ctx, grant, expire interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCode", reflect.TypeOf((*MockOAuthRedis)(nil).CreateCode), ctx, grant, expire)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockOAuthRedis) CreateRefreshToken(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, grant, expire)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockOAuthRedisMockRecorder) CreateRefreshToken(ctx, grant, expire interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockOAuthRedis)(nil).CreateRefreshToken), ctx, grant, expire)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockOAuthRedis) GetRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(*entity.OAuthGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockOAuthRedisMockRecorder) GetRefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockOAuthRedis)(nil).GetRefreshToken), ctx, refreshToken)
+}
+
+// MockRevocationRedis is a mock of RevocationRedis interface.
+type MockRevocationRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevocationRedisMockRecorder
+}
+
+// MockRevocationRedisMockRecorder is the mock recorder for MockRevocationRedis.
+type MockRevocationRedisMockRecorder struct {
+	mock *MockRevocationRedis
+}
+
+// NewMockRevocationRedis creates a new mock instance.
+func NewMockRevocationRedis(ctrl *gomock.Controller) *MockRevocationRedis {
+	mock := &MockRevocationRedis{ctrl: ctrl}
+	mock.recorder = &MockRevocationRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevocationRedis) EXPECT() *MockRevocationRedisMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockRevocationRedis) IsRevoked(ctx context.Context, token *entity.AccessToken) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockRevocationRedisMockRecorder) IsRevoked(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockRevocationRedis)(nil).IsRevoked), ctx, token)
+}
+
+// RevokeToken mocks base method.
+func (m *MockRevocationRedis) RevokeToken(ctx context.Context, token *entity.AccessToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockRevocationRedisMockRecorder) RevokeToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockRevocationRedis)(nil).RevokeToken), ctx, token)
+}
+
+// RevokeUserTokens mocks base method.
+func (m *MockRevocationRedis) RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, expire int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserTokens", ctx, userID, before, expire)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserTokens indicates an expected call of RevokeUserTokens.
+func (mr *MockRevocationRedisMockRecorder) RevokeUserTokens(ctx, userID, before, expire interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserTokens", reflect.TypeOf((*MockRevocationRedis)(nil).RevokeUserTokens), ctx, userID, before, expire)
+}
@@ -0,0 +1,140 @@
+package redisrepo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/token"
+	"github.com/go-redis/redis/v9"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	oauthCodePrefix    = "oauth-code:"
+	oauthRefreshPrefix = "oauth-refresh:"
+)
+
+// OAuth grants redis storage. Authorization codes and refresh tokens are
+// never stored, grants are keyed by HMAC of the token with the server secret.
+type OAuthStorage struct {
+	redis  *redis.Client
+	secret []byte
+}
+
+// OAuth storage constructor
+func newOAuthStorage(redis *redis.Client, secret []byte) *OAuthStorage {
+	return &OAuthStorage{
+		redis:  redis,
+		secret: secret,
+	}
+}
+
+// Save grant behind a new authorization code
+func (s *OAuthStorage) CreateCode(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthRedis.CreateCode")
+	defer span.Finish()
+
+	code, err := token.New(token.OAuthCode)
+	if err != nil {
+		return "", errors.Wrap(err, "OAuthStorage.CreateCode.New")
+	}
+	if err := s.saveGrant(ctx, oauthCodePrefix, code, grant, expire); err != nil {
+		return "", errors.Wrap(err, "OAuthStorage.CreateCode.Set")
+	}
+	return code, nil
+}
+
+// Get grant of the authorization code and delete it, so the code can be used only once
+func (s *OAuthStorage) ConsumeCode(ctx context.Context, code string) (*entity.OAuthGrant, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthRedis.ConsumeCode")
+	defer span.Finish()
+
+	grant, err := s.consumeGrant(ctx, oauthCodePrefix, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "OAuthStorage.ConsumeCode")
+	}
+	return grant, nil
+}
+
+// Save grant behind a new refresh token
+func (s *OAuthStorage) CreateRefreshToken(ctx context.Context, grant *entity.OAuthGrant, expire int) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthRedis.CreateRefreshToken")
+	defer span.Finish()
+
+	refreshToken, err := token.New(token.OAuthRefresh)
+	if err != nil {
+		return "", errors.Wrap(err, "OAuthStorage.CreateRefreshToken.New")
+	}
+	if err := s.saveGrant(ctx, oauthRefreshPrefix, refreshToken, grant, expire); err != nil {
+		return "", errors.Wrap(err, "OAuthStorage.CreateRefreshToken.Set")
+	}
+	return refreshToken, nil
+}
+
+// Get grant of the refresh token and delete it, refresh tokens are rotated on every use
+func (s *OAuthStorage) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthRedis.ConsumeRefreshToken")
+	defer span.Finish()
+
+	grant, err := s.consumeGrant(ctx, oauthRefreshPrefix, refreshToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "OAuthStorage.ConsumeRefreshToken")
+	}
+	return grant, nil
+}
+
+// Get grant of the refresh token without using it
+func (s *OAuthStorage) GetRefreshToken(ctx context.Context, refreshToken string) (*entity.OAuthGrant, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "OAuthRedis.GetRefreshToken")
+	defer span.Finish()
+
+	grantBytes, ttl, err := getWithTTL(ctx, s.redis, oauthRefreshPrefix+s.tokenHash(refreshToken))
+	if err != nil {
+		return nil, errors.Wrap(err, "OAuthStorage.GetRefreshToken.Get")
+	}
+	grant := &entity.OAuthGrant{}
+	if err := json.Unmarshal(grantBytes, grant); err != nil {
+		return nil, errors.Wrap(err, "OAuthStorage.GetRefreshToken.Unmarshal")
+	}
+	if ttl > 0 {
+		grant.ExpiresAt = time.Now().Add(ttl).Truncate(time.Second)
+	}
+	return grant, nil
+}
+
+func (s *OAuthStorage) saveGrant(ctx context.Context, prefix, tokenString string, grant *entity.OAuthGrant, expire int) error {
+	grantBytes, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, prefix+s.tokenHash(tokenString), grantBytes, time.Second*time.Duration(expire)).Err()
+}
+
+func (s *OAuthStorage) consumeGrant(ctx context.Context, prefix, tokenString string) (*entity.OAuthGrant, error) {
+	key := prefix + s.tokenHash(tokenString)
+	pipe := s.redis.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	grant := &entity.OAuthGrant{}
+	if err := json.Unmarshal([]byte(get.Val()), grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// HMAC of the token with the server secret
+func (s *OAuthStorage) tokenHash(tokenString string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(tokenString))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package redisrepo
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func SetupOAuthRedis() (*OAuthStorage, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return newOAuthStorage(client, []byte("secret")), mr
+}
+
+func TestRedis_OAuthGrants(t *testing.T) {
+	t.Parallel()
+
+	oauthRedisStorage, mr := SetupOAuthRedis()
+
+	grant := &entity.OAuthGrant{
+		ClientID:            "client",
+		UserID:              uuid.New(),
+		Scopes:              []string{"profile"},
+		RedirectURI:         "https://app.example.com/callback",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S256",
+	}
+
+	t.Run("ConsumeCode", func(t *testing.T) {
+		code, err := oauthRedisStorage.CreateCode(context.Background(), grant, 60)
+		require.NoError(t, err)
+		require.False(t, mr.Exists(oauthCodePrefix+code), "code must not be stored in plain")
+
+		consumed, err := oauthRedisStorage.ConsumeCode(context.Background(), code)
+		require.NoError(t, err)
+		require.Equal(t, grant, consumed)
+
+		_, err = oauthRedisStorage.ConsumeCode(context.Background(), code)
+		require.True(t, errors.Is(err, redis.Nil))
+	})
+
+	t.Run("ConsumeRefreshToken", func(t *testing.T) {
+		refreshToken, err := oauthRedisStorage.CreateRefreshToken(context.Background(), grant, 60)
+		require.NoError(t, err)
+
+		_, err = oauthRedisStorage.ConsumeCode(context.Background(), refreshToken)
+		require.True(t, errors.Is(err, redis.Nil), "refresh token is not a code")
+
+		consumed, err := oauthRedisStorage.ConsumeRefreshToken(context.Background(), refreshToken)
+		require.NoError(t, err)
+		require.Equal(t, grant, consumed)
+
+		_, err = oauthRedisStorage.ConsumeRefreshToken(context.Background(), refreshToken)
+		require.True(t, errors.Is(err, redis.Nil))
+	})
+
+	t.Run("GetRefreshToken", func(t *testing.T) {
+		refreshToken, err := oauthRedisStorage.CreateRefreshToken(context.Background(), grant, 60)
+		require.NoError(t, err)
+
+		found, err := oauthRedisStorage.GetRefreshToken(context.Background(), refreshToken)
+		require.NoError(t, err)
+		require.Equal(t, grant.UserID, found.UserID)
+		require.WithinDuration(t, time.Now().Add(60*time.Second), found.ExpiresAt, 2*time.Second)
+
+		// the token is still usable
+		_, err = oauthRedisStorage.ConsumeRefreshToken(context.Background(), refreshToken)
+		require.NoError(t, err)
+
+		_, err = oauthRedisStorage.GetRefreshToken(context.Background(), refreshToken)
+		require.True(t, errors.Is(err, redis.Nil))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		code, err := oauthRedisStorage.CreateCode(context.Background(), grant, 60)
+		require.NoError(t, err)
+
+		mr.FastForward(61 * time.Second)
+
+		_, err = oauthRedisStorage.ConsumeCode(context.Background(), code)
+		require.True(t, errors.Is(err, redis.Nil))
+	})
+}
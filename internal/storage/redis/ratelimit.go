@@ -0,0 +1,79 @@
+package redisrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/go-redis/redis/v9"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+const rateLimitPrefix = "rate-limit:"
+
+// GCRA, key stores theoretical arrival time of the next request.
+// KEYS: limit key
+// ARGV: now, emission interval, burst tolerance in milliseconds
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local newTat = tat + interval
+local diff = now - (newTat - tolerance)
+if diff < 0 then
+	return {0, 0, -diff, tat - now}
+end
+
+redis.call('SET', KEYS[1], string.format('%d', newTat), 'PX', string.format('%d', newTat - now))
+return {1, math.floor(diff / interval), 0, newTat - now}
+`)
+
+// Distributed rate limit redis storage
+type RateLimitStorage struct {
+	redis *redis.Client
+}
+
+// Rate limit storage constructor
+func newRateLimitStorage(redis *redis.Client) *RateLimitStorage {
+	return &RateLimitStorage{
+		redis: redis,
+	}
+}
+
+// Take one request from the key quota
+func (s *RateLimitStorage) Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RateLimitRedis.Allow")
+	defer span.Finish()
+
+	burst := policy.Burst
+	if burst < 1 {
+		burst = policy.Limit
+	}
+	interval := policy.Period.Milliseconds() / int64(policy.Limit)
+	if interval < 1 {
+		interval = 1
+	}
+
+	result, err := rateLimitScript.Run(ctx, s.redis, []string{rateLimitPrefix + key},
+		time.Now().UnixMilli(),
+		interval,
+		interval*int64(burst),
+	).Int64Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "RateLimitStorage.Allow.Run")
+	}
+
+	return &entity.RateLimit{
+		Allowed:    result[0] == 1,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+		Reset:      time.Duration(result[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package redisrepo
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func SetupRateLimitRedis() *RateLimitStorage {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return newRateLimitStorage(client)
+}
+
+func TestRedis_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	rateLimitRedisStorage := SetupRateLimitRedis()
+
+	t.Run("Burst", func(t *testing.T) {
+		ctx := context.Background()
+		policy := &entity.RateLimitPolicy{
+			Limit:  60,
+			Period: time.Hour,
+			Burst:  3,
+		}
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			limit, err := rateLimitRedisStorage.Allow(ctx, "burst", policy)
+			require.NoError(t, err)
+			require.True(t, limit.Allowed)
+			require.Equal(t, remaining, limit.Remaining)
+		}
+
+		limit, err := rateLimitRedisStorage.Allow(ctx, "burst", policy)
+		require.NoError(t, err)
+		require.False(t, limit.Allowed)
+		require.Zero(t, limit.Remaining)
+		// next request is allowed after one emission interval
+		require.InDelta(t, time.Minute, limit.RetryAfter, float64(time.Second))
+		require.InDelta(t, 3*time.Minute, limit.Reset, float64(time.Second))
+	})
+
+	t.Run("SeparateKeys", func(t *testing.T) {
+		ctx := context.Background()
+		policy := &entity.RateLimitPolicy{
+			Limit:  1,
+			Period: time.Hour,
+		}
+
+		limit, err := rateLimitRedisStorage.Allow(ctx, "first", policy)
+		require.NoError(t, err)
+		require.True(t, limit.Allowed)
+
+		limit, err = rateLimitRedisStorage.Allow(ctx, "first", policy)
+		require.NoError(t, err)
+		require.False(t, limit.Allowed)
+
+		limit, err = rateLimitRedisStorage.Allow(ctx, "second", policy)
+		require.NoError(t, err)
+		require.True(t, limit.Allowed)
+	})
+}
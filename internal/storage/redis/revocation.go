@@ -0,0 +1,85 @@
+package redisrepo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	revokedTokenPrefix  = "revoked-token:"
+	revokedBeforePrefix = "revoked-before:"
+)
+
+// Access token revocation redis storage, revoked token ids are kept
+// until the tokens expire, the per-user watermark invalidates
+// every token of the user issued before it
+type RevocationStorage struct {
+	redis *redis.Client
+}
+
+// Revocation storage constructor
+func newRevocationStorage(redis *redis.Client) *RevocationStorage {
+	return &RevocationStorage{
+		redis: redis,
+	}
+}
+
+// Put token id on the denylist for the remaining token lifetime
+func (s *RevocationStorage) RevokeToken(ctx context.Context, token *entity.AccessToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RevocationRedis.RevokeToken")
+	defer span.Finish()
+
+	ttl := time.Until(token.ExpiresAt)
+	if token.ID == "" || ttl <= 0 {
+		return nil
+	}
+	if err := s.redis.Set(ctx, revokedTokenPrefix+token.ID, 1, ttl).Err(); err != nil {
+		return errors.Wrap(err, "RevocationStorage.RevokeToken.Set")
+	}
+	return nil
+}
+
+// Invalidate tokens of the user issued before the time, the watermark is kept
+// in milliseconds and expires with the last token it can apply to
+func (s *RevocationStorage) RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, expire int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RevocationRedis.RevokeUserTokens")
+	defer span.Finish()
+
+	key := revokedBeforePrefix + userID.String()
+	if err := s.redis.Set(ctx, key, before.UnixMilli(), time.Second*time.Duration(expire)).Err(); err != nil {
+		return errors.Wrap(err, "RevocationStorage.RevokeUserTokens.Set")
+	}
+	return nil
+}
+
+// Check token id against the denylist and issue time against the subject watermark
+func (s *RevocationStorage) IsRevoked(ctx context.Context, token *entity.AccessToken) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RevocationRedis.IsRevoked")
+	defer span.Finish()
+
+	pipe := s.redis.Pipeline()
+	denied := pipe.Exists(ctx, revokedTokenPrefix+token.ID)
+	watermark := pipe.Get(ctx, revokedBeforePrefix+token.Subject)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, errors.Wrap(err, "RevocationStorage.IsRevoked.Exec")
+	}
+
+	if token.ID != "" && denied.Val() > 0 {
+		return true, nil
+	}
+	if watermark.Err() != nil {
+		return false, nil
+	}
+	before, err := strconv.ParseInt(watermark.Val(), 10, 64)
+	if err != nil {
+		return false, errors.Wrap(err, "RevocationStorage.IsRevoked.ParseInt")
+	}
+	return token.IssuedAt.UnixMilli() < before, nil
+}
@@ -0,0 +1,122 @@
+package redisrepo
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func SetupRevocationRedis() (*RevocationStorage, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return newRevocationStorage(client), mr
+}
+
+func TestRedis_Revocation(t *testing.T) {
+	t.Parallel()
+
+	revocationRedisStorage, mr := SetupRevocationRedis()
+
+	t.Run("RevokeToken", func(t *testing.T) {
+		token := &entity.AccessToken{
+			ID:        uuid.New().String(),
+			Subject:   uuid.New().String(),
+			IssuedAt:  time.Now(),
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+
+		revoked, err := revocationRedisStorage.IsRevoked(context.Background(), token)
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		err = revocationRedisStorage.RevokeToken(context.Background(), token)
+		require.NoError(t, err)
+
+		revoked, err = revocationRedisStorage.IsRevoked(context.Background(), token)
+		require.NoError(t, err)
+		require.True(t, revoked)
+
+		// denylist entry lives as long as the token
+		ttl := mr.TTL(revokedTokenPrefix + token.ID)
+		require.True(t, ttl > 0 && ttl <= time.Minute)
+		mr.FastForward(time.Minute)
+		require.False(t, mr.Exists(revokedTokenPrefix+token.ID))
+	})
+
+	t.Run("RevokeExpiredToken", func(t *testing.T) {
+		token := &entity.AccessToken{
+			ID:        uuid.New().String(),
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}
+
+		err := revocationRedisStorage.RevokeToken(context.Background(), token)
+		require.NoError(t, err)
+		require.False(t, mr.Exists(revokedTokenPrefix+token.ID))
+	})
+
+	t.Run("RevokeUserTokens", func(t *testing.T) {
+		userID := uuid.New()
+		now := time.Now()
+		issuedBefore := &entity.AccessToken{
+			ID:       uuid.New().String(),
+			Subject:  userID.String(),
+			IssuedAt: now.Add(-time.Minute),
+		}
+		issuedAfter := &entity.AccessToken{
+			ID:       uuid.New().String(),
+			Subject:  userID.String(),
+			IssuedAt: now.Add(time.Second),
+		}
+
+		err := revocationRedisStorage.RevokeUserTokens(context.Background(), userID, now, 900)
+		require.NoError(t, err)
+		require.Equal(t, 900*time.Second, mr.TTL(revokedBeforePrefix+userID.String()))
+
+		revoked, err := revocationRedisStorage.IsRevoked(context.Background(), issuedBefore)
+		require.NoError(t, err)
+		require.True(t, revoked)
+
+		revoked, err = revocationRedisStorage.IsRevoked(context.Background(), issuedAfter)
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		// tokens issued later within the same second stay valid
+		sameSecond := time.Unix(now.Unix(), 0).Add(100 * time.Millisecond)
+		err = revocationRedisStorage.RevokeUserTokens(context.Background(), userID, sameSecond, 900)
+		require.NoError(t, err)
+		revoked, err = revocationRedisStorage.IsRevoked(context.Background(), &entity.AccessToken{
+			Subject:  userID.String(),
+			IssuedAt: sameSecond.Add(-time.Millisecond),
+		})
+		require.NoError(t, err)
+		require.True(t, revoked)
+		revoked, err = revocationRedisStorage.IsRevoked(context.Background(), &entity.AccessToken{
+			Subject:  userID.String(),
+			IssuedAt: sameSecond.Add(800 * time.Millisecond),
+		})
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		// watermark of one user does not touch tokens of others
+		revoked, err = revocationRedisStorage.IsRevoked(context.Background(), &entity.AccessToken{
+			ID:       uuid.New().String(),
+			Subject:  uuid.New().String(),
+			IssuedAt: now.Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		require.False(t, revoked)
+	})
+}
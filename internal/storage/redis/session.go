@@ -2,12 +2,16 @@ package redisrepo
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"math/rand"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/token"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -15,15 +19,113 @@ import (
 	"github.com/go-redis/redis/v9"
 )
 
-// Session redis storage
+const (
+	refreshTokenPrefix  = "refresh-token:"
+	sessionFamilyPrefix = "session-family:"
+	rotatedTokenPrefix  = "rotated-token:"
+	userSessionsPrefix  = "user-sessions:"
+)
+
+var ErrSessionReused = errors.New("rotated refresh token reused")
+
+// Session family changed between the lookup of its keys and the script
+var errFamilyChanged = errors.New("session family changed")
+
+// Attempts to rotate or revoke a session family changed concurrently
+const familyAttempts = 3
+
+// Rotate refresh token of the family. A rotated token keeps a marker with its
+// session until expiry, presenting it again revokes the current family token.
+// Sessions created before families existed join the family from ARGV[4].
+// KEYS[4] and KEYS[5] are the plain keys of sessions created before tokens were hashed.
+// KEYS[6], KEYS[7] and KEYS[8] are the family, the user sessions and the current
+// family token keys read beforehand, ARGV[8] is the current token or empty.
+// Returns {1, new session} on rotation, {-1, old session} on reuse, {0} when unknown
+// and {2} when the family keys are stale.
+var rotateSessionScript = redis.NewScript(`
+local key = KEYS[1]
+local session = redis.call('GET', key)
+if not session then
+	key = KEYS[4]
+	session = redis.call('GET', key)
+end
+if not session then
+	local rotated = redis.call('GET', KEYS[2]) or redis.call('GET', KEYS[5])
+	if not rotated then
+		return {0}
+	end
+	local old = cjson.decode(rotated)
+	if ARGV[2] .. old.family_id ~= KEYS[6] or ARGV[3] .. old.user_id ~= KEYS[7] then
+		return {2}
+	end
+	local current = redis.call('HGET', KEYS[6], 'token') or ''
+	if current ~= ARGV[8] then
+		return {2}
+	end
+	if current ~= '' then
+		redis.call('DEL', KEYS[8])
+	end
+	redis.call('DEL', KEYS[6])
+	redis.call('SREM', KEYS[7], old.family_id)
+	return {-1, rotated}
+end
+
+local ttl = tonumber(ARGV[1])
+local data = cjson.decode(session)
+if not data.family_id then
+	data.family_id = ARGV[4]
+end
+if ARGV[2] .. data.family_id ~= KEYS[6] or ARGV[3] .. data.user_id ~= KEYS[7] then
+	return {2}
+end
+data.refresh_token = nil
+local previous = cjson.encode(data)
+data.ip = ARGV[6]
+data.user_agent = ARGV[7]
+local next = cjson.encode(data)
+
+redis.call('DEL', key)
+redis.call('SET', KEYS[2], previous, 'EX', ttl)
+redis.call('SET', KEYS[3], next, 'EX', ttl)
+redis.call('HSETNX', KEYS[6], 'created_at', ARGV[5])
+redis.call('HMSET', KEYS[6], 'token', KEYS[3], 'user_id', data.user_id, 'last_used_at', ARGV[5], 'ip', ARGV[6], 'user_agent', ARGV[7])
+redis.call('EXPIRE', KEYS[6], ttl)
+redis.call('SREM', KEYS[7], key)
+redis.call('SADD', KEYS[7], data.family_id)
+redis.call('EXPIRE', KEYS[7], ttl)
+return {1, next}
+`)
+
+// Revoke session family of the user, returns 0 when the family belongs to someone else
+// and -1 when its token is no longer KEYS[3] read beforehand, ARGV[3] is the token or empty
+var revokeSessionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'user_id') ~= ARGV[1] then
+	return 0
+end
+local token = redis.call('HGET', KEYS[1], 'token') or ''
+if token ~= ARGV[3] then
+	return -1
+end
+if token ~= '' then
+	redis.call('DEL', KEYS[3])
+end
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[2])
+return 1
+`)
+
+// Session redis storage. Refresh tokens are never stored, sessions
+// are keyed by HMAC of the token with the server secret.
 type SessionStorage struct {
-	redis   *redis.Client
+	redis  *redis.Client
+	secret []byte
 }
 
 // Session storage constructor
-func newSessionStorage(redis *redis.Client) *SessionStorage {
+func newSessionStorage(redis *redis.Client, secret []byte) *SessionStorage {
 	return &SessionStorage{
-		redis:   redis,
+		redis:  redis,
+		secret: secret,
 	}
 }
 
@@ -32,17 +134,48 @@ func (s *SessionStorage) CreateSession(ctx context.Context, session *entity.Sess
 	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.CreateSession")
 	defer span.Finish()
 
-	session.RefreshToken = newRefreshToken()
+	refreshToken, err := token.New(token.RefreshToken)
+	if err != nil {
+		return "", errors.Wrap(err, "SessionStorage.CreateSession.New")
+	}
+	if session.FamilyID == uuid.Nil {
+		session.FamilyID = uuid.New()
+	}
+	if session.AuthTime == nil {
+		// sign-in time, kept across rotation for oidc auth_time
+		authTime := time.Now().UTC().Truncate(time.Second)
+		session.AuthTime = &authTime
+	}
 
-	sessionBytes, err := json.Marshal(&session)
+	stored := *session
+	stored.RefreshToken = ""
+	sessionBytes, err := json.Marshal(&stored)
 	if err != nil {
 		return "", errors.Wrap(err, "SessionStorage.CreateSession.Marshal")
 	}
-	if err := s.redis.Set(ctx, session.RefreshToken, sessionBytes, time.Second*time.Duration(expire)).Err(); err != nil {
-		return "", errors.Wrap(err, "SessionStorage.CreateSession.Set")
+	now := time.Now().Unix()
+	key := s.sessionKey(refreshToken)
+	userKey := userSessionsKey(session.UserID)
+	familyKey := sessionFamilyKey(session.FamilyID)
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, key, sessionBytes, time.Second*time.Duration(expire))
+	pipe.HMSet(ctx, familyKey,
+		"token", key,
+		"user_id", session.UserID.String(),
+		"created_at", now,
+		"last_used_at", now,
+		"ip", session.IP,
+		"user_agent", session.UserAgent,
+	)
+	pipe.Expire(ctx, familyKey, time.Second*time.Duration(expire))
+	pipe.SAdd(ctx, userKey, session.FamilyID.String())
+	pipe.Expire(ctx, userKey, time.Second*time.Duration(expire))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", errors.Wrap(err, "SessionStorage.CreateSession.Exec")
 	}
 
-	return session.RefreshToken, nil
+	session.RefreshToken = refreshToken
+	return refreshToken, nil
 }
 
 // Get user id from session
@@ -50,38 +183,388 @@ func (s *SessionStorage) GetUserID(ctx context.Context, refreshToken string) (uu
 	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.GetUserID")
 	defer span.Finish()
 
-	sessionBytes, err := s.redis.Get(ctx, refreshToken).Bytes()
+	session, err := s.getSession(ctx, refreshToken)
 	if err != nil {
-		return uuid.Nil , errors.Wrap(err, "SessionStorage.GetUserID.Get")
-	}
-	session := &entity.Session{}
-	if err = json.Unmarshal(sessionBytes, session); err != nil {
-		return uuid.Nil, errors.Wrap(err, "SessionStorage.GetSessionByID.Get")
+		return uuid.Nil, errors.Wrap(err, "SessionStorage.GetUserID")
 	}
 
 	return session.UserID, nil
 }
 
+// Get session by refresh token
+func (s *SessionStorage) GetSession(ctx context.Context, refreshToken string) (*entity.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.GetSession")
+	defer span.Finish()
+
+	session, err := s.getSession(ctx, refreshToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "SessionStorage.GetSession")
+	}
+	return session, nil
+}
+
+// Rotate refresh token atomically, the presented token is invalidated and its
+// successor is returned. Reuse of a rotated token revokes the whole family and
+// returns the rotated session with ErrSessionReused.
+// Client IP and user agent of the presented session are recorded as last used.
+func (s *SessionStorage) RotateSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.RotateSession")
+	defer span.Finish()
+
+	refreshToken, err := token.New(token.RefreshToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "SessionStorage.RotateSession.New")
+	}
+	hash := s.tokenHash(session.RefreshToken)
+	keys := []string{
+		refreshTokenPrefix + hash, rotatedTokenKey(hash), s.sessionKey(refreshToken),
+		refreshTokenPrefix + hash, rotatedTokenKey(hash),
+	}
+	if isPlainToken(session.RefreshToken) {
+		keys[3], keys[4] = session.RefreshToken, rotatedTokenKey(session.RefreshToken)
+	}
+	newFamilyID := uuid.New().String()
+
+	// scripts touch only declared keys, family keys are read first
+	// and the script checks they are still the same
+	var result []interface{}
+	for attempt := 0; ; attempt++ {
+		family, err := s.familyOfToken(ctx, keys, newFamilyID)
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.Wrap(redis.Nil, "SessionStorage.RotateSession")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "SessionStorage.RotateSession")
+		}
+
+		result, err = rotateSessionScript.Run(ctx, s.redis, append(keys[:5:5], family.keys()...),
+			expire, sessionFamilyPrefix, userSessionsPrefix, newFamilyID,
+			time.Now().Unix(), session.IP, session.UserAgent, family.token,
+		).Slice()
+		if err != nil {
+			return nil, errors.Wrap(err, "SessionStorage.RotateSession.Run")
+		}
+		if status, _ := result[0].(int64); status != 2 {
+			break
+		}
+		if attempt+1 == familyAttempts {
+			return nil, errors.Wrap(errFamilyChanged, "SessionStorage.RotateSession")
+		}
+	}
+
+	status, _ := result[0].(int64)
+	if status == 0 {
+		return nil, errors.Wrap(redis.Nil, "SessionStorage.RotateSession")
+	}
+	payload, _ := result[1].(string)
+	rotated := &entity.Session{}
+	if err := json.Unmarshal([]byte(payload), rotated); err != nil {
+		return nil, errors.Wrap(err, "SessionStorage.RotateSession.Unmarshal")
+	}
+	if status < 0 {
+		rotated.RefreshToken = session.RefreshToken
+		return rotated, ErrSessionReused
+	}
+	rotated.RefreshToken = refreshToken
+	return rotated, nil
+}
+
 // Delete session cookie
 func (s *SessionStorage) DeleteSession(ctx context.Context, refreshToken string) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.DeleteSession")
 	defer span.Finish()
-	if err := s.redis.Del(ctx, refreshToken).Err(); err != nil {
-		return errors.Wrap(err, "SessionStorage.DeleteSession.Del")
+
+	session, err := s.getSession(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return errors.Wrap(err, "SessionStorage.DeleteSession")
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, s.sessionKey(refreshToken), sessionFamilyKey(session.FamilyID))
+	if isPlainToken(refreshToken) {
+		pipe.Del(ctx, refreshToken)
+	}
+	pipe.SRem(ctx, userSessionsKey(session.UserID), session.FamilyID.String(), refreshToken)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "SessionStorage.DeleteSession.Exec")
+	}
+	return nil
+}
+
+// List active sessions of the user
+func (s *SessionStorage) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*entity.SessionInfo, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.GetUserSessions")
+	defer span.Finish()
+
+	userKey := userSessionsKey(userID)
+	members, err := s.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "SessionStorage.GetUserSessions.SMembers")
+	}
+
+	pipe := s.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(members))
+	for i, member := range members {
+		cmds[i] = pipe.HGetAll(ctx, sessionFamilyPrefix+member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "SessionStorage.GetUserSessions.Exec")
+	}
+
+	sessions := make([]*entity.SessionInfo, 0, len(members))
+	stale := []interface{}{}
+	for i, member := range members {
+		family := cmds[i].Val()
+		familyID, err := uuid.Parse(member)
+		if err != nil || family["user_id"] != userID.String() {
+			// expired family or refresh token indexed before sessions had ids
+			stale = append(stale, member)
+			continue
+		}
+		sessions = append(sessions, &entity.SessionInfo{
+			ID:         familyID,
+			IP:         family["ip"],
+			UserAgent:  family["user_agent"],
+			CreatedAt:  parseUnix(family["created_at"]),
+			LastUsedAt: parseUnix(family["last_used_at"]),
+		})
+	}
+	if len(stale) > 0 {
+		if err := s.redis.SRem(ctx, userKey, stale...).Err(); err != nil {
+			return nil, errors.Wrap(err, "SessionStorage.GetUserSessions.SRem")
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// Delete session of the user by id, redis.Nil when the user has no such session
+func (s *SessionStorage) DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.DeleteSessionByID")
+	defer span.Finish()
+
+	revoked, err := s.revokeFamily(ctx, userID, sessionID.String())
+	if err != nil {
+		return errors.Wrap(err, "SessionStorage.DeleteSessionByID")
+	}
+	if !revoked {
+		return errors.Wrap(redis.Nil, "SessionStorage.DeleteSessionByID")
+	}
+	return nil
+}
+
+// Delete all sessions of the user
+func (s *SessionStorage) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.DeleteUserSessions")
+	defer span.Finish()
+
+	if err := s.deleteSessions(ctx, userID, uuid.Nil); err != nil {
+		return errors.Wrap(err, "SessionStorage.DeleteUserSessions")
 	}
 	return nil
 }
 
-func newRefreshToken() string {
-	b := make([]byte, 32)
+// Delete all sessions of the user except the kept one
+func (s *SessionStorage) DeleteOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRedis.DeleteOtherSessions")
+	defer span.Finish()
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
+	if err := s.deleteSessions(ctx, userID, keepSessionID); err != nil {
+		return errors.Wrap(err, "SessionStorage.DeleteOtherSessions")
+	}
+	return nil
+}
 
-	_, err := r.Read(b)
+func (s *SessionStorage) deleteSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	userKey := userSessionsKey(userID)
+	members, err := s.redis.SMembers(ctx, userKey).Result()
 	if err != nil {
-		return ""
+		return errors.Wrap(err, "SMembers")
 	}
 
-	return fmt.Sprintf("%x", b)
-}
\ No newline at end of file
+	for _, member := range members {
+		if _, err := uuid.Parse(member); err != nil {
+			// refresh token indexed before sessions had ids
+			if err := s.redis.SRem(ctx, userKey, member).Err(); err != nil {
+				return errors.Wrap(err, "SRem")
+			}
+			if err := s.redis.Del(ctx, member).Err(); err != nil {
+				return errors.Wrap(err, "Del")
+			}
+			continue
+		}
+		if member == keepSessionID.String() {
+			continue
+		}
+		if _, err := s.revokeFamily(ctx, userID, member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SessionStorage) revokeFamily(ctx context.Context, userID uuid.UUID, familyID string) (bool, error) {
+	familyKey := sessionFamilyPrefix + familyID
+	for attempt := 0; attempt < familyAttempts; attempt++ {
+		token, err := s.redis.HGet(ctx, familyKey, "token").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return false, errors.Wrap(err, "revokeFamily.HGet")
+		}
+		tokenKey := token
+		if tokenKey == "" {
+			tokenKey = familyKey
+		}
+
+		keys := []string{familyKey, userSessionsKey(userID), tokenKey}
+		revoked, err := revokeSessionScript.Run(ctx, s.redis, keys, userID.String(), familyID, token).Int()
+		if err != nil {
+			return false, errors.Wrap(err, "revokeFamily.Run")
+		}
+		if revoked >= 0 {
+			return revoked == 1, nil
+		}
+	}
+	return false, errors.Wrap(errFamilyChanged, "revokeFamily")
+}
+
+// Keys of the session family a refresh token belongs to
+type tokenFamily struct {
+	familyKey string
+	userKey   string
+	token     string
+}
+
+// Family, user sessions and current token keys, unset token is passed
+// as the family key so every key of the script is declared
+func (f *tokenFamily) keys() []string {
+	tokenKey := f.token
+	if tokenKey == "" {
+		tokenKey = f.familyKey
+	}
+	return []string{f.familyKey, f.userKey, tokenKey}
+}
+
+// Find family of the session or the rotated marker under the keys of the
+// rotate script, sessions created before families join the new family.
+// Returns redis.Nil when the token is unknown
+func (s *SessionStorage) familyOfToken(ctx context.Context, keys []string, newFamilyID string) (*tokenFamily, error) {
+	pipe := s.redis.Pipeline()
+	cmds := []*redis.StringCmd{
+		pipe.Get(ctx, keys[0]), pipe.Get(ctx, keys[3]),
+		pipe.Get(ctx, keys[1]), pipe.Get(ctx, keys[4]),
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errors.Wrap(err, "familyOfToken.Exec")
+	}
+
+	for _, cmd := range cmds {
+		value, err := cmd.Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "familyOfToken.Get")
+		}
+
+		var session struct {
+			UserID   string `json:"user_id"`
+			FamilyID string `json:"family_id"`
+		}
+		if err := json.Unmarshal([]byte(value), &session); err != nil {
+			return nil, errors.Wrap(err, "familyOfToken.Unmarshal")
+		}
+		if session.FamilyID == "" {
+			session.FamilyID = newFamilyID
+		}
+
+		family := &tokenFamily{
+			familyKey: sessionFamilyPrefix + session.FamilyID,
+			userKey:   userSessionsPrefix + session.UserID,
+		}
+		family.token, err = s.redis.HGet(ctx, family.familyKey, "token").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, errors.Wrap(err, "familyOfToken.HGet")
+		}
+		return family, nil
+	}
+	return nil, redis.Nil
+}
+
+func (s *SessionStorage) getSession(ctx context.Context, refreshToken string) (*entity.Session, error) {
+	sessionBytes, ttl, err := getWithTTL(ctx, s.redis, s.sessionKey(refreshToken))
+	if errors.Is(err, redis.Nil) && isPlainToken(refreshToken) {
+		// session created before tokens were hashed, readable until it expires
+		sessionBytes, ttl, err = getWithTTL(ctx, s.redis, refreshToken)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getSession.Get")
+	}
+	session := &entity.Session{}
+	if err = json.Unmarshal(sessionBytes, session); err != nil {
+		return nil, errors.Wrap(err, "getSession.Unmarshal")
+	}
+	session.RefreshToken = refreshToken
+	if ttl > 0 {
+		session.ExpiresAt = time.Now().Add(ttl).Truncate(time.Second)
+	}
+	return session, nil
+}
+
+// Value of the key with its time to live
+func getWithTTL(ctx context.Context, client *redis.Client, key string) ([]byte, time.Duration, error) {
+	pipe := client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.TTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, err
+	}
+	value, err := get.Bytes()
+	return value, ttl.Val(), err
+}
+
+// Redis key of the session
+func (s *SessionStorage) sessionKey(refreshToken string) string {
+	return refreshTokenPrefix + s.tokenHash(refreshToken)
+}
+
+// HMAC of the refresh token with the server secret
+func (s *SessionStorage) tokenHash(refreshToken string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(refreshToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Whether token has the format of refresh tokens which were stored as plain keys
+func isPlainToken(refreshToken string) bool {
+	if len(refreshToken) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(refreshToken)
+	return err == nil
+}
+
+func parseUnix(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+func sessionFamilyKey(familyID uuid.UUID) string {
+	return sessionFamilyPrefix + familyID.String()
+}
+
+func rotatedTokenKey(tokenHash string) string {
+	return rotatedTokenPrefix + tokenHash
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return userSessionsPrefix + userID.String()
+}
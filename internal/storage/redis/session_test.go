@@ -2,10 +2,13 @@ package redisrepo
 
 import (
 	"context"
+	"encoding/hex"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/token"
 	"github.com/alicebob/miniredis"
 	"github.com/go-redis/redis/v9"
 	"github.com/google/uuid"
@@ -21,17 +24,26 @@ func SetupSessionRedis() *SessionStorage {
 		Addr: mr.Addr(),
 	})
 
-	sessionRedisStorage := newSessionStorage(client)
+	sessionRedisStorage := newSessionStorage(client, []byte("secret"))
 	return sessionRedisStorage
 }
 
+// Refresh token in the format used before tokens were prefixed and hashed
+func newPlainToken() string {
+	b, err := token.Random(32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
 func TestRedis_CreateSession(t *testing.T) {
 	t.Parallel()
 
 	sessionRedisStorage := SetupSessionRedis()
 
 	t.Run("CreateSession", func(t *testing.T) {
-		refreshToken := newRefreshToken()
+		refreshToken := newPlainToken()
 		session := &entity.Session{
 			RefreshToken: refreshToken,
 		}
@@ -39,6 +51,37 @@ func TestRedis_CreateSession(t *testing.T) {
 		s, err := sessionRedisStorage.CreateSession(context.Background(), session, 10)
 		require.NoError(t, err)
 		require.NotEqual(t, s, "")
+
+		tokenType, err := token.Parse(s)
+		require.NoError(t, err)
+		require.Equal(t, token.RefreshToken, tokenType)
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		seen := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			s, err := sessionRedisStorage.CreateSession(context.Background(), &entity.Session{}, 10)
+			require.NoError(t, err)
+			require.False(t, seen[s])
+			seen[s] = true
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		s, err := sessionRedisStorage.CreateSession(context.Background(), &entity.Session{}, 10)
+		require.NoError(t, err)
+
+		tampered := []byte(s)
+		if tampered[10] == 'a' {
+			tampered[10] = 'b'
+		} else {
+			tampered[10] = 'a'
+		}
+		_, err = token.Parse(string(tampered))
+		require.ErrorIs(t, err, token.ErrInvalidChecksum)
+
+		_, err = token.Parse(newPlainToken())
+		require.ErrorIs(t, err, token.ErrMalformed)
 	})
 }
 
@@ -49,7 +92,7 @@ func TestRedis_GetUserID(t *testing.T) {
 
 	t.Run("GetSessionByID", func(t *testing.T) {
 		userId := uuid.New()
-		refreshToken := newRefreshToken()
+		refreshToken := newPlainToken()
 		session := &entity.Session{
 			RefreshToken: refreshToken,
 			UserID: userId,
@@ -72,7 +115,7 @@ func TestRedis_DeleteSession(t *testing.T) {
 
 	t.Run("DeleteSession", func(t *testing.T) {
 		userId := uuid.New()
-		refreshToken := newRefreshToken()
+		refreshToken := newPlainToken()
 		session := &entity.Session{
 			RefreshToken: refreshToken,
 			UserID: userId,
@@ -86,4 +129,233 @@ func TestRedis_DeleteSession(t *testing.T) {
 		require.NoError(t, err)
 		require.Nil(t, err)
 	})
-}
\ No newline at end of file
+}
+
+func TestRedis_DeleteUserSessions(t *testing.T) {
+	t.Parallel()
+
+	sessionRedisStorage := SetupSessionRedis()
+
+	t.Run("DeleteUserSessions", func(t *testing.T) {
+		userId := uuid.New()
+
+		first, err := sessionRedisStorage.CreateSession(context.Background(), &entity.Session{UserID: userId}, 10)
+		require.NoError(t, err)
+		second, err := sessionRedisStorage.CreateSession(context.Background(), &entity.Session{UserID: userId}, 10)
+		require.NoError(t, err)
+
+		err = sessionRedisStorage.DeleteUserSessions(context.Background(), userId)
+		require.NoError(t, err)
+
+		_, err = sessionRedisStorage.GetUserID(context.Background(), first)
+		require.Error(t, err)
+		_, err = sessionRedisStorage.GetUserID(context.Background(), second)
+		require.Error(t, err)
+	})
+}
+func TestRedis_RotateSession(t *testing.T) {
+	t.Parallel()
+
+	sessionRedisStorage := SetupSessionRedis()
+	ctx := context.Background()
+
+	userID := uuid.New()
+	first, err := sessionRedisStorage.CreateSession(ctx, &entity.Session{
+		UserID: userID,
+	}, 10)
+	require.NoError(t, err)
+
+	var second *entity.Session
+	t.Run("Rotate", func(t *testing.T) {
+		second, err = sessionRedisStorage.RotateSession(ctx, &entity.Session{RefreshToken: first}, 10)
+		require.NoError(t, err)
+		require.NotEqual(t, first, second.RefreshToken)
+		require.Equal(t, userID, second.UserID)
+
+		// sign in time stays with the session across rotation
+		session, err := sessionRedisStorage.GetSession(ctx, second.RefreshToken)
+		require.NoError(t, err)
+		require.NotNil(t, session.AuthTime)
+
+		_, err = sessionRedisStorage.GetUserID(ctx, first)
+		require.ErrorIs(t, err, redis.Nil)
+
+		uid, err := sessionRedisStorage.GetUserID(ctx, second.RefreshToken)
+		require.NoError(t, err)
+		require.Equal(t, userID, uid)
+	})
+
+	t.Run("Reuse", func(t *testing.T) {
+		reused, err := sessionRedisStorage.RotateSession(ctx, &entity.Session{RefreshToken: first}, 10)
+		require.ErrorIs(t, err, ErrSessionReused)
+		require.Equal(t, second.FamilyID, reused.FamilyID)
+
+		// the whole family is revoked
+		_, err = sessionRedisStorage.GetUserID(ctx, second.RefreshToken)
+		require.ErrorIs(t, err, redis.Nil)
+		_, err = sessionRedisStorage.RotateSession(ctx, second, 10)
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := sessionRedisStorage.RotateSession(ctx, &entity.Session{RefreshToken: "unknown"}, 10)
+		require.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("StaleFamilyKeys", func(t *testing.T) {
+		refreshToken, err := sessionRedisStorage.CreateSession(ctx, &entity.Session{UserID: userID}, 10)
+		require.NoError(t, err)
+		hash := sessionRedisStorage.tokenHash(refreshToken)
+
+		// family keys read before the session moved to another family are rejected
+		otherFamily := sessionFamilyKey(uuid.New())
+		keys := []string{
+			refreshTokenPrefix + hash, rotatedTokenKey(hash), refreshTokenPrefix + "next",
+			refreshTokenPrefix + hash, rotatedTokenKey(hash),
+			otherFamily, userSessionsKey(userID), otherFamily,
+		}
+		result, err := rotateSessionScript.Run(ctx, sessionRedisStorage.redis, keys,
+			10, sessionFamilyPrefix, userSessionsPrefix, uuid.New().String(), time.Now().Unix(), "", "", "",
+		).Slice()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{int64(2)}, result)
+
+		uid, err := sessionRedisStorage.GetUserID(ctx, refreshToken)
+		require.NoError(t, err)
+		require.Equal(t, userID, uid)
+	})
+}
+
+func TestRedis_UserSessions(t *testing.T) {
+	t.Parallel()
+
+	sessionRedisStorage := SetupSessionRedis()
+	ctx := context.Background()
+
+	userID := uuid.New()
+	current := &entity.Session{
+		UserID:    userID,
+		IP:        "192.0.2.1:1234",
+		UserAgent: "laptop",
+	}
+	currentToken, err := sessionRedisStorage.CreateSession(ctx, current, 10)
+	require.NoError(t, err)
+	other := &entity.Session{
+		UserID:    userID,
+		UserAgent: "phone",
+	}
+	otherToken, err := sessionRedisStorage.CreateSession(ctx, other, 10)
+	require.NoError(t, err)
+	third := &entity.Session{
+		UserID:    userID,
+		UserAgent: "tablet",
+	}
+	_, err = sessionRedisStorage.CreateSession(ctx, third, 10)
+	require.NoError(t, err)
+
+	t.Run("GetUserSessions", func(t *testing.T) {
+		_, err := sessionRedisStorage.RotateSession(ctx, &entity.Session{
+			RefreshToken: currentToken,
+			IP:           "192.0.2.2:1234",
+			UserAgent:    "laptop",
+		}, 10)
+		require.NoError(t, err)
+
+		sessions, err := sessionRedisStorage.GetUserSessions(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 3)
+
+		var found bool
+		for _, session := range sessions {
+			if session.ID == current.FamilyID {
+				found = true
+				require.Equal(t, "192.0.2.2:1234", session.IP)
+				require.False(t, session.CreatedAt.IsZero())
+			}
+		}
+		require.True(t, found)
+	})
+
+	t.Run("DeleteSessionByID", func(t *testing.T) {
+		err := sessionRedisStorage.DeleteSessionByID(ctx, uuid.New(), other.FamilyID)
+		require.ErrorIs(t, err, redis.Nil)
+
+		err = sessionRedisStorage.DeleteSessionByID(ctx, userID, other.FamilyID)
+		require.NoError(t, err)
+
+		_, err = sessionRedisStorage.GetUserID(ctx, otherToken)
+		require.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("DeleteOtherSessions", func(t *testing.T) {
+		err := sessionRedisStorage.DeleteOtherSessions(ctx, userID, current.FamilyID)
+		require.NoError(t, err)
+
+		sessions, err := sessionRedisStorage.GetUserSessions(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		require.Equal(t, current.FamilyID, sessions[0].ID)
+	})
+}
+
+func TestRedis_HashedSessions(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+	sessionRedisStorage := newSessionStorage(client, []byte("secret"))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("NotStoredInPlain", func(t *testing.T) {
+		refreshToken, err := sessionRedisStorage.CreateSession(ctx, &entity.Session{UserID: userID}, 10)
+		require.NoError(t, err)
+
+		for _, key := range mr.Keys() {
+			require.NotContains(t, key, refreshToken)
+			if value, err := mr.Get(key); err == nil {
+				require.NotContains(t, value, refreshToken)
+			}
+		}
+
+		session, err := sessionRedisStorage.GetSession(ctx, refreshToken)
+		require.NoError(t, err)
+		require.Equal(t, refreshToken, session.RefreshToken)
+
+		otherSecret := newSessionStorage(client, []byte("other secret"))
+		_, err = otherSecret.GetUserID(ctx, refreshToken)
+		require.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("PlainKeyMigration", func(t *testing.T) {
+		legacy := newPlainToken()
+		require.NoError(t, mr.Set(legacy, `{"refresh_token":"`+legacy+`","user_id":"`+userID.String()+`"}`))
+		mr.SetTTL(legacy, 10*time.Second)
+
+		uid, err := sessionRedisStorage.GetUserID(ctx, legacy)
+		require.NoError(t, err)
+		require.Equal(t, userID, uid)
+
+		rotated, err := sessionRedisStorage.RotateSession(ctx, &entity.Session{RefreshToken: legacy}, 10)
+		require.NoError(t, err)
+		require.Equal(t, userID, rotated.UserID)
+		require.False(t, mr.Exists(legacy))
+
+		_, err = sessionRedisStorage.RotateSession(ctx, &entity.Session{RefreshToken: legacy}, 10)
+		require.ErrorIs(t, err, ErrSessionReused)
+		_, err = sessionRedisStorage.GetUserID(ctx, rotated.RefreshToken)
+		require.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("DeletePlainKey", func(t *testing.T) {
+		legacy := newPlainToken()
+		require.NoError(t, mr.Set(legacy, `{"user_id":"`+userID.String()+`"}`))
+
+		require.NoError(t, sessionRedisStorage.DeleteSession(ctx, legacy))
+		require.False(t, mr.Exists(legacy))
+	})
+}
@@ -5,16 +5,27 @@ import (
 )
 
 type Deps struct {
-	Redis *redis.Client
+	Redis       *redis.Client
+	TokenSecret []byte
 }
 
 // Storage redis
 type Storage struct {
-	Session *SessionStorage
+	Session    *SessionStorage
+	Token      *TokenStorage
+	Throttle   *ThrottleStorage
+	RateLimit  *RateLimitStorage
+	OAuth      *OAuthStorage
+	Revocation *RevocationStorage
 }
 
 func NewStorage(deps Deps) *Storage {
 	return &Storage{
-		Session: newSessionStorage(deps.Redis),
+		Session:    newSessionStorage(deps.Redis, deps.TokenSecret),
+		Token:      newTokenStorage(deps.Redis),
+		Throttle:   newThrottleStorage(deps.Redis),
+		RateLimit:  newRateLimitStorage(deps.Redis),
+		OAuth:      newOAuthStorage(deps.Redis, deps.TokenSecret),
+		Revocation: newRevocationStorage(deps.Redis),
 	}
 }
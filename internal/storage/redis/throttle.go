@@ -0,0 +1,112 @@
+package redisrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/go-redis/redis/v9"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+const throttlePrefix = "sign-in-throttle:"
+
+// Count failure, set backoff or lockout when the failure reaches the limit.
+// KEYS: failures, backoff, lock
+// ARGV: window, base delay, max delay, lockout in milliseconds, max failures
+var registerFailureScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+if failures == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+
+local maxFailures = tonumber(ARGV[5])
+if maxFailures > 0 and failures >= maxFailures then
+	redis.call('SET', KEYS[3], '1', 'PX', ARGV[4])
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return {failures, tonumber(ARGV[4]), 1}
+end
+
+local delay = math.min(tonumber(ARGV[2]) * 2 ^ (failures - 1), tonumber(ARGV[3]))
+delay = math.floor(delay)
+if delay > 0 then
+	redis.call('SET', KEYS[2], '1', 'PX', string.format('%d', delay))
+end
+return {failures, delay, 0}
+`)
+
+// Failed sign-in throttling redis storage, keys are emails or IPs
+type ThrottleStorage struct {
+	redis *redis.Client
+}
+
+// Throttle storage constructor
+func newThrottleStorage(redis *redis.Client) *ThrottleStorage {
+	return &ThrottleStorage{
+		redis: redis,
+	}
+}
+
+// Get remaining lockout or backoff of the key
+func (s *ThrottleStorage) GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ThrottleRedis.GetThrottle")
+	defer span.Finish()
+
+	pipe := s.redis.Pipeline()
+	lock := pipe.PTTL(ctx, throttleKey(key, "lock"))
+	backoff := pipe.PTTL(ctx, throttleKey(key, "backoff"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "ThrottleStorage.GetThrottle.Exec")
+	}
+
+	// negative ttl means the key does not exist
+	throttle := &entity.LoginThrottle{}
+	switch {
+	case lock.Val() > 0:
+		throttle.Locked = true
+		throttle.RetryAfter = lock.Val()
+	case backoff.Val() > 0:
+		throttle.RetryAfter = backoff.Val()
+	}
+	return throttle, nil
+}
+
+// Count failed sign-in of the key, returns backoff or lockout caused by it
+func (s *ThrottleStorage) RegisterFailure(ctx context.Context, key string, policy *entity.ThrottlePolicy) (*entity.LoginThrottle, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ThrottleRedis.RegisterFailure")
+	defer span.Finish()
+
+	keys := []string{throttleKey(key, "failures"), throttleKey(key, "backoff"), throttleKey(key, "lock")}
+	result, err := registerFailureScript.Run(ctx, s.redis, keys,
+		policy.Window.Milliseconds(),
+		policy.BaseDelay.Milliseconds(),
+		policy.MaxDelay.Milliseconds(),
+		policy.Lockout.Milliseconds(),
+		policy.MaxFailures,
+	).Int64Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "ThrottleStorage.RegisterFailure.Run")
+	}
+
+	return &entity.LoginThrottle{
+		Failures:   int(result[0]),
+		RetryAfter: time.Duration(result[1]) * time.Millisecond,
+		Locked:     result[2] == 1,
+	}, nil
+}
+
+// Forget failures, backoff and lockout of the key
+func (s *ThrottleStorage) ResetThrottle(ctx context.Context, key string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ThrottleRedis.ResetThrottle")
+	defer span.Finish()
+
+	if err := s.redis.Del(ctx, throttleKey(key, "failures"), throttleKey(key, "backoff"), throttleKey(key, "lock")).Err(); err != nil {
+		return errors.Wrap(err, "ThrottleStorage.ResetThrottle.Del")
+	}
+	return nil
+}
+
+func throttleKey(key, kind string) string {
+	return throttlePrefix + key + ":" + kind
+}
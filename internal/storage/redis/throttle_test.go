@@ -0,0 +1,84 @@
+package redisrepo
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func SetupThrottleRedis() *ThrottleStorage {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return newThrottleStorage(client)
+}
+
+func TestRedis_Throttle(t *testing.T) {
+	t.Parallel()
+
+	throttleRedisStorage := SetupThrottleRedis()
+	policy := &entity.ThrottlePolicy{
+		MaxFailures: 3,
+		Window:      time.Minute,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		Lockout:     time.Hour,
+	}
+
+	t.Run("Backoff", func(t *testing.T) {
+		ctx := context.Background()
+
+		throttle, err := throttleRedisStorage.RegisterFailure(ctx, "email:backoff@gmail.com", policy)
+		require.NoError(t, err)
+		require.Equal(t, 1, throttle.Failures)
+		require.Equal(t, time.Second, throttle.RetryAfter)
+		require.False(t, throttle.Locked)
+
+		throttle, err = throttleRedisStorage.RegisterFailure(ctx, "email:backoff@gmail.com", policy)
+		require.NoError(t, err)
+		require.Equal(t, 2, throttle.Failures)
+		require.Equal(t, 2*time.Second, throttle.RetryAfter)
+
+		throttle, err = throttleRedisStorage.GetThrottle(ctx, "email:backoff@gmail.com")
+		require.NoError(t, err)
+		require.False(t, throttle.Locked)
+		require.Equal(t, 2*time.Second, throttle.RetryAfter)
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		ctx := context.Background()
+
+		var throttle *entity.LoginThrottle
+		var err error
+		for i := 0; i < policy.MaxFailures; i++ {
+			throttle, err = throttleRedisStorage.RegisterFailure(ctx, "email:lockout@gmail.com", policy)
+			require.NoError(t, err)
+		}
+		require.True(t, throttle.Locked)
+		require.Equal(t, time.Hour, throttle.RetryAfter)
+
+		throttle, err = throttleRedisStorage.GetThrottle(ctx, "email:lockout@gmail.com")
+		require.NoError(t, err)
+		require.True(t, throttle.Locked)
+		require.Equal(t, time.Hour, throttle.RetryAfter)
+
+		err = throttleRedisStorage.ResetThrottle(ctx, "email:lockout@gmail.com")
+		require.NoError(t, err)
+
+		throttle, err = throttleRedisStorage.GetThrottle(ctx, "email:lockout@gmail.com")
+		require.NoError(t, err)
+		require.False(t, throttle.Locked)
+		require.Zero(t, throttle.RetryAfter)
+	})
+}
@@ -0,0 +1,76 @@
+package redisrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// Single-use token redis storage
+type TokenStorage struct {
+	redis *redis.Client
+}
+
+// Token storage constructor
+func newTokenStorage(redis *redis.Client) *TokenStorage {
+	return &TokenStorage{
+		redis: redis,
+	}
+}
+
+// Save token with owner user id
+func (s *TokenStorage) CreateToken(ctx context.Context, kind, tokenID string, userID uuid.UUID, expire int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRedis.CreateToken")
+	defer span.Finish()
+
+	if err := s.redis.Set(ctx, tokenKey(kind, tokenID), userID.String(), time.Second*time.Duration(expire)).Err(); err != nil {
+		return errors.Wrap(err, "TokenStorage.CreateToken.Set")
+	}
+	return nil
+}
+
+// Get token owner without using the token up
+func (s *TokenStorage) GetToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRedis.GetToken")
+	defer span.Finish()
+
+	value, err := s.redis.Get(ctx, tokenKey(kind, tokenID)).Result()
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "TokenStorage.GetToken.Get")
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "TokenStorage.GetToken.Parse")
+	}
+	return userID, nil
+}
+
+// Get token owner and delete token, so it can be used only once
+func (s *TokenStorage) ConsumeToken(ctx context.Context, kind, tokenID string) (uuid.UUID, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRedis.ConsumeToken")
+	defer span.Finish()
+
+	key := tokenKey(kind, tokenID)
+	pipe := s.redis.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return uuid.Nil, errors.Wrap(err, "TokenStorage.ConsumeToken.Exec")
+	}
+
+	userID, err := uuid.Parse(get.Val())
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "TokenStorage.ConsumeToken.Parse")
+	}
+	return userID, nil
+}
+
+func tokenKey(kind, tokenID string) string {
+	return fmt.Sprintf("%s:%s", kind, tokenID)
+}
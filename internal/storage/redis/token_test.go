@@ -0,0 +1,72 @@
+package redisrepo
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func SetupTokenRedis() *TokenStorage {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	return newTokenStorage(client)
+}
+
+func TestRedis_ConsumeToken(t *testing.T) {
+	t.Parallel()
+
+	tokenRedisStorage := SetupTokenRedis()
+
+	t.Run("ConsumeToken", func(t *testing.T) {
+		userID := uuid.New()
+		tokenID := uuid.New().String()
+
+		err := tokenRedisStorage.CreateToken(context.Background(), "verify_email", tokenID, userID, 10)
+		require.NoError(t, err)
+
+		uid, err := tokenRedisStorage.ConsumeToken(context.Background(), "verify_email", tokenID)
+		require.NoError(t, err)
+		require.Equal(t, uid, userID)
+
+		_, err = tokenRedisStorage.ConsumeToken(context.Background(), "verify_email", tokenID)
+		require.Error(t, err)
+	})
+}
+
+func TestRedis_GetToken(t *testing.T) {
+	t.Parallel()
+
+	tokenRedisStorage := SetupTokenRedis()
+
+	t.Run("GetToken", func(t *testing.T) {
+		userID := uuid.New()
+		tokenID := uuid.New().String()
+
+		err := tokenRedisStorage.CreateToken(context.Background(), "reset_password", tokenID, userID, 10)
+		require.NoError(t, err)
+
+		// token is not used up by lookup
+		for i := 0; i < 2; i++ {
+			uid, err := tokenRedisStorage.GetToken(context.Background(), "reset_password", tokenID)
+			require.NoError(t, err)
+			require.Equal(t, uid, userID)
+		}
+
+		_, err = tokenRedisStorage.ConsumeToken(context.Background(), "reset_password", tokenID)
+		require.NoError(t, err)
+
+		_, err = tokenRedisStorage.GetToken(context.Background(), "reset_password", tokenID)
+		require.Error(t, err)
+	})
+}
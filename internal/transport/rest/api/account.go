@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+type DeleteAccount struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// DeleteMe godoc
+// @Summary Delete account
+// @Description delete current user checking the password, all sessions are revoked, the account can be restored by the link from the letter during the grace period
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body DeleteAccount true "current password"
+// @Success 202 {string} string	"accepted"
+// @Failure 400 {object} httpe.RestError
+// @Router /user/me [delete]
+func (h *UserHandler) DeleteMe() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.DeleteMe")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &DeleteAccount{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.DeleteAccount(ctx, user.ID, input.Password); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+		utils.DeleteCookie(c, h.config.Cookie.Name)
+
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+// RestoreAccount godoc
+// @Summary Restore account
+// @Description restore deleted account with token from deletion letter
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailToken true "restore token"
+// @Success 200 {string} string	"ok"
+// @Failure 400 {object} httpe.RestError
+// @Router /user/restore [post]
+func (h *UserHandler) RestoreAccount() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.RestoreAccount")
+		defer span.Finish()
+
+		token := &VerifyEmailToken{}
+		if err := utils.ReadRequest(c, token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.RestoreAccount(ctx, token.Token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Account(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+	mockExportService := mockservice.NewMockExport(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			Name: "jwt-token",
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+	exportHandler := NewExportHandler(mockExportService)
+	user := &entity.User{ID: uuid.New(), Name: "PavelV"}
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.Set("user", user)
+		return c, recorder
+	}
+
+	t.Run("DeleteMe", func(t *testing.T) {
+		c, recorder := newContext(http.MethodDelete, "/api/user/me", `{"password":"12345678"}`)
+
+		mockUserService.EXPECT().DeleteAccount(gomock.Any(), user.ID, "12345678").Return(nil)
+
+		err := userHandler.DeleteMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		require.Contains(t, recorder.Header().Get(echo.HeaderSetCookie), "jwt-token=")
+	})
+
+	t.Run("DeleteMeWithoutPassword", func(t *testing.T) {
+		c, recorder := newContext(http.MethodDelete, "/api/user/me", `{}`)
+
+		err := userHandler.DeleteMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("RestoreAccount", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "/api/user/restore", `{"token":"restore token"}`)
+
+		mockUserService.EXPECT().RestoreAccount(gomock.Any(), "restore token").Return(nil)
+
+		err := userHandler.RestoreAccount()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("ExportMe", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me/export", "")
+
+		mockExportService.EXPECT().ExportUser(gomock.Any(), user.ID).Return(&entity.UserExport{
+			Profile:  user,
+			Passkeys: []*entity.WebAuthnCredential{},
+			Sessions: []*entity.SessionInfo{},
+		}, nil)
+
+		err := exportHandler.ExportMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Header().Get(echo.HeaderContentDisposition), "attachment")
+		require.Contains(t, recorder.Body.String(), `"name":"PavelV"`)
+	})
+}
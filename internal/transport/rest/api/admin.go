@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Admin user management service interface
+type AdminService interface {
+	ListUsers(ctx context.Context, filter *entity.UserFilter) (*entity.UserList, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	ForcePasswordReset(ctx context.Context, userID uuid.UUID) error
+	SuspendUser(ctx context.Context, userID uuid.UUID) error
+	UnsuspendUser(ctx context.Context, userID uuid.UUID) error
+	UnlockUser(ctx context.Context, userID uuid.UUID) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// init admin handlers
+func (h *Handlers) initAdminHandlers(api *echo.Group, mw *middlewares.MiddlewareManager) {
+	admin := api.Group("/admin", mw.AuthJWTMiddleware())
+	{
+		read := mw.RequirePermission(entity.PermissionUsersRead)
+		write := mw.RequirePermission(entity.PermissionUsersWrite)
+
+		users := admin.Group("/users")
+		users.GET("", h.admin.ListUsers(), read)
+		users.GET("/:id", h.admin.GetUser(), read)
+		users.PATCH("/:id", h.admin.UpdateUser(), write)
+		users.POST("/:id/password-reset", h.admin.ForcePasswordReset(), write)
+		users.POST("/:id/suspend", h.admin.SuspendUser(), write)
+		users.POST("/:id/unsuspend", h.admin.UnsuspendUser(), write)
+		users.POST("/:id/unlock", h.admin.UnlockUser(), write)
+		users.DELETE("/:id", h.admin.DeleteUser(), write)
+
+		admin.GET("/audit", h.admin.ListAuditEvents(), mw.RequirePermission(entity.PermissionAuditRead))
+	}
+}
+
+// Admin handler
+type AdminHandler struct {
+	admin AdminService
+	audit AuditService
+}
+
+// New admin handler constructor
+func NewAdminHandler(admin AdminService, audit AuditService) *AdminHandler {
+	return &AdminHandler{
+		admin: admin,
+		audit: audit,
+	}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description paginated users, newest first, email and name match substrings
+// @Tags Admin
+// @Produce json
+// @Param email query string false "email substring"
+// @Param name query string false "name substring"
+// @Param created_after query string false "RFC 3339 time or date, inclusive"
+// @Param created_before query string false "RFC 3339 time or date, exclusive"
+// @Param page query int false "page number, starts with 1"
+// @Param size query int false "page size, 20 by default, 100 at most"
+// @Success 200 {object} entity.UserList
+// @Failure 400 {object} httpe.RestError
+// @Failure 403 {object} httpe.RestError
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "AdminHandler.ListUsers")
+		defer span.Finish()
+
+		filter, err := parseUserFilter(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httpe.NewBadRequestError(httpe.BadQueryParams))
+		}
+
+		users, err := h.admin.ListUsers(ctx, filter)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, users)
+	}
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Description user with roles and permissions
+// @Tags Admin
+// @Produce json
+// @Param id path string true "user id"
+// @Success 200 {object} entity.User
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "AdminHandler.GetUser")
+		defer span.Finish()
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, httpe.NewNotFoundError(httpe.NotFound))
+		}
+
+		user, err := h.admin.GetUser(ctx, userID)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+type adminUpdateUser struct {
+	Name  string `json:"name" validate:"omitempty,lte=30"`
+	Email string `json:"email" validate:"omitempty,lte=60,email"`
+}
+
+// UpdateUser godoc
+// @Summary Update user
+// @Description change name and email, omitted fields are kept, email verification is reset on email change
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Param input body adminUpdateUser true "new name and email"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} httpe.RestError
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id} [patch]
+func (h *AdminHandler) UpdateUser() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "AdminHandler.UpdateUser")
+		defer span.Finish()
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, httpe.NewNotFoundError(httpe.NotFound))
+		}
+
+		input := &adminUpdateUser{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		user, err := h.admin.UpdateUser(ctx, &entity.User{
+			ID:    userID,
+			Name:  input.Name,
+			Email: input.Email,
+		})
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+// ForcePasswordReset godoc
+// @Summary Force password reset
+// @Description invalidate current password, sign out all devices and send password reset letter
+// @Tags Admin
+// @Param id path string true "user id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id}/password-reset [post]
+func (h *AdminHandler) ForcePasswordReset() echo.HandlerFunc {
+	return h.userAction("AdminHandler.ForcePasswordReset", h.admin.ForcePasswordReset)
+}
+
+// SuspendUser godoc
+// @Summary Suspend user
+// @Description block sign in and sign out all devices
+// @Tags Admin
+// @Param id path string true "user id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id}/suspend [post]
+func (h *AdminHandler) SuspendUser() echo.HandlerFunc {
+	return h.userAction("AdminHandler.SuspendUser", h.admin.SuspendUser)
+}
+
+// UnsuspendUser godoc
+// @Summary Unsuspend user
+// @Description allow suspended user to sign in again
+// @Tags Admin
+// @Param id path string true "user id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id}/unsuspend [post]
+func (h *AdminHandler) UnsuspendUser() echo.HandlerFunc {
+	return h.userAction("AdminHandler.UnsuspendUser", h.admin.UnsuspendUser)
+}
+
+// UnlockUser godoc
+// @Summary Unlock user
+// @Description lift sign-in lockout after too many failed attempts
+// @Tags Admin
+// @Param id path string true "user id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser() echo.HandlerFunc {
+	return h.userAction("AdminHandler.UnlockUser", h.admin.UnlockUser)
+}
+
+// DeleteUser godoc
+// @Summary Delete user
+// @Description delete user permanently with passkeys, MFA and sessions
+// @Tags Admin
+// @Param id path string true "user id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser() echo.HandlerFunc {
+	return h.userAction("AdminHandler.DeleteUser", h.admin.DeleteUser)
+}
+
+// Handler of action on user from path id
+func (h *AdminHandler) userAction(operation string, action func(ctx context.Context, userID uuid.UUID) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), operation)
+		defer span.Finish()
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, httpe.NewNotFoundError(httpe.NotFound))
+		}
+
+		if err := action(ctx, userID); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// Users filter from query params
+func parseUserFilter(c echo.Context) (*entity.UserFilter, error) {
+	filter := &entity.UserFilter{
+		Email: c.QueryParam("email"),
+		Name:  c.QueryParam("name"),
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(c.QueryParam("created_after")); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(c.QueryParam("created_before")); err != nil {
+		return nil, err
+	}
+	if page := c.QueryParam("page"); page != "" {
+		if filter.Page, err = strconv.Atoi(page); err != nil {
+			return nil, err
+		}
+	}
+	if size := c.QueryParam("size"); size != "" {
+		if filter.Size, err = strconv.Atoi(size); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// RFC 3339 time or date, nil when empty
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ListUsers(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminService := mockservice.NewMockAdmin(ctrl)
+	adminHandler := NewAdminHandler(mockAdminService, anyAudit(ctrl))
+
+	t.Run("Filter", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/api/admin/users?email=gmail&name=Pavel&created_after=2022-01-01&created_before=2022-02-01T10:00:00Z&page=2&size=5", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		after := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		before := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+		filter := &entity.UserFilter{
+			Email:         "gmail",
+			Name:          "Pavel",
+			CreatedAfter:  &after,
+			CreatedBefore: &before,
+			Page:          2,
+			Size:          5,
+		}
+		list := &entity.UserList{
+			Users: []*entity.User{{ID: uuid.New(), Email: "edbeermtn@gmail.com"}},
+			Total: 6,
+			Page:  2,
+			Size:  5,
+		}
+		mockAdminService.EXPECT().ListUsers(gomock.Any(), filter).Return(list, nil)
+
+		err := adminHandler.ListUsers()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `"total":6`)
+	})
+
+	t.Run("BadQueryParams", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/api/admin/users?created_after=yesterday", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		err := adminHandler.ListUsers()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestHandler_AdminUserActions(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminService := mockservice.NewMockAdmin(ctrl)
+	adminHandler := NewAdminHandler(mockAdminService, anyAudit(ctrl))
+	userID := uuid.New()
+
+	newContext := func(method, body, id string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		request := httptest.NewRequest(method, "/api/admin/users/"+id, strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return c, recorder
+	}
+
+	t.Run("GetUserInvalidID", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "", "not-uuid")
+
+		err := adminHandler.GetUser()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("UpdateUser", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPatch, `{"name":"Pavel"}`, userID.String())
+
+		mockAdminService.EXPECT().UpdateUser(gomock.Any(), &entity.User{ID: userID, Name: "Pavel"}).
+			Return(&entity.User{ID: userID, Name: "Pavel"}, nil)
+
+		err := adminHandler.UpdateUser()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UpdateUserInvalidEmail", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPatch, `{"email":"not email"}`, userID.String())
+
+		err := adminHandler.UpdateUser()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("SuspendUser", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "", userID.String())
+
+		mockAdminService.EXPECT().SuspendUser(gomock.Any(), userID).Return(nil)
+
+		err := adminHandler.SuspendUser()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UnlockUser", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "", userID.String())
+
+		mockAdminService.EXPECT().UnlockUser(gomock.Any(), userID).Return(nil)
+
+		err := adminHandler.UnlockUser()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
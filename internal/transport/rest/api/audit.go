@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Security audit log service interface
+type AuditService interface {
+	Record(ctx context.Context, event string, userID uuid.UUID, err error)
+	ListEvents(ctx context.Context, filter *entity.AuditFilter) (*entity.AuditList, error)
+}
+
+// ListAuditEvents godoc
+// @Summary List audit log
+// @Description paginated security audit log, newest first
+// @Tags Admin
+// @Produce json
+// @Param user_id query string false "user id"
+// @Param event query string false "event type"
+// @Param from query string false "RFC 3339 time or date, inclusive"
+// @Param to query string false "RFC 3339 time or date, exclusive"
+// @Param page query int false "page number, starts with 1"
+// @Param size query int false "page size, 20 by default, 100 at most"
+// @Success 200 {object} entity.AuditList
+// @Failure 400 {object} httpe.RestError
+// @Failure 403 {object} httpe.RestError
+// @Router /admin/audit [get]
+func (h *AdminHandler) ListAuditEvents() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "AdminHandler.ListAuditEvents")
+		defer span.Finish()
+
+		filter, err := parseAuditFilter(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httpe.NewBadRequestError(httpe.BadQueryParams))
+		}
+
+		events, err := h.audit.ListEvents(ctx, filter)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, events)
+	}
+}
+
+// Audit log filter from query params
+func parseAuditFilter(c echo.Context) (*entity.AuditFilter, error) {
+	filter := &entity.AuditFilter{
+		Event: c.QueryParam("event"),
+	}
+
+	var err error
+	if userID := c.QueryParam("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserID = &id
+	}
+	if filter.From, err = parseTimeParam(c.QueryParam("from")); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseTimeParam(c.QueryParam("to")); err != nil {
+		return nil, err
+	}
+	if page := c.QueryParam("page"); page != "" {
+		if filter.Page, err = strconv.Atoi(page); err != nil {
+			return nil, err
+		}
+	}
+	if size := c.QueryParam("size"); size != "" {
+		if filter.Size, err = strconv.Atoi(size); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
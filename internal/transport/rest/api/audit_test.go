@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// Audit service mock accepting any event
+func anyAudit(ctrl *gomock.Controller) *mockservice.MockAudit {
+	mockAuditService := mockservice.NewMockAudit(ctrl)
+	mockAuditService.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	return mockAuditService
+}
+
+func TestHandler_ListAuditEvents(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminService := mockservice.NewMockAdmin(ctrl)
+	mockAuditService := mockservice.NewMockAudit(ctrl)
+	adminHandler := NewAdminHandler(mockAdminService, mockAuditService)
+
+	t.Run("Filter", func(t *testing.T) {
+		userID := uuid.New()
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/api/admin/audit?user_id="+userID.String()+"&event=sign_in&from=2022-10-01&page=2", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		mockAuditService.EXPECT().ListEvents(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, filter *entity.AuditFilter) (*entity.AuditList, error) {
+				require.Equal(t, userID, *filter.UserID)
+				require.Equal(t, entity.AuditSignIn, filter.Event)
+				require.NotNil(t, filter.From)
+				require.Nil(t, filter.To)
+				require.Equal(t, 2, filter.Page)
+				return &entity.AuditList{Events: []*entity.AuditEvent{{Seq: 1, Event: entity.AuditSignIn}}, Total: 1, Page: 2, Size: 20}, nil
+			})
+
+		err := adminHandler.ListAuditEvents()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `"event":"sign_in"`)
+	})
+
+	t.Run("BadUserID", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/api/admin/audit?user_id=1", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		err := adminHandler.ListAuditEvents()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestHandler_AuditClient(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{Server: config.Server{TrustedProxies: []string{"10.0.0.0/8"}}}
+	ipExtractor, err := utils.NewIPExtractor(cfg)
+	require.NoError(t, err)
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockAuditService := mockservice.NewMockAudit(ctrl)
+	userHandler := NewUserHandler(cfg, mockUserService, nil, mockAuditService)
+
+	auditedClient := func(remoteAddr string) utils.Client {
+		e := echo.New()
+		e.IPExtractor = ipExtractor
+		request := httptest.NewRequest(http.MethodPost, "/api/user/sign-in", strings.NewReader(`{"email":"edbeermtn@gmail.com","password":"12345678"}`))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderXForwardedFor, "203.0.113.9")
+		request.Header.Set("User-Agent", "Firefox")
+		request.RemoteAddr = remoteAddr
+		c := e.NewContext(request, httptest.NewRecorder())
+
+		var client utils.Client
+		mockUserService.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(nil, nil, httpe.NewUnauthorizedError(httpe.WrongCredentials))
+		mockAuditService.EXPECT().Record(gomock.Any(), entity.AuditSignIn, uuid.Nil, gomock.Any()).
+			Do(func(ctx context.Context, _ string, _ uuid.UUID, _ error) {
+				client = utils.GetClientFromCtx(ctx)
+			})
+
+		require.NoError(t, userHandler.SignIn()(c))
+		return client
+	}
+
+	t.Run("TrustedProxy", func(t *testing.T) {
+		require.Equal(t, utils.Client{IP: "203.0.113.9", UserAgent: "Firefox"}, auditedClient("10.0.0.2:5000"))
+	})
+
+	t.Run("DirectClient", func(t *testing.T) {
+		// forwarded address is ignored and the port is not recorded
+		require.Equal(t, utils.Client{IP: "198.51.100.4", UserAgent: "Firefox"}, auditedClient("198.51.100.4:5000"))
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// User data export service interface
+type ExportService interface {
+	ExportUser(ctx context.Context, userID uuid.UUID) (*entity.UserExport, error)
+}
+
+// Export handler
+type ExportHandler struct {
+	export ExportService
+}
+
+// New export handler constructor
+func NewExportHandler(export ExportService) *ExportHandler {
+	return &ExportHandler{export: export}
+}
+
+// ExportMe godoc
+// @Summary Export account data
+// @Description JSON archive of everything stored about the current user
+// @Tags User
+// @Produce json
+// @Success 200 {object} entity.UserExport
+// @Failure 401 {object} httpe.RestError
+// @Router /user/me/export [get]
+func (h *ExportHandler) ExportMe() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "ExportHandler.ExportMe")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		export, err := h.export.ExportUser(ctx, user.ID)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="account-export.json"`)
+		return c.JSON(http.StatusOK, export)
+	}
+}
@@ -12,20 +12,40 @@ import (
 
 // Dependencies
 type Deps struct {
-	UserService    UserService
-	SessionService SessionService
-	Config         *config.Config
+	UserService     UserService
+	SessionService  SessionService
+	WebAuthnService WebAuthnService
+	AdminService    AdminService
+	ExportService   ExportService
+	AuditService    AuditService
+	OAuthService    OAuthService
+	ServiceAccounts ServiceAccountService
+	RateLimiter     middlewares.RateLimiter
+	KeyManager      KeyManager
+	Config          *config.Config
 }
 
 // Handlers
 type Handlers struct {
-	user *UserHandler
+	user     *UserHandler
+	webauthn *WebAuthnHandler
+	jwks     *JWKSHandler
+	admin    *AdminHandler
+	export   *ExportHandler
+	oauth    *OAuthHandler
+	limiter  middlewares.RateLimiter
 }
 
 // New handlers constructor
 func NewHandlers(deps Deps) *Handlers {
 	return &Handlers{
-		user: NewUserHandler(deps.Config, deps.UserService, deps.SessionService),
+		user:     NewUserHandler(deps.Config, deps.UserService, deps.SessionService, deps.AuditService),
+		webauthn: NewWebAuthnHandler(deps.Config, deps.WebAuthnService, deps.SessionService),
+		jwks:     NewJWKSHandler(deps.KeyManager),
+		admin:    NewAdminHandler(deps.AdminService, deps.AuditService),
+		export:   NewExportHandler(deps.ExportService),
+		oauth:    NewOAuthHandler(deps.Config, deps.OAuthService, deps.ServiceAccounts, deps.UserService, deps.KeyManager),
+		limiter:  deps.RateLimiter,
 	}
 }
 
@@ -43,7 +63,14 @@ func (h *Handlers) Init(e *echo.Echo, logger logger.Logger) error {
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderXRequestID},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderXRequestID, echo.HeaderAuthorization},
+		ExposeHeaders: []string{
+			echo.HeaderRetryAfter,
+			middlewares.HeaderRateLimitLimit,
+			middlewares.HeaderRateLimitRemaining,
+			middlewares.HeaderRateLimitReset,
+			middlewares.HeaderRateLimitPolicy,
+		},
 	}))
 	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: 5,
@@ -56,13 +83,20 @@ func (h *Handlers) Init(e *echo.Echo, logger logger.Logger) error {
 	mw := middlewares.NewMiddlewareManager(
 		h.user.session,
 		h.user.user,
+		h.oauth.accounts,
+		h.jwks.keys,
+		h.limiter,
 		h.user.config,
 		[]string{"*"},
 		logger,
 	)
+	e.Use(mw.RateLimit())
+
 	docs.SwaggerInfo.Title = "Auth JWT example restapi"
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
+	h.initWellKnownHandlers(e)
+	h.initOAuthHandlers(e, mw)
 	h.initApi(e, mw)
 
 	return nil
@@ -72,5 +106,7 @@ func (h *Handlers) initApi(e *echo.Echo, mw *middlewares.MiddlewareManager) {
 	api := e.Group("/api")
 	{
 		h.initUserHandlers(api, mw)
+		h.initWebAuthnHandlers(api, mw)
+		h.initAdminHandlers(api, mw)
 	}
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+// Token key manager interface
+type KeyManager interface {
+	middlewares.KeyProvider
+	JWKS() *jwt.JWKS
+	SigningAlg() string
+}
+
+// init well-known handlers
+func (h *Handlers) initWellKnownHandlers(e *echo.Echo) {
+	wellKnown := e.Group("/.well-known")
+	{
+		wellKnown.GET("/jwks.json", h.jwks.GetJWKS())
+		wellKnown.GET("/openid-configuration", h.oauth.Discovery())
+	}
+}
+
+// JWKS handler
+type JWKSHandler struct {
+	keys KeyManager
+}
+
+// New jwks handler constructor
+func NewJWKSHandler(keys KeyManager) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS godoc
+// @Summary Get token signing keys
+// @Description public keys for access token verification, selected by kid header
+// @Tags Keys
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, h.keys.JWKS())
+	}
+}
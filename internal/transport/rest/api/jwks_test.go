@@ -0,0 +1,171 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/jwt"
+	gojwt "github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func privateKeyPEM(t *testing.T, key crypto.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestHandler_GetJWKS(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg string
+		kty string
+		key crypto.PrivateKey
+	}{
+		{alg: jwt.AlgRS256, kty: "RSA", key: rsaKey},
+		{alg: jwt.AlgES256, kty: "EC", key: ecKey},
+		{alg: jwt.AlgEdDSA, kty: "OKP", key: edKey},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.alg, func(t *testing.T) {
+			key, err := jwt.ParsePrivateKey("", tt.alg, privateKeyPEM(t, tt.key))
+			require.NoError(t, err)
+			require.NotEqual(t, "", key.ID)
+
+			manager, err := jwt.NewKeyManager(key)
+			require.NoError(t, err)
+
+			e := echo.New()
+			request := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			recorder := httptest.NewRecorder()
+			c := e.NewContext(request, recorder)
+
+			err = NewJWKSHandler(manager).GetJWKS()(c)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			jwks := &jwt.JWKS{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), jwks))
+			require.Len(t, jwks.Keys, 1)
+			require.Equal(t, key.ID, jwks.Keys[0].Kid)
+			require.Equal(t, tt.alg, jwks.Keys[0].Alg)
+			require.Equal(t, tt.kty, jwks.Keys[0].Kty)
+
+			userID := uuid.New()
+			accessToken, err := manager.GenerateJWTToken(&entity.User{ID: userID})
+			require.NoError(t, err)
+
+			token, err := gojwt.Parse(accessToken, manager.Keyfunc)
+			require.NoError(t, err)
+			require.Equal(t, key.ID, token.Header["kid"])
+
+			id, err := manager.Parse(accessToken)
+			require.NoError(t, err)
+			require.Equal(t, userID.String(), id)
+		})
+	}
+
+	t.Run("HMACNotPublished", func(t *testing.T) {
+		manager, err := jwt.NewManager("secret")
+		require.NoError(t, err)
+		require.Empty(t, manager.JWKS().Keys)
+	})
+
+	t.Run("UnknownKid", func(t *testing.T) {
+		key, err := jwt.ParsePrivateKey("key-1", jwt.AlgES256, privateKeyPEM(t, ecKey))
+		require.NoError(t, err)
+		manager, err := jwt.NewKeyManager(key)
+		require.NoError(t, err)
+
+		token := gojwt.NewWithClaims(gojwt.SigningMethodES256, gojwt.MapClaims{"id": uuid.New().String()})
+		token.Header["kid"] = "key-2"
+		tokenString, err := token.SignedString(ecKey)
+		require.NoError(t, err)
+
+		_, err = manager.Parse(tokenString)
+		require.Error(t, err)
+	})
+}
+
+func TestHandler_GetJWKSRotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey, err := jwt.GenerateKey("old", jwt.AlgES256)
+	require.NoError(t, err)
+	newKey, err := jwt.GenerateKey("new", jwt.AlgEdDSA)
+	require.NoError(t, err)
+
+	ring := &jwt.KeyRing{}
+	require.NoError(t, ring.Add(oldKey))
+	require.NoError(t, ring.Promote("old", time.Now().Add(-time.Hour), time.Hour))
+	require.NoError(t, ring.Add(newKey))
+
+	manager, err := jwt.NewRingManager(ring)
+	require.NoError(t, err)
+
+	user := &entity.User{ID: uuid.New()}
+	oldToken, err := manager.GenerateJWTToken(user)
+	require.NoError(t, err)
+
+	t.Run("PendingKeyPublished", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		err = NewJWKSHandler(manager).GetJWKS()(c)
+		require.NoError(t, err)
+
+		jwks := &jwt.JWKS{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), jwks))
+		require.Len(t, jwks.Keys, 2)
+	})
+
+	t.Run("Promote", func(t *testing.T) {
+		require.NoError(t, ring.Promote("new", time.Now(), time.Hour))
+		require.NoError(t, manager.SetKeyRing(ring))
+
+		newToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+		token, err := gojwt.Parse(newToken, manager.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, "new", token.Header["kid"])
+
+		// tokens signed by the previous key still verify
+		_, err = manager.Parse(oldToken)
+		require.NoError(t, err)
+	})
+
+	t.Run("Retire", func(t *testing.T) {
+		require.NoError(t, ring.Retire("old", time.Now()))
+		require.NoError(t, manager.SetKeyRing(ring))
+
+		_, err = manager.Parse(oldToken)
+		require.Error(t, err)
+		require.Len(t, manager.JWKS().Keys, 1)
+	})
+}
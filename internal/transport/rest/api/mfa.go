@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+type MFASignIn struct {
+	Challenge string `json:"challenge" validate:"required"`
+	Code      string `json:"code" validate:"required,lte=16"`
+}
+
+// SignInMFA godoc
+// @Summary Login with two-factor code
+// @Description exchange MFA challenge from sign-in and TOTP or backup code for user and session
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body MFASignIn true "challenge and code"
+// @Success 200 {object} entity.UserWithToken
+// @Failure 401 {object} httpe.RestError
+// @Router /user/sign-in/mfa [post]
+func (h *UserHandler) SignInMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.SignInMFA")
+		defer span.Finish()
+
+		input := &MFASignIn{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		userWithToken, err := h.user.SignInMFA(ctx, input.Challenge, input.Code)
+		if err != nil {
+			h.audit.Record(ctx, entity.AuditSignInMFA, uuid.Nil, err)
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+		h.audit.Record(ctx, entity.AuditSignInMFA, userWithToken.User.ID, nil)
+
+		refreshToken, err := h.session.CreateSession(ctx, newSession(c, userWithToken.User.ID), h.config.Cookie.MaxAge)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		c.SetCookie(utils.ConfigureJWTCookie(h.config, refreshToken))
+		return c.JSON(http.StatusOK, userWithToken)
+	}
+}
+
+// EnrollTOTP godoc
+// @Summary Enroll TOTP
+// @Description generate TOTP secret and otpauth URI, two-factor authentication is enabled after confirmation
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Success 200 {object} entity.TOTPEnrollment
+// @Failure 400 {object} httpe.RestError
+// @Router /user/mfa/totp/enroll [post]
+func (h *UserHandler) EnrollTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.EnrollTOTP")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		enrollment, err := h.user.EnrollTOTP(ctx, user.ID)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+type MFACode struct {
+	Code string `json:"code" validate:"required,lte=16"`
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP
+// @Description enable two-factor authentication with the first valid code, returns backup codes
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body MFACode true "TOTP code"
+// @Success 200 {object} entity.BackupCodes
+// @Failure 400 {object} httpe.RestError
+// @Router /user/mfa/totp/confirm [post]
+func (h *UserHandler) ConfirmTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ConfirmTOTP")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &MFACode{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		codes, err := h.user.ConfirmTOTP(ctx, user.ID, input.Code)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, codes)
+	}
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description disable two-factor authentication with TOTP or backup code
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body MFACode true "TOTP or backup code"
+// @Success 200 {string} string	"ok"
+// @Failure 401 {object} httpe.RestError
+// @Router /user/mfa/totp/disable [post]
+func (h *UserHandler) DisableTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.DisableTOTP")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &MFACode{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.DisableTOTP(ctx, user.ID, input.Code); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
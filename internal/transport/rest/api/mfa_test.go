@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/converter"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SignInMFA(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			MaxAge: 10,
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	input := &MFASignIn{
+		Challenge: "challenge",
+		Code:      "123456",
+	}
+
+	buffer, err := converter.AnyToBytesBuffer(input)
+	require.NoError(t, err)
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/sign-in/mfa", strings.NewReader(buffer.String()))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "userHandler.SignInMFA")
+	defer span.Finish()
+
+	handlerFunc := userHandler.SignInMFA()
+
+	userID := uuid.New()
+	userWithToken := &entity.UserWithToken{
+		User: &entity.User{
+			ID: userID,
+		},
+	}
+	sess := &entity.Session{
+		UserID: userID,
+		IP:     "192.0.2.1",
+	}
+
+	mockUserService.EXPECT().SignInMFA(ctxWithTrace, input.Challenge, input.Code).Return(userWithToken, nil)
+	mockSessionService.EXPECT().CreateSession(ctxWithTrace, gomock.Eq(sess), 10).Return("refresh token", nil)
+
+	err = handlerFunc(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHandler_EnrollTOTP(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	userHandler := NewUserHandler(&config.Config{}, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/mfa/totp/enroll", nil)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	user := &entity.User{
+		ID: uuid.New(),
+	}
+	c.Set("user", user)
+
+	mockUserService.EXPECT().EnrollTOTP(gomock.Any(), user.ID).Return(&entity.TOTPEnrollment{
+		Secret: "SECRET",
+		URI:    "otpauth://totp/Auth:user?secret=SECRET",
+	}, nil)
+
+	err := userHandler.EnrollTOTP()(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "SECRET")
+}
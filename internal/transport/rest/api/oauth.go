@@ -0,0 +1,481 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/oauth"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// OAuth authorization server interface
+type OAuthService interface {
+	Authorize(ctx context.Context, userID uuid.UUID, request *entity.AuthorizationRequest) (*entity.OAuthConsent, error)
+	Consent(ctx context.Context, userID uuid.UUID, authTime time.Time, consentToken string, request *entity.AuthorizationRequest, approved bool) (string, error)
+	Exchange(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error)
+	ValidateAccessToken(ctx context.Context, accessToken string) (*entity.OAuthGrant, error)
+	Introspect(ctx context.Context, request *entity.TokenHintRequest) (*entity.Introspection, error)
+	Revoke(ctx context.Context, request *entity.TokenHintRequest) error
+}
+
+// Service accounts interface
+type ServiceAccountService interface {
+	GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error)
+	IssueToken(ctx context.Context, request *entity.TokenRequest) (*entity.OAuthToken, error)
+}
+
+// init oauth handlers
+func (h *Handlers) initOAuthHandlers(e *echo.Echo, mw *middlewares.MiddlewareManager) {
+	oauth := e.Group("/oauth")
+	{
+		oauth.GET("/authorize", h.oauth.Authorize(), mw.AuthSessionMiddleware(h.oauth.config.OAuth.LoginURL))
+		oauth.POST("/authorize", h.oauth.Consent(), mw.AuthSessionMiddleware(h.oauth.config.OAuth.LoginURL))
+		oauth.POST("/token", h.oauth.Token())
+		oauth.POST("/introspect", h.oauth.Introspect())
+		oauth.POST("/revoke", h.oauth.Revoke())
+		oauth.GET("/userinfo", h.oauth.UserInfo())
+		oauth.POST("/userinfo", h.oauth.UserInfo())
+	}
+}
+
+// OAuth handler
+type OAuthHandler struct {
+	config   *config.Config
+	oauth    OAuthService
+	accounts ServiceAccountService
+	user     UserService
+	keys     KeyManager
+}
+
+// New oauth handler constructor
+func NewOAuthHandler(config *config.Config, oauth OAuthService, accounts ServiceAccountService, user UserService, keys KeyManager) *OAuthHandler {
+	return &OAuthHandler{
+		config:   config,
+		oauth:    oauth,
+		accounts: accounts,
+		user:     user,
+		keys:     keys,
+	}
+}
+
+// Consent page descriptions of the scopes, unknown scopes are shown as is
+var scopeDescriptions = map[string]string{
+	entity.ScopeOpenID:  "Sign you in with your account",
+	entity.ScopeProfile: "Your name",
+	entity.ScopeEmail:   "Your email address",
+}
+
+var consentPage = template.Must(template.New("consent").Funcs(template.FuncMap{
+	"describe": func(scope string) string {
+		if description, ok := scopeDescriptions[scope]; ok {
+			return description
+		}
+		return scope
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Authorize {{.Client.Name}}</title>
+<style>body{font-family:sans-serif;max-width:28rem;margin:4rem auto;padding:0 1rem}button{margin-right:.5rem}</style>
+</head>
+<body>
+<h1>{{.Client.Name}} wants to access your account</h1>
+<p>Signed in as {{.Email}}</p>
+{{if .Scopes}}<p>The application asks for:</p>
+<ul>{{range .Scopes}}<li>{{describe .}}</li>{{end}}</ul>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="consent" value="{{.Token}}">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<button type="submit" name="decision" value="allow">Allow</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</form>
+</body>
+</html>
+`))
+
+// Authorize godoc
+// @Summary OAuth authorization endpoint
+// @Description validates authorization code request with PKCE and shows consent page to the signed in user
+// @Tags OAuth
+// @Produce html
+// @Param response_type query string true "code"
+// @Param client_id query string true "client id"
+// @Param redirect_uri query string false "registered redirect uri"
+// @Param scope query string false "space delimited scopes"
+// @Param state query string false "client state"
+// @Param code_challenge query string true "S256 code challenge"
+// @Param code_challenge_method query string true "S256"
+// @Param nonce query string false "OpenID Connect nonce returned in the ID token"
+// @Success 200 {string} string "consent page"
+// @Failure 302 {string} string "redirect to the client with error or to the login page"
+// @Failure 400 {object} httpe.RestError
+// @Router /oauth/authorize [get]
+func (h *OAuthHandler) Authorize() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.Authorize")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		request := &entity.AuthorizationRequest{}
+		if err := c.Bind(request); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		consent, err := h.oauth.Authorize(ctx, user.ID, request)
+		if err != nil {
+			return authorizeError(c, err)
+		}
+
+		page := &bytes.Buffer{}
+		if err := consentPage.Execute(page, struct {
+			*entity.OAuthConsent
+			Email string
+		}{consent, user.Email}); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		header := c.Response().Header()
+		header.Set("Cache-Control", "no-store")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+		return c.HTML(http.StatusOK, page.String())
+	}
+}
+
+// Consent godoc
+// @Summary Submit OAuth consent
+// @Description redirects to the client with authorization code when the user allows access
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Param consent formData string true "consent token of the page"
+// @Param decision formData string true "allow or deny"
+// @Success 303 {string} string "redirect to the client"
+// @Failure 400 {object} httpe.RestError
+// @Router /oauth/authorize [post]
+func (h *OAuthHandler) Consent() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.Consent")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		request := &entity.AuthorizationRequest{}
+		if err := c.Bind(request); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		// auth_time of the ID token is the sign in time of the browser session
+		authTime := time.Now()
+		if session, ok := c.Get("session").(*entity.Session); ok && session.AuthTime != nil {
+			authTime = *session.AuthTime
+		}
+
+		redirectURL, err := h.oauth.Consent(ctx, user.ID, authTime, c.FormValue("consent"), request, c.FormValue("decision") == "allow")
+		if err != nil {
+			return authorizeError(c, err)
+		}
+
+		return c.Redirect(http.StatusSeeOther, redirectURL)
+	}
+}
+
+// Token godoc
+// @Summary OAuth token endpoint
+// @Description exchanges authorization code or refresh token, service accounts use client credentials grant.
+// @Description Clients authenticate with basic auth or form credentials
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "authorization code"
+// @Param redirect_uri formData string false "redirect uri of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "refresh token"
+// @Param scope formData string false "narrower scope on refresh"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200 {object} entity.OAuthToken
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.Token")
+		defer span.Finish()
+
+		header := c.Response().Header()
+		header.Set("Cache-Control", "no-store")
+		header.Set("Pragma", "no-cache")
+
+		request := &entity.TokenRequest{}
+		if err := c.Bind(request); err != nil {
+			return tokenError(c, oauth.NewError(oauth.ErrInvalidRequest, "Malformed request"))
+		}
+		if err := clientCredentials(c, &request.ClientID, &request.ClientSecret); err != nil {
+			return tokenError(c, err)
+		}
+
+		var token *entity.OAuthToken
+		var err error
+		if request.GrantType == oauth.GrantClientCredentials {
+			token, err = h.accounts.IssueToken(ctx, request)
+		} else {
+			token, err = h.oauth.Exchange(ctx, request)
+		}
+		if err != nil {
+			return tokenError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, token)
+	}
+}
+
+// Introspect godoc
+// @Summary OAuth token introspection
+// @Description tells whether access or refresh token is active, RFC 7662. Callers authenticate as confidential client or service account
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200 {object} entity.Introspection
+// @Failure 401 {object} oauth.Error
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) Introspect() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.Introspect")
+		defer span.Finish()
+
+		c.Response().Header().Set("Cache-Control", "no-store")
+
+		request := &entity.TokenHintRequest{}
+		if err := c.Bind(request); err != nil || request.Token == "" {
+			return tokenError(c, oauth.NewError(oauth.ErrInvalidRequest, "token is required"))
+		}
+		if err := clientCredentials(c, &request.ClientID, &request.ClientSecret); err != nil {
+			return tokenError(c, err)
+		}
+
+		introspection, err := h.oauth.Introspect(ctx, request)
+		if err != nil {
+			return tokenError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, introspection)
+	}
+}
+
+// Revoke godoc
+// @Summary OAuth token revocation
+// @Description revokes refresh or access token, RFC 7009. Unknown tokens are accepted as already revoked
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "refresh or access token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Router /oauth/revoke [post]
+func (h *OAuthHandler) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.Revoke")
+		defer span.Finish()
+
+		request := &entity.TokenHintRequest{}
+		if err := c.Bind(request); err != nil || request.Token == "" {
+			return tokenError(c, oauth.NewError(oauth.ErrInvalidRequest, "token is required"))
+		}
+		if err := clientCredentials(c, &request.ClientID, &request.ClientSecret); err != nil {
+			return tokenError(c, err)
+		}
+
+		if err := h.oauth.Revoke(ctx, request); err != nil {
+			return tokenError(c, err)
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// UserInfo godoc
+// @Summary OpenID Connect userinfo endpoint
+// @Description claims of the user released by the scopes of the access token, requires openid scope
+// @Tags OAuth
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} entity.UserInfo
+// @Failure 401 {object} oauth.Error
+// @Failure 403 {object} oauth.Error
+// @Router /oauth/userinfo [get]
+func (h *OAuthHandler) UserInfo() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "OAuthHandler.UserInfo")
+		defer span.Finish()
+
+		c.Response().Header().Set("Cache-Control", "no-store")
+
+		bearer := strings.Fields(c.Request().Header.Get(echo.HeaderAuthorization))
+		if len(bearer) != 2 || !strings.EqualFold(bearer[0], "Bearer") {
+			return bearerError(c, oauth.NewError(oauth.ErrInvalidRequest, "Missing bearer access token"))
+		}
+
+		grant, err := h.oauth.ValidateAccessToken(ctx, bearer[1])
+		if err != nil {
+			return bearerError(c, err)
+		}
+		if !oauth.HasScope(grant.Scopes, entity.ScopeOpenID) {
+			return bearerError(c, oauth.NewError(oauth.ErrInsufficientScope, "Access token has no openid scope"))
+		}
+
+		u, err := h.user.GetUserByID(ctx, grant.UserID)
+		if err != nil {
+			var restErr httpe.RestErr
+			if errors.Is(err, sql.ErrNoRows) || errors.As(err, &restErr) && restErr.Status() == http.StatusForbidden {
+				return bearerError(c, oauth.NewError(oauth.ErrInvalidToken, "User is no longer active"))
+			}
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, entity.NewUserInfo(u.User, grant.Scopes))
+	}
+}
+
+// Discovery godoc
+// @Summary OpenID Connect discovery
+// @Description provider metadata with endpoints, supported scopes and signing algorithms
+// @Tags OAuth
+// @Produce json
+// @Success 200 {object} oauth.Discovery
+// @Router /.well-known/openid-configuration [get]
+func (h *OAuthHandler) Discovery() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		issuer := h.config.OAuth.Issuer
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, &oauth.Discovery{
+			Issuer:                            issuer,
+			AuthorizationEndpoint:             issuer + "/oauth/authorize",
+			TokenEndpoint:                     issuer + "/oauth/token",
+			UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+			IntrospectionEndpoint:             issuer + "/oauth/introspect",
+			RevocationEndpoint:                issuer + "/oauth/revoke",
+			JWKSURI:                           issuer + "/.well-known/jwks.json",
+			ScopesSupported:                   []string{entity.ScopeOpenID, entity.ScopeProfile, entity.ScopeEmail},
+			ResponseTypesSupported:            []string{oauth.ResponseTypeCode},
+			GrantTypesSupported:               []string{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken, oauth.GrantClientCredentials},
+			SubjectTypesSupported:             []string{"public"},
+			IDTokenSigningAlgValuesSupported:  []string{h.keys.SigningAlg()},
+			TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:     []string{oauth.MethodS256},
+			ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "email_verified"},
+			AuthorizationResponseIssParameter: true,
+		})
+	}
+}
+
+// Take client credentials from basic authorization header, their
+// parts are form encoded. Only one authentication method is allowed
+func clientCredentials(c echo.Context, clientID, clientSecret *string) error {
+	id, secret, ok := c.Request().BasicAuth()
+	if !ok {
+		return nil
+	}
+	if *clientSecret != "" {
+		return oauth.NewError(oauth.ErrInvalidRequest, "Multiple client authentication methods")
+	}
+
+	var err error
+	if id, err = url.QueryUnescape(id); err != nil {
+		return oauth.NewError(oauth.ErrInvalidClient, "Malformed client credentials")
+	}
+	if secret, err = url.QueryUnescape(secret); err != nil {
+		return oauth.NewError(oauth.ErrInvalidClient, "Malformed client credentials")
+	}
+	if *clientID != "" && *clientID != id {
+		return oauth.NewError(oauth.ErrInvalidRequest, "client_id does not match client credentials")
+	}
+	*clientID, *clientSecret = id, secret
+	return nil
+}
+
+// Invalid authorization requests go back to the client,
+// unknown clients and redirect uris are shown to the user
+func authorizeError(c echo.Context, err error) error {
+	var redirect *oauth.RedirectError
+	if errors.As(err, &redirect) {
+		return c.Redirect(http.StatusFound, redirect.URL)
+	}
+	return c.JSON(httpe.ErrorResponse(err))
+}
+
+// Protected resource error response of RFC 6750 section 3,
+// requests without access token get no error code in the challenge
+func bearerError(c echo.Context, err error) error {
+	var oauthErr *oauth.Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = oauth.NewError(oauth.ErrServerError, "")
+	}
+
+	status, challenge := oauthErr.Status(), `Bearer realm="oauth"`
+	switch oauthErr.Code {
+	case oauth.ErrInvalidRequest:
+		status = http.StatusUnauthorized
+	case oauth.ErrServerError:
+		return c.JSON(status, oauthErr)
+	default:
+		challenge += fmt.Sprintf(`, error=%q`, oauthErr.Code)
+	}
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+	return c.JSON(status, oauthErr)
+}
+
+// Token endpoint error response of RFC 6749 section 5.2,
+// also used by introspection and revocation
+func tokenError(c echo.Context, err error) error {
+	var oauthErr *oauth.Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = oauth.NewError(oauth.ErrServerError, "")
+	}
+	if oauthErr.Code == oauth.ErrInvalidClient {
+		if _, _, ok := c.Request().BasicAuth(); ok {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+	}
+	return c.JSON(oauthErr.Status(), oauthErr)
+}
@@ -0,0 +1,545 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/service"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	mockstorage "github.com/Edbeer/Project/internal/storage/psql/mock"
+	redisrepo "github.com/Edbeer/Project/internal/storage/redis"
+	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/oauth"
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v9"
+	jwtgo "github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+var consentTokenPattern = regexp.MustCompile(`name="consent" value="([^"]+)"`)
+
+func TestHandler_OAuthFlow(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			Name: "jwt-token",
+		},
+		OAuth: config.OAuth{
+			Issuer:        "https://auth.example.com",
+			LoginURL:      "https://auth.example.com/login",
+			CodeExpire:    60,
+			ConsentExpire: 600,
+			RefreshExpire: 3600,
+		},
+	}
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	redisStorage := redisrepo.NewStorage(redisrepo.Deps{
+		Redis:       redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		TokenSecret: []byte("secret"),
+	})
+
+	manager, err := jwt.NewManager("secret")
+	require.NoError(t, err)
+	mockClientStorage := mockstorage.NewMockOAuthPsql(ctrl)
+	mockUserStorage := mockstorage.NewMockUserPsql(ctrl)
+	mockAudit := mockservice.NewMockAudit(ctrl)
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+	mockAccountStorage := mockstorage.NewMockServiceAccountPsql(ctrl)
+	oauthService := service.NewOAuthService(config, mockClientStorage, mockAccountStorage, redisStorage.OAuth, redisStorage.Token, redisStorage.Session, redisStorage.Revocation, mockUserStorage, manager, mockAudit)
+	accountService := service.NewServiceAccountService(config, mockAccountStorage, mockUserStorage, manager)
+
+	e := echo.New()
+	h := &Handlers{
+		oauth: NewOAuthHandler(config, oauthService, accountService, mockUserService, manager),
+		jwks:  NewJWKSHandler(manager),
+	}
+	mw := middlewares.NewMiddlewareManager(mockSessionService, mockUserService, accountService, manager, nil, config, []string{"*"}, nil)
+	h.initWellKnownHandlers(e)
+	h.initOAuthHandlers(e, mw)
+	e.GET("/principal", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("user").(entity.Principal).PrincipalType())
+	}, mw.AuthJWTMiddleware(), mw.RequirePermission(entity.PermissionUsersRead))
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	secret := "client secret"
+	secretSum := sha256.Sum256([]byte(secret))
+	secretHash := hex.EncodeToString(secretSum[:])
+	client := &entity.OAuthClient{
+		ID:           "client",
+		SecretHash:   &secretHash,
+		Name:         "Internal <App>",
+		RedirectURIs: entity.SpaceList{"https://app.example.com/callback"},
+		Scopes:       entity.SpaceList{"openid", "profile", "email"},
+	}
+	user := &entity.User{ID: uuid.New(), Name: "Edbeer", Email: "edbeermtn@gmail.com"}
+	authTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "client").Return(client, nil).AnyTimes()
+	mockUserStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil).AnyTimes()
+	mockSessionService.EXPECT().GetSession(gomock.Any(), "refresh token").
+		Return(&entity.Session{UserID: user.ID, AuthTime: &authTime}, nil).AnyTimes()
+	mockUserService.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&entity.UserWithToken{User: user}, nil).AnyTimes()
+	mockSessionService.EXPECT().IsAccessTokenRevoked(gomock.Any(), gomock.Any()).
+		DoAndReturn(redisStorage.Revocation.IsRevoked).AnyTimes()
+	mockAudit.EXPECT().Record(gomock.Any(), entity.AuditOAuthConsent, user.ID, nil)
+	account := &entity.ServiceAccount{
+		ClientID:   "backup-job",
+		SecretHash: secretHash,
+		Name:       "Backup job",
+		OwnerID:    user.ID,
+		Scopes:     entity.SpaceList{entity.PermissionUsersRead, entity.PermissionAuditRead},
+	}
+	mockClientStorage.EXPECT().GetClient(gomock.Any(), "backup-job").Return(nil, sql.ErrNoRows).AnyTimes()
+	mockAccountStorage.EXPECT().GetServiceAccount(gomock.Any(), "backup-job").
+		DoAndReturn(func(context.Context, string) (*entity.ServiceAccount, error) {
+			copied := *account
+			return &copied, nil
+		}).AnyTimes()
+
+	httpClient := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	verifier := strings.Repeat("verifier-", 6)
+	authorize := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"client"},
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"scope":                 {"openid profile email"},
+		"state":                 {"af0ifjsldkj"},
+		"nonce":                 {"n-0S6_WzA2Mj"},
+		"code_challenge":        {oauth.Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	authorizeURL := server.URL + "/oauth/authorize?" + authorize.Encode()
+
+	token := func(t *testing.T, form url.Values) (int, map[string]interface{}) {
+		request, err := http.NewRequest(http.MethodPost, server.URL+"/oauth/token", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		request.SetBasicAuth("client", url.QueryEscape(secret))
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		require.Equal(t, "no-store", response.Header.Get("Cache-Control"))
+
+		body := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		return response.StatusCode, body
+	}
+
+	t.Run("RedirectToLogin", func(t *testing.T) {
+		response, err := httpClient.Get(authorizeURL)
+		require.NoError(t, err)
+		response.Body.Close()
+
+		require.Equal(t, http.StatusFound, response.StatusCode)
+		location, err := url.Parse(response.Header.Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "auth.example.com", location.Host)
+		require.Equal(t, authorizeURL, location.Query().Get("return_to"))
+	})
+
+	var code string
+	t.Run("Consent", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, authorizeURL, nil)
+		require.NoError(t, err)
+		request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		page := &bytes.Buffer{}
+		_, err = page.ReadFrom(response.Body)
+		require.NoError(t, err)
+		response.Body.Close()
+
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "DENY", response.Header.Get("X-Frame-Options"))
+		require.Contains(t, page.String(), "Internal &lt;App&gt;")
+		require.Contains(t, page.String(), "<li>Your email address</li>")
+		match := consentTokenPattern.FindStringSubmatch(page.String())
+		require.Len(t, match, 2)
+
+		form := url.Values{"consent": {match[1]}, "decision": {"allow"}}
+		for key, values := range authorize {
+			form[key] = values
+		}
+		request, err = http.NewRequest(http.MethodPost, server.URL+"/oauth/authorize", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+
+		response, err = httpClient.Do(request)
+		require.NoError(t, err)
+		response.Body.Close()
+
+		require.Equal(t, http.StatusSeeOther, response.StatusCode)
+		location, err := url.Parse(response.Header.Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "app.example.com", location.Host)
+		require.Equal(t, "af0ifjsldkj", location.Query().Get("state"))
+		require.Equal(t, "https://auth.example.com", location.Query().Get("iss"))
+		code = location.Query().Get("code")
+		require.NotEmpty(t, code)
+
+		// consent token is single-use
+		request, err = http.NewRequest(http.MethodPost, server.URL+"/oauth/authorize", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+
+		response, err = httpClient.Do(request)
+		require.NoError(t, err)
+		response.Body.Close()
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	var refreshToken, accessToken string
+	t.Run("ExchangeCode", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {"https://app.example.com/callback"},
+			"code_verifier": {verifier},
+		}
+		status, body := token(t, form)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "Bearer", body["token_type"])
+		require.Equal(t, "openid profile email", body["scope"])
+		refreshToken, _ = body["refresh_token"].(string)
+		require.NotEmpty(t, refreshToken)
+		accessToken, _ = body["access_token"].(string)
+
+		claims := &jwt.OAuthClaims{}
+		_, err := jwtgo.ParseWithClaims(accessToken, claims, manager.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, user.ID.String(), claims.Subject)
+		require.Equal(t, "client", claims.ClientID)
+		require.Equal(t, "https://auth.example.com", claims.Issuer)
+
+		idClaims := &jwt.IDClaims{}
+		_, err = jwtgo.ParseWithClaims(body["id_token"].(string), idClaims, manager.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, user.ID.String(), idClaims.Subject)
+		require.Equal(t, "client", idClaims.Audience)
+		require.Equal(t, "n-0S6_WzA2Mj", idClaims.Nonce)
+		require.Equal(t, authTime.Unix(), idClaims.AuthTime)
+		require.Equal(t, "Edbeer", idClaims.Name)
+		require.Equal(t, user.Email, idClaims.Email)
+
+		// authorization code is single-use
+		status, body = token(t, form)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, oauth.ErrInvalidGrant, body["error"])
+	})
+
+	userInfo := func(t *testing.T, accessToken string) (*http.Response, map[string]interface{}) {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/oauth/userinfo", nil)
+		require.NoError(t, err)
+		if accessToken != "" {
+			request.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+		}
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		body := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		return response, body
+	}
+
+	t.Run("UserInfo", func(t *testing.T) {
+		response, body := userInfo(t, accessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, map[string]interface{}{
+			"sub":            user.ID.String(),
+			"name":           "Edbeer",
+			"email":          user.Email,
+			"email_verified": false,
+		}, body)
+
+		response, body = userInfo(t, "")
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, `Bearer realm="oauth"`, response.Header.Get(echo.HeaderWWWAuthenticate))
+
+		response, body = userInfo(t, "invalid")
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, oauth.ErrInvalidToken, body["error"])
+		require.Equal(t, `Bearer realm="oauth", error="invalid_token"`, response.Header.Get(echo.HeaderWWWAuthenticate))
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+			"scope":         {"email"},
+		}
+		status, body := token(t, form)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "email", body["scope"])
+		require.NotEqual(t, refreshToken, body["refresh_token"])
+		require.Nil(t, body["id_token"])
+		rotated := body["refresh_token"].(string)
+
+		// userinfo needs the openid scope
+		response, body := userInfo(t, body["access_token"].(string))
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.Equal(t, oauth.ErrInsufficientScope, body["error"])
+
+		// refresh token is rotated
+		status, body = token(t, form)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, oauth.ErrInvalidGrant, body["error"])
+		refreshToken = rotated
+	})
+
+	t.Run("Discovery", func(t *testing.T) {
+		response, err := httpClient.Get(server.URL + "/.well-known/openid-configuration")
+		require.NoError(t, err)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		discovery := &oauth.Discovery{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(discovery))
+		require.Equal(t, "https://auth.example.com", discovery.Issuer)
+		require.Equal(t, "https://auth.example.com/oauth/userinfo", discovery.UserInfoEndpoint)
+		require.Equal(t, "https://auth.example.com/.well-known/jwks.json", discovery.JWKSURI)
+		require.Equal(t, []string{manager.SigningAlg()}, discovery.IDTokenSigningAlgValuesSupported)
+		require.Contains(t, discovery.ScopesSupported, "openid")
+	})
+
+	principal := func(t *testing.T, accessToken string) (int, string) {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/principal", nil)
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		body := &bytes.Buffer{}
+		_, err = body.ReadFrom(response.Body)
+		require.NoError(t, err)
+		return response.StatusCode, body.String()
+	}
+
+	t.Run("ClientCredentials", func(t *testing.T) {
+		serviceToken := func(t *testing.T, scope string) (int, map[string]interface{}) {
+			form := url.Values{"grant_type": {"client_credentials"}, "scope": {scope}}
+			request, err := http.NewRequest(http.MethodPost, server.URL+"/oauth/token", strings.NewReader(form.Encode()))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			request.SetBasicAuth("backup-job", url.QueryEscape(secret))
+
+			response, err := httpClient.Do(request)
+			require.NoError(t, err)
+			defer response.Body.Close()
+
+			body := map[string]interface{}{}
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+			return response.StatusCode, body
+		}
+
+		status, body := serviceToken(t, "")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "users:read audit:read", body["scope"])
+		require.Nil(t, body["refresh_token"])
+
+		status, kind := principal(t, body["access_token"].(string))
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, entity.PrincipalService, kind)
+
+		// token without the permission scope
+		status, body = serviceToken(t, "audit:read")
+		require.Equal(t, http.StatusOK, status)
+		status, _ = principal(t, body["access_token"].(string))
+		require.Equal(t, http.StatusForbidden, status)
+
+		status, body = serviceToken(t, "users:write")
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, oauth.ErrInvalidScope, body["error"])
+
+		// user access tokens of oauth clients are not service tokens
+		status, _ = principal(t, accessToken)
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	tokenHint := func(t *testing.T, endpoint, clientID, token string) (int, map[string]interface{}) {
+		form := url.Values{"token": {token}}
+		request, err := http.NewRequest(http.MethodPost, server.URL+endpoint, strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		request.SetBasicAuth(clientID, url.QueryEscape(secret))
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		body := map[string]interface{}{}
+		payload := &bytes.Buffer{}
+		_, err = payload.ReadFrom(response.Body)
+		require.NoError(t, err)
+		if payload.Len() > 0 {
+			require.NoError(t, json.Unmarshal(payload.Bytes(), &body))
+		}
+		return response.StatusCode, body
+	}
+
+	t.Run("Introspect", func(t *testing.T) {
+		status, body := tokenHint(t, "/oauth/introspect", "client", accessToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, true, body["active"])
+		require.Equal(t, user.ID.String(), body["sub"])
+		require.Equal(t, "client", body["client_id"])
+		require.Equal(t, "openid profile email", body["scope"])
+		require.Equal(t, user.Email, body["username"])
+		require.Equal(t, "access_token", body["token_type"])
+
+		// access tokens of a client are described only to the client
+		status, body = tokenHint(t, "/oauth/introspect", "backup-job", accessToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]interface{}{"active": false}, body)
+
+		// first-party tokens of the users only to service accounts reading users
+		userToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+		_, body = tokenHint(t, "/oauth/introspect", "backup-job", userToken)
+		require.Equal(t, true, body["active"])
+		require.Equal(t, user.ID.String(), body["sub"])
+		_, body = tokenHint(t, "/oauth/introspect", "client", userToken)
+		require.Equal(t, map[string]interface{}{"active": false}, body)
+
+		status, body = tokenHint(t, "/oauth/introspect", "client", refreshToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, true, body["active"])
+		require.Equal(t, "refresh_token", body["token_type"])
+		require.Equal(t, "openid profile email", body["scope"])
+		require.Greater(t, body["exp"], float64(time.Now().Unix()))
+
+		// refresh tokens are described only to their client
+		status, body = tokenHint(t, "/oauth/introspect", "backup-job", refreshToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]interface{}{"active": false}, body)
+
+		status, body = tokenHint(t, "/oauth/introspect", "client", "invalid")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]interface{}{"active": false}, body)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		mockAudit.EXPECT().Record(gomock.Any(), entity.AuditTokenRevoke, user.ID, nil).Times(3)
+		defer func(scopes entity.SpaceList) { account.Scopes = scopes }(account.Scopes)
+
+		// refresh token of another client is left alone
+		status, _ := tokenHint(t, "/oauth/revoke", "backup-job", refreshToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body := tokenHint(t, "/oauth/introspect", "client", refreshToken)
+		require.Equal(t, true, body["active"])
+
+		status, _ = tokenHint(t, "/oauth/revoke", "client", refreshToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body = tokenHint(t, "/oauth/introspect", "client", refreshToken)
+		require.Equal(t, false, body["active"])
+		status, body = token(t, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, oauth.ErrInvalidGrant, body["error"])
+
+		// session refresh tokens of the first-party sign in
+		sessionToken, err := redisStorage.Session.CreateSession(context.Background(), &entity.Session{UserID: user.ID}, 60)
+		require.NoError(t, err)
+		_, body = tokenHint(t, "/oauth/introspect", "backup-job", sessionToken)
+		require.Equal(t, true, body["active"])
+		require.Equal(t, user.ID.String(), body["sub"])
+
+		// oauth clients and service accounts without users:write leave sessions alone
+		_, body = tokenHint(t, "/oauth/introspect", "client", sessionToken)
+		require.Equal(t, map[string]interface{}{"active": false}, body)
+		status, _ = tokenHint(t, "/oauth/revoke", "client", sessionToken)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = tokenHint(t, "/oauth/revoke", "backup-job", sessionToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body = tokenHint(t, "/oauth/introspect", "backup-job", sessionToken)
+		require.Equal(t, true, body["active"])
+
+		account.Scopes = append(entity.SpaceList{entity.PermissionUsersWrite}, account.Scopes...)
+		status, _ = tokenHint(t, "/oauth/revoke", "backup-job", sessionToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body = tokenHint(t, "/oauth/introspect", "backup-job", sessionToken)
+		require.Equal(t, false, body["active"])
+
+		// access token of another client is left alone
+		status, _ = tokenHint(t, "/oauth/revoke", "backup-job", accessToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body = tokenHint(t, "/oauth/introspect", "client", accessToken)
+		require.Equal(t, true, body["active"])
+
+		status, _ = tokenHint(t, "/oauth/revoke", "client", accessToken)
+		require.Equal(t, http.StatusOK, status)
+		_, body = tokenHint(t, "/oauth/introspect", "client", accessToken)
+		require.Equal(t, false, body["active"])
+		response, body := userInfo(t, accessToken)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, oauth.ErrInvalidToken, body["error"])
+
+		// service account revokes its own token
+		serviceToken, err := manager.GenerateServiceToken(config.OAuth.Issuer, account, account.Scopes)
+		require.NoError(t, err)
+		status, _ = tokenHint(t, "/oauth/revoke", "backup-job", serviceToken)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = principal(t, serviceToken)
+		require.Equal(t, http.StatusUnauthorized, status)
+
+		// watermark revokes first-party tokens of the user issued before it
+		userToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+		status, _ = principal(t, userToken)
+		require.Equal(t, http.StatusForbidden, status)
+		err = redisStorage.Revocation.RevokeUserTokens(context.Background(), user.ID, time.Now().Add(time.Second), 900)
+		require.NoError(t, err)
+		status, _ = principal(t, userToken)
+		require.Equal(t, http.StatusUnauthorized, status)
+
+		status, _ = tokenHint(t, "/oauth/revoke", "client", "invalid")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("WrongClientSecret", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodPost, server.URL+"/oauth/token", strings.NewReader("grant_type=refresh_token&refresh_token=x"))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		request.SetBasicAuth("client", "wrong")
+
+		response, err := httpClient.Do(request)
+		require.NoError(t, err)
+		response.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, `Basic realm="oauth"`, response.Header.Get(echo.HeaderWWWAuthenticate))
+	})
+}
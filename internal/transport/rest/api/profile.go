@@ -0,0 +1,154 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+type UpdateProfile struct {
+	Name string `json:"name" validate:"required,lte=30"`
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description change name of the current user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body UpdateProfile true "new name"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} httpe.RestError
+// @Router /user/me [patch]
+func (h *UserHandler) UpdateMe() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.UpdateMe")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &UpdateProfile{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		updatedUser, err := h.user.UpdateProfile(ctx, user.ID, input.Name)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, updatedUser)
+	}
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description set new password checking the current one, other sessions are revoked
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body ChangePassword true "current and new password"
+// @Success 200 {string} string	"ok"
+// @Failure 400 {object} httpe.ValidationError
+// @Router /user/me/password [post]
+func (h *UserHandler) ChangePassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ChangePassword")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &ChangePassword{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		refreshToken := currentRefreshToken(c, h.config.Cookie.Name)
+		if err := h.user.ChangePassword(ctx, user.ID, input.CurrentPassword, input.NewPassword, refreshToken); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+type ChangeEmail struct {
+	Email    string `json:"email" validate:"required,lte=60,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangeEmail godoc
+// @Summary Change email
+// @Description send confirmation link to the new email and notify the current one, email changes after confirmation
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body ChangeEmail true "new email and current password"
+// @Success 202 {string} string	"accepted"
+// @Failure 400 {object} httpe.RestError
+// @Router /user/me/email [post]
+func (h *UserHandler) ChangeEmail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ChangeEmail")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &ChangeEmail{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.RequestEmailChange(ctx, user.ID, input.Email, input.Password); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description change email with token from confirmation letter
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailToken true "confirmation token"
+// @Success 200 {string} string	"ok"
+// @Failure 400 {object} httpe.RestError
+// @Router /user/email/confirm [post]
+func (h *UserHandler) ConfirmEmailChange() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ConfirmEmailChange")
+		defer span.Finish()
+
+		token := &VerifyEmailToken{}
+		if err := utils.ReadRequest(c, token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.ConfirmEmailChange(ctx, token.Token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Profile(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			Name: "jwt-token",
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+	user := &entity.User{ID: uuid.New(), Name: "PavelV"}
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.Set("user", user)
+		return c, recorder
+	}
+
+	t.Run("GetMe", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me", "")
+		withHash := &entity.User{ID: user.ID, Name: user.Name, Password: "$argon2id$v=19$m=16384,t=2,p=1$c2FsdA$aGFzaA"}
+		c.Set("user", withHash)
+
+		err := userHandler.GetMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `"name":"PavelV"`)
+		require.NotContains(t, recorder.Body.String(), `"password"`)
+		require.NotEmpty(t, withHash.Password)
+	})
+
+	t.Run("UpdateMe", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPatch, "/api/user/me", `{"name":"Pavel"}`)
+
+		mockUserService.EXPECT().UpdateProfile(gomock.Any(), user.ID, "Pavel").
+			Return(&entity.User{ID: user.ID, Name: "Pavel"}, nil)
+
+		err := userHandler.UpdateMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `"name":"Pavel"`)
+	})
+
+	t.Run("UpdateMeEmptyName", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPatch, "/api/user/me", `{"name":""}`)
+
+		err := userHandler.UpdateMe()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "/api/user/me/password", `{"current_password":"12345678","new_password":"87654321"}`)
+
+		mockUserService.EXPECT().ChangePassword(gomock.Any(), user.ID, "12345678", "87654321", "refresh token").Return(nil)
+
+		err := userHandler.ChangePassword()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("ChangeEmail", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "/api/user/me/email", `{"email":"new@gmail.com","password":"12345678"}`)
+
+		mockUserService.EXPECT().RequestEmailChange(gomock.Any(), user.ID, "new@gmail.com", "12345678").Return(nil)
+
+		err := userHandler.ChangeEmail()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// New session of the user with client ip and user agent
+func newSession(c echo.Context, userID uuid.UUID) *entity.Session {
+	return &entity.Session{
+		UserID:    userID,
+		IP:        utils.GetIP(c),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
+
+// Refresh token of the current session from cookie, empty without cookie
+func currentRefreshToken(c echo.Context, cookieName string) string {
+	cookie, err := c.Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// GetSessions godoc
+// @Summary Get active sessions
+// @Description list devices where the user is signed in, the session of refresh cookie is marked as current
+// @Tags Session
+// @Produce json
+// @Success 200 {array} entity.SessionInfo
+// @Failure 401 {object} httpe.RestError
+// @Router /user/sessions [get]
+func (h *UserHandler) GetSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.GetSessions")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		sessions, err := h.session.GetUserSessions(ctx, user.ID, currentRefreshToken(c, h.config.Cookie.Name))
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// DeleteSession godoc
+// @Summary Revoke session
+// @Description sign out the device of the session
+// @Tags Session
+// @Param id path string true "session id"
+// @Success 200 {string} string	"ok"
+// @Failure 404 {object} httpe.RestError
+// @Router /user/sessions/{id} [delete]
+func (h *UserHandler) DeleteSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.DeleteSession")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		sessionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, httpe.NewNotFoundError(httpe.SessionNotFound))
+		}
+
+		if err := h.session.DeleteSessionByID(ctx, user.ID, sessionID); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// DeleteOtherSessions godoc
+// @Summary Revoke other sessions
+// @Description sign out everywhere except the session of refresh cookie
+// @Tags Session
+// @Success 200 {string} string	"ok"
+// @Failure 401 {object} httpe.RestError
+// @Router /user/sessions [delete]
+func (h *UserHandler) DeleteOtherSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.DeleteOtherSessions")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		refreshToken := currentRefreshToken(c, h.config.Cookie.Name)
+		if refreshToken == "" {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.NoCookie))
+		}
+
+		if err := h.session.DeleteOtherSessions(ctx, user.ID, refreshToken); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_GetSessions(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			Name: "jwt-token",
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodGet, "/api/user/sessions", nil)
+	request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	user := &entity.User{ID: uuid.New()}
+	c.Set("user", user)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "UserHandler.GetSessions")
+	defer span.Finish()
+
+	sessions := []*entity.SessionInfo{
+		{
+			ID:      uuid.New(),
+			Current: true,
+		},
+	}
+	mockSessionService.EXPECT().GetUserSessions(ctxWithTrace, user.ID, "refresh token").Return(sessions, nil)
+
+	err := userHandler.GetSessions()(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), sessions[0].ID.String())
+}
+
+func TestHandler_DeleteOtherSessions(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			Name: "jwt-token",
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+	user := &entity.User{ID: uuid.New()}
+
+	t.Run("WithoutCookie", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodDelete, "/api/user/sessions", nil)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.Set("user", user)
+
+		err := userHandler.DeleteOtherSessions()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("DeleteOtherSessions", func(t *testing.T) {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodDelete, "/api/user/sessions", nil)
+		request.AddCookie(&http.Cookie{Name: "jwt-token", Value: "refresh token"})
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.Set("user", user)
+
+		mockSessionService.EXPECT().DeleteOtherSessions(gomock.Any(), user.ID, "refresh token").Return(nil)
+
+		err := userHandler.DeleteOtherSessions()(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
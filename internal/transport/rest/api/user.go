@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
@@ -19,15 +20,36 @@ import (
 // User service interface
 type UserService interface {
 	SignUp(ctx context.Context, user *entity.User) (*entity.UserWithToken, error)
-	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, error)
+	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, *entity.MFAChallenge, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.UserWithToken, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, user *entity.User) error
+	ForgotPassword(ctx context.Context, user *entity.User) error
+	ResetPassword(ctx context.Context, token, password string) error
+	SignInMFA(ctx context.Context, challenge, code string) (*entity.UserWithToken, error)
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (*entity.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (*entity.BackupCodes, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, name string) (*entity.User, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword, refreshToken string) error
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, email, password string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error
+	RestoreAccount(ctx context.Context, token string) error
 }
 
 // Session service interface
 type SessionService interface {
 	CreateSession(ctx context.Context, session *entity.Session, expire int) (string, error)
+	GetSession(ctx context.Context, refreshToken string) (*entity.Session, error)
 	GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error)
+	RefreshSession(ctx context.Context, session *entity.Session, expire int) (*entity.Session, error)
 	DeleteSession(ctx context.Context, refreshToken string) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID, refreshToken string) ([]*entity.SessionInfo, error)
+	DeleteSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID uuid.UUID, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, token *entity.AccessToken) error
+	IsAccessTokenRevoked(ctx context.Context, token *entity.AccessToken) (bool, error)
 }
 
 // init user handlers
@@ -36,10 +58,31 @@ func (h *Handlers) initUserHandlers(api *echo.Group, mw *middlewares.MiddlewareM
 	{
 		user.POST("/sign-up", h.user.SignUp())
 		user.POST("/sign-in", h.user.SignIn())
+		user.POST("/sign-in/mfa", h.user.SignInMFA())
 		user.POST("/auth/refresh", h.user.RefreshTokens())
+		user.POST("/verify-email", h.user.VerifyEmail())
+		user.POST("/verify-email/resend", h.user.ResendVerification())
+		user.POST("/password/forgot", h.user.ForgotPassword())
+		user.POST("/password/reset", h.user.ResetPassword())
+		user.POST("/email/confirm", h.user.ConfirmEmailChange())
+		user.POST("/restore", h.user.RestoreAccount())
 		user.Use(mw.AuthJWTMiddleware())
 		user.POST("/sign-out", h.user.SignOut())
 		user.GET("/me", h.user.GetMe())
+		user.PATCH("/me", h.user.UpdateMe())
+		user.DELETE("/me", h.user.DeleteMe())
+		user.GET("/me/export", h.export.ExportMe())
+		user.POST("/me/password", h.user.ChangePassword())
+		user.POST("/me/email", h.user.ChangeEmail())
+		user.GET("/sessions", h.user.GetSessions())
+		user.DELETE("/sessions", h.user.DeleteOtherSessions())
+		user.DELETE("/sessions/:id", h.user.DeleteSession())
+		mfa := user.Group("/mfa/totp")
+		{
+			mfa.POST("/enroll", h.user.EnrollTOTP())
+			mfa.POST("/confirm", h.user.ConfirmTOTP())
+			mfa.POST("/disable", h.user.DisableTOTP())
+		}
 	}
 } 
 
@@ -48,21 +91,23 @@ type UserHandler struct {
 	config  *config.Config
 	user    UserService
 	session SessionService
+	audit   AuditService
 }
 
 // New user handler constructor
-func NewUserHandler(config *config.Config, user UserService, session SessionService) *UserHandler {
+func NewUserHandler(config *config.Config, user UserService, session SessionService, audit AuditService) *UserHandler {
 	return &UserHandler{
 		config:  config,
 		user:    user,
 		session: session,
+		audit:   audit,
 	}
 }
 
 type inputUser struct {
 	Name     string `json:"name" validate:"required_with,lte=30"`
 	Email    string `json:"email" validate:"omitempty,email"`
-	Password string `json:"password,omitempty" validate:"required,gte=6"`
+	Password string `json:"password,omitempty" validate:"required"`
 }
 
 // SignUp godoc
@@ -73,6 +118,7 @@ type inputUser struct {
 // @Produce json
 // @Param input body inputUser true "sign up info"
 // @Success 201 {object} entity.User
+// @Failure 400 {object} httpe.ValidationError
 // @Router /user/sign-up [post]
 func (h *UserHandler) SignUp() echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -90,12 +136,12 @@ func (h *UserHandler) SignUp() echo.HandlerFunc {
 			Password: user.Password,
 		})
 		if err != nil {
+			h.audit.Record(ctx, entity.AuditSignUp, uuid.Nil, err)
 			return c.JSON(httpe.ParseErrors(err).Status(), httpe.ParseErrors(err))
 		}
+		h.audit.Record(ctx, entity.AuditSignUp, createdUser.User.ID, nil)
 
-		refreshToken, err := h.session.CreateSession(ctx, &entity.Session{
-			UserID: createdUser.User.ID,
-		}, h.config.Cookie.MaxAge)
+		refreshToken, err := h.session.CreateSession(ctx, newSession(c, createdUser.User.ID), h.config.Cookie.MaxAge)
 		if err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
@@ -113,12 +159,16 @@ type Login struct {
 
 // SignIn godoc
 // @Summary Login new user
-// @Description login user, returns user and set session
+// @Description login user, returns user and set session or MFA challenge when two-factor authentication is enabled
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param input body Login true "sign up info"
 // @Success 200 {object} entity.User
+// @Success 202 {object} entity.MFAChallenge
+// @Failure 401 {object} httpe.RestError
+// @Failure 423 {object} httpe.RestError
+// @Failure 429 {object} httpe.RestError
 // @Router /user/sign-in [post]
 func (h *UserHandler) SignIn() echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -129,17 +179,23 @@ func (h *UserHandler) SignIn() echo.HandlerFunc {
 		if err := utils.ReadRequest(c, login); err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
-		userWithToken, err := h.user.SignIn(ctx, &entity.User{
+		userWithToken, challenge, err := h.user.SignIn(ctx, &entity.User{
 			Email:    login.Email,
 			Password: login.Password,
 		})
 		if err != nil {
+			h.audit.Record(ctx, entity.AuditSignIn, uuid.Nil, err)
+			if retryAfter := httpe.RetryAfter(err); retryAfter > 0 {
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			}
 			return c.JSON(httpe.ErrorResponse(err))
 		}
+		if challenge != nil {
+			return c.JSON(http.StatusAccepted, challenge)
+		}
+		h.audit.Record(ctx, entity.AuditSignIn, userWithToken.User.ID, nil)
 
-		refreshToken, err := h.session.CreateSession(ctx, &entity.Session{
-			UserID: userWithToken.User.ID,
-		}, h.config.Cookie.MaxAge)
+		refreshToken, err := h.session.CreateSession(ctx, newSession(c, userWithToken.User.ID), h.config.Cookie.MaxAge)
 		if err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
@@ -175,34 +231,159 @@ func (h *UserHandler) RefreshTokens() echo.HandlerFunc {
 		if err := utils.ReadRequest(c, token); err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
-		uuid, err := h.session.GetUserID(ctx, token.Token)
-		if err != nil {
-			return c.JSON(httpe.ErrorResponse(err))
-		}
-		
-		user, err := h.user.GetUserByID(ctx, uuid)
+		// presented token is invalidated, its successor continues the session
+		refreshSession := newSession(c, uuid.Nil)
+		refreshSession.RefreshToken = token.Token
+		session, err := h.session.RefreshSession(ctx, refreshSession, h.config.Cookie.MaxAge)
 		if err != nil {
+			h.audit.Record(ctx, entity.AuditTokenRefresh, uuid.Nil, err)
 			return c.JSON(httpe.ErrorResponse(err))
 		}
 
-		refreshToken, err := h.session.CreateSession(ctx, &entity.Session{
-			UserID: user.User.ID,
-		}, h.config.Cookie.MaxAge)
+		user, err := h.user.GetUserByID(ctx, session.UserID)
+		h.audit.Record(ctx, entity.AuditTokenRefresh, session.UserID, err)
 		if err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
 
-		c.SetCookie(utils.ConfigureJWTCookie(h.config, refreshToken))
+		c.SetCookie(utils.ConfigureJWTCookie(h.config, session.RefreshToken))
 		return c.JSON(http.StatusOK, TokenResponse{
 			AccessToken: user.AccessToken,
-			RefreshToken: refreshToken,
+			RefreshToken: session.RefreshToken,
 		})
 	}
 }
 
+type VerifyEmailToken struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description verify user email with token from verification letter
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailToken true "verification token"
+// @Success 200 {string} string	"ok"
+// @Failure 400 {object} httpe.RestError
+// @Router /user/verify-email [post]
+func (h *UserHandler) VerifyEmail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.VerifyEmail")
+		defer span.Finish()
+
+		token := &VerifyEmailToken{}
+		if err := utils.ReadRequest(c, token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.VerifyEmail(ctx, token.Token); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+type ResendEmail struct {
+	Email string `json:"email" validate:"required,lte=60,email"`
+}
+
+// ResendVerification godoc
+// @Summary Resend verification letter
+// @Description send verification letter again, response does not depend on email existence
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body ResendEmail true "user email"
+// @Success 200 {string} string	"ok"
+// @Router /user/verify-email/resend [post]
+func (h *UserHandler) ResendVerification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ResendVerification")
+		defer span.Finish()
+
+		input := &ResendEmail{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.ResendVerification(ctx, &entity.User{
+			Email: input.Email,
+		}); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description send password reset letter, response does not depend on email existence
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body ResendEmail true "user email"
+// @Success 200 {string} string	"ok"
+// @Router /user/password/forgot [post]
+func (h *UserHandler) ForgotPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ForgotPassword")
+		defer span.Finish()
+
+		input := &ResendEmail{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.ForgotPassword(ctx, &entity.User{
+			Email: input.Email,
+		}); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description set new password with token from reset letter, all user sessions are revoked
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body ResetPassword true "reset token and new password"
+// @Success 200 {string} string	"ok"
+// @Failure 400 {object} httpe.ValidationError
+// @Router /user/password/reset [post]
+func (h *UserHandler) ResetPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "UserHandler.ResetPassword")
+		defer span.Finish()
+
+		input := &ResetPassword{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		if err := h.user.ResetPassword(ctx, input.Token, input.Password); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
 // SignOut godoc
 // @Summary Logout user
-// @Description logout user removing session
+// @Description logout user removing session and revoking access token
 // @Tags User
 // @Accept  json
 // @Produce  json
@@ -220,7 +401,17 @@ func (u *UserHandler) SignOut() echo.HandlerFunc {
 			}
 			return c.JSON(http.StatusInternalServerError, httpe.NewInternalServerError(err))
 		}
-		if err = u.session.DeleteSession(ctx, cookie.Value); err != nil {
+		// access token of the request stops working now, not when it expires
+		if token, ok := c.Get("access_token").(*entity.AccessToken); ok {
+			err = u.session.RevokeAccessToken(ctx, token)
+		}
+		if err == nil {
+			err = u.session.DeleteSession(ctx, cookie.Value)
+		}
+		if user, ok := c.Get("user").(*entity.User); ok {
+			u.audit.Record(ctx, entity.AuditSignOut, user.ID, err)
+		}
+		if err != nil {
 			return c.JSON(httpe.ErrorResponse(err))
 		}
 		utils.DeleteCookie(c, u.config.Cookie.Name)
@@ -242,9 +433,12 @@ func (u *UserHandler) GetMe() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user, ok := c.Get("user").(*entity.User)
 		if !ok {
-			httpe.NewUnauthorizedError(httpe.Unauthorized)
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
 		}
 
-		return c.JSON(http.StatusOK, user)
+		// the user of the context keeps its password hash for password checks
+		me := *user
+		me.SanitizePasswor()
+		return c.JSON(http.StatusOK, &me)
 	}
 }
\ No newline at end of file
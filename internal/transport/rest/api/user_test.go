@@ -1,15 +1,18 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
 	mockservice "github.com/Edbeer/Project/internal/service/mock"
 	"github.com/Edbeer/Project/pkg/converter"
+	"github.com/Edbeer/Project/pkg/httpe"
 	"github.com/Edbeer/Project/pkg/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -33,7 +36,7 @@ func TestHandler_Register(t *testing.T) {
 		},
 	}
 
-	userHandler := NewUserHandler(config, mockUserService, mockSessionService)
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
 
 	user := &entity.User{
 		Name: "PavelV",
@@ -66,6 +69,7 @@ func TestHandler_Register(t *testing.T) {
 	}
 	sess := &entity.Session{
 		UserID: userID,
+		IP:     "192.0.2.1",
 	}
 	token := "token"
 
@@ -92,7 +96,7 @@ func TestHandler_SignIn(t *testing.T) {
 		},
 	}
 
-	userHandler := NewUserHandler(config, mockUserService, mockSessionService)
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
 
 	type Login struct {
 		Email    string `json:"email" db:"email" validate:"omitempty,lte=60,email"`
@@ -134,10 +138,11 @@ func TestHandler_SignIn(t *testing.T) {
 	}
 	sess := &entity.Session{
 		UserID: userID,
+		IP:     "192.0.2.1",
 	}
 	token := "refresh token"
 
-	mockUserService.EXPECT().SignIn(ctxWithTrace, gomock.Eq(user)).Return(userWithToken, nil)
+	mockUserService.EXPECT().SignIn(ctxWithTrace, gomock.Eq(user)).Return(userWithToken, nil, nil)
 	mockSessionService.EXPECT().CreateSession(ctxWithTrace, gomock.Eq(sess), 10).Return(token, nil)
 
 	err = handlerFunc(c)
@@ -145,6 +150,77 @@ func TestHandler_SignIn(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestHandler_SignInThrottled(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+	userHandler := NewUserHandler(&config.Config{}, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	signIn := func(err error) *httptest.ResponseRecorder {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodPost, "/api/user/sign-in", strings.NewReader(`{"email":"edbeermtn@gmail.com","password":"12345678"}`))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+
+		mockUserService.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(nil, nil, err)
+
+		require.NoError(t, userHandler.SignIn()(c))
+		return recorder
+	}
+
+	t.Run("TooManyRequests", func(t *testing.T) {
+		recorder := signIn(httpe.NewTooManyRequestsError(httpe.TooManyAttempts, 1500*time.Millisecond))
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		recorder := signIn(httpe.NewLockedError(httpe.AccountLocked, 15*time.Minute))
+		require.Equal(t, http.StatusLocked, recorder.Code)
+		require.Equal(t, "900", recorder.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		recorder := signIn(sql.ErrNoRows)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+		require.Empty(t, recorder.Header().Get(echo.HeaderRetryAfter))
+	})
+}
+
+func TestHandler_ResetPasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+	userHandler := NewUserHandler(&config.Config{}, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/password/reset", strings.NewReader(`{"token":"reset","password":"qwerty"}`))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+	c := e.NewContext(request, recorder)
+
+	mockUserService.EXPECT().ResetPassword(gomock.Any(), "reset", "qwerty").Return(httpe.NewValidationError([]httpe.FieldError{
+		{Field: "password", Code: "too_short", Message: "Password must be at least 8 characters long"},
+	}))
+
+	require.NoError(t, userHandler.ResetPassword()(c))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(t, `{
+		"status": 400,
+		"error": "Invalid fields",
+		"fields": [{"field": "password", "code": "too_short", "message": "Password must be at least 8 characters long"}]
+	}`, recorder.Body.String())
+}
+
 func TestHandler_SignOut(t *testing.T) {
 	t.Parallel()
 
@@ -160,7 +236,7 @@ func TestHandler_SignOut(t *testing.T) {
 		},
 	}
 
-	userHandler := NewUserHandler(config, mockUserService, mockSessionService)
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
 	token := "jwt-token"
 	cookieValue := "cookieValue"
 
@@ -183,9 +259,153 @@ func TestHandler_SignOut(t *testing.T) {
 	require.NotEqual(t, cookie.Value, "")
 	require.Equal(t, cookie.Value, cookieValue)
 
+	// access token set by the auth middleware is revoked with the session
+	accessToken := &entity.AccessToken{ID: "jti", ExpiresAt: time.Now().Add(time.Minute)}
+	c.Set("access_token", accessToken)
+
+	mockSessionService.EXPECT().RevokeAccessToken(ctxWithTrace, accessToken).Return(nil)
 	mockSessionService.EXPECT().DeleteSession(ctxWithTrace, gomock.Eq(cookie.Value)).Return(nil)
 
 	err = logout(c)
 	require.NoError(t, err)
 	require.Nil(t, err)
-}
\ No newline at end of file
+}
+
+func TestHandler_VerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	input := &VerifyEmailToken{
+		Token: "token",
+	}
+
+	buffer, err := converter.AnyToBytesBuffer(input)
+	require.NoError(t, err)
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/verify-email", strings.NewReader(buffer.String()))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "userHandler.VerifyEmail")
+	defer span.Finish()
+
+	handlerFunc := userHandler.VerifyEmail()
+
+	mockUserService.EXPECT().VerifyEmail(ctxWithTrace, gomock.Eq(input.Token)).Return(nil)
+
+	err = handlerFunc(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	input := &ResetPassword{
+		Token:    "token",
+		Password: "12345678",
+	}
+
+	buffer, err := converter.AnyToBytesBuffer(input)
+	require.NoError(t, err)
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/password/reset", strings.NewReader(buffer.String()))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "userHandler.ResetPassword")
+	defer span.Finish()
+
+	handlerFunc := userHandler.ResetPassword()
+
+	mockUserService.EXPECT().ResetPassword(ctxWithTrace, input.Token, input.Password).Return(nil)
+
+	err = handlerFunc(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+func TestHandler_RefreshTokens(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			MaxAge: 10,
+		},
+	}
+
+	userHandler := NewUserHandler(config, mockUserService, mockSessionService, anyAudit(ctrl))
+
+	input := &RefreshToken{
+		Token: "refresh token",
+	}
+
+	buffer, err := converter.AnyToBytesBuffer(input)
+	require.NoError(t, err)
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/auth/refresh", strings.NewReader(buffer.String()))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "UserHandler.RefreshTokens")
+	defer span.Finish()
+
+	handlerFunc := userHandler.RefreshTokens()
+
+	session := &entity.Session{
+		RefreshToken: "next refresh token",
+		UserID:       uuid.New(),
+		FamilyID:     uuid.New(),
+	}
+	userWithToken := &entity.UserWithToken{
+		User: &entity.User{
+			ID: session.UserID,
+		},
+		AccessToken: "access token",
+	}
+
+	presented := &entity.Session{
+		RefreshToken: input.Token,
+		IP:           "192.0.2.1",
+	}
+	mockSessionService.EXPECT().RefreshSession(ctxWithTrace, gomock.Eq(presented), 10).Return(session, nil)
+	mockUserService.EXPECT().GetUserByID(ctxWithTrace, session.UserID).Return(userWithToken, nil)
+
+	err = handlerFunc(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), session.RefreshToken)
+}
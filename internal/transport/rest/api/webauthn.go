@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/internal/transport/rest/middlewares"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/Edbeer/Project/pkg/webauthn"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// WebAuthn service interface
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (*webauthn.CreationOptions, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, name string, response *webauthn.AttestationResponse) (*entity.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, user *entity.User) (*webauthn.RequestOptions, error)
+	FinishLogin(ctx context.Context, response *webauthn.AssertionResponse) (*entity.UserWithToken, error)
+}
+
+// init webauthn handlers
+func (h *Handlers) initWebAuthnHandlers(api *echo.Group, mw *middlewares.MiddlewareManager) {
+	webauthn := api.Group("/user/webauthn")
+	{
+		webauthn.POST("/login/begin", h.webauthn.BeginLogin())
+		webauthn.POST("/login/finish", h.webauthn.FinishLogin())
+		webauthn.POST("/register/begin", h.webauthn.BeginRegistration(), mw.AuthJWTMiddleware())
+		webauthn.POST("/register/finish", h.webauthn.FinishRegistration(), mw.AuthJWTMiddleware())
+	}
+}
+
+// WebAuthn handler
+type WebAuthnHandler struct {
+	config   *config.Config
+	webauthn WebAuthnService
+	session  SessionService
+}
+
+// New webauthn handler constructor
+func NewWebAuthnHandler(config *config.Config, webauthn WebAuthnService, session SessionService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		config:   config,
+		webauthn: webauthn,
+		session:  session,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary Begin passkey registration
+// @Description returns options for navigator.credentials.create
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Success 200 {object} webauthn.CreationOptions
+// @Failure 401 {object} httpe.RestError
+// @Router /user/webauthn/register/begin [post]
+func (h *WebAuthnHandler) BeginRegistration() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "WebAuthnHandler.BeginRegistration")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		options, err := h.webauthn.BeginRegistration(ctx, user.ID)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, options)
+	}
+}
+
+type RegistrationFinish struct {
+	Name       string                       `json:"name" validate:"lte=64"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+// FinishRegistration godoc
+// @Summary Finish passkey registration
+// @Description verifies attestation response and stores passkey
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Param input body RegistrationFinish true "passkey name and navigator.credentials.create result"
+// @Success 201 {object} entity.WebAuthnCredential
+// @Failure 400 {object} httpe.RestError
+// @Router /user/webauthn/register/finish [post]
+func (h *WebAuthnHandler) FinishRegistration() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "WebAuthnHandler.FinishRegistration")
+		defer span.Finish()
+
+		user, ok := c.Get("user").(*entity.User)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+		}
+
+		input := &RegistrationFinish{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		credential, err := h.webauthn.FinishRegistration(ctx, user.ID, input.Name, &input.Credential)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusCreated, credential)
+	}
+}
+
+type LoginBegin struct {
+	Email string `json:"email" validate:"omitempty,lte=60,email"`
+}
+
+// BeginLogin godoc
+// @Summary Begin passkey login
+// @Description returns options for navigator.credentials.get, email is optional for discoverable passkeys
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Param input body LoginBegin false "user email"
+// @Success 200 {object} webauthn.RequestOptions
+// @Router /user/webauthn/login/begin [post]
+func (h *WebAuthnHandler) BeginLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "WebAuthnHandler.BeginLogin")
+		defer span.Finish()
+
+		input := &LoginBegin{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		options, err := h.webauthn.BeginLogin(ctx, &entity.User{
+			Email: input.Email,
+		})
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		return c.JSON(http.StatusOK, options)
+	}
+}
+
+// FinishLogin godoc
+// @Summary Finish passkey login
+// @Description verifies assertion, returns user and set session
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Param input body webauthn.AssertionResponse true "navigator.credentials.get result"
+// @Success 200 {object} entity.UserWithToken
+// @Failure 401 {object} httpe.RestError
+// @Router /user/webauthn/login/finish [post]
+func (h *WebAuthnHandler) FinishLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "WebAuthnHandler.FinishLogin")
+		defer span.Finish()
+
+		input := &webauthn.AssertionResponse{}
+		if err := utils.ReadRequest(c, input); err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		userWithToken, err := h.webauthn.FinishLogin(ctx, input)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		refreshToken, err := h.session.CreateSession(ctx, newSession(c, userWithToken.User.ID), h.config.Cookie.MaxAge)
+		if err != nil {
+			return c.JSON(httpe.ErrorResponse(err))
+		}
+
+		c.SetCookie(utils.ConfigureJWTCookie(h.config, refreshToken))
+		return c.JSON(http.StatusOK, userWithToken)
+	}
+}
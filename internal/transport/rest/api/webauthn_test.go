@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/converter"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/Edbeer/Project/pkg/webauthn"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_WebAuthnFinishLogin(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWebAuthnService := mockservice.NewMockWebAuthn(ctrl)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+
+	config := &config.Config{
+		Cookie: config.Cookie{
+			MaxAge: 10,
+		},
+	}
+
+	webauthnHandler := NewWebAuthnHandler(config, mockWebAuthnService, mockSessionService)
+
+	input := &webauthn.AssertionResponse{
+		ID:   "credential",
+		Type: "public-key",
+	}
+	input.Response.ClientDataJSON = "client-data"
+	input.Response.AuthenticatorData = "auth-data"
+	input.Response.Signature = "signature"
+
+	buffer, err := converter.AnyToBytesBuffer(input)
+	require.NoError(t, err)
+
+	e := echo.New()
+	request := httptest.NewRequest(http.MethodPost, "/api/user/webauthn/login/finish", strings.NewReader(buffer.String()))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	ctx := utils.GetRequestCtx(c)
+	span, ctxWithTrace := opentracing.StartSpanFromContext(ctx, "WebAuthnHandler.FinishLogin")
+	defer span.Finish()
+
+	handlerFunc := webauthnHandler.FinishLogin()
+
+	userID := uuid.New()
+	userWithToken := &entity.UserWithToken{
+		User: &entity.User{
+			ID: userID,
+		},
+	}
+	sess := &entity.Session{
+		UserID: userID,
+		IP:     "192.0.2.1",
+	}
+
+	mockWebAuthnService.EXPECT().FinishLogin(ctxWithTrace, gomock.Eq(input)).Return(userWithToken, nil)
+	mockSessionService.EXPECT().CreateSession(ctxWithTrace, gomock.Eq(sess), 10).Return("refresh token", nil)
+
+	err = handlerFunc(c)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -2,11 +2,15 @@ package middlewares
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"errors"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
 	"github.com/Edbeer/Project/pkg/httpe"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -26,7 +30,7 @@ func (mw *MiddlewareManager) AuthJWTMiddleware() echo.MiddlewareFunc {
 
 				tokenString := headerParts[1]
 
-				if err := validateJWTToken(tokenString, mw.user, c, mw.config); err != nil {
+				if err := validateJWTToken(tokenString, mw.session, mw.user, mw.accounts, mw.keys, c, mw.config); err != nil {
 					return c.JSON(httpe.ErrorResponse(err))
 				}
 				return next(c)
@@ -36,7 +40,7 @@ func (mw *MiddlewareManager) AuthJWTMiddleware() echo.MiddlewareFunc {
 					return c.JSON(httpe.ErrorResponse(err))
 				}
 
-				if err := validateJWTToken(cookie.Value, mw.user, c, mw.config); err != nil {
+				if err := validateJWTToken(cookie.Value, mw.session, mw.user, mw.accounts, mw.keys, c, mw.config); err != nil {
 					return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
 				}
 				return next(c)
@@ -45,18 +49,54 @@ func (mw *MiddlewareManager) AuthJWTMiddleware() echo.MiddlewareFunc {
 	}
 }
 
-func validateJWTToken(tokenString string, user UserService, c echo.Context, config *config.Config) error {
+// Session cookie auth for browser pages like the oauth consent, sets user and
+// session. Users without
+// a session are redirected to the login page with the page url in return_to
+func (mw *MiddlewareManager) AuthSessionMiddleware(loginURL string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(mw.config.Cookie.Name)
+			if err == nil && cookie.Value != "" {
+				session, err := mw.session.GetSession(c.Request().Context(), cookie.Value)
+				if err == nil {
+					u, err := mw.user.GetUserByID(c.Request().Context(), session.UserID)
+					if err != nil {
+						return c.JSON(httpe.ErrorResponse(err))
+					}
+					if mw.config.Verification.Required && !u.User.IsVerified() {
+						return c.JSON(http.StatusForbidden, httpe.NewForbiddenError(httpe.EmailNotVerified))
+					}
+
+					c.Set("user", u.User)
+					c.Set("session", session)
+
+					ctx := context.WithValue(c.Request().Context(), "user", u.User)
+					c.SetRequest(c.Request().WithContext(ctx))
+					return next(c)
+				}
+			}
+
+			login, err := url.Parse(loginURL)
+			if loginURL == "" || err != nil || c.Request().Method != http.MethodGet {
+				return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+			}
+			query := login.Query()
+			query.Set("return_to", c.Scheme()+"://"+c.Request().Host+c.Request().URL.RequestURI())
+			login.RawQuery = query.Encode()
+			return c.Redirect(http.StatusFound, login.String())
+		}
+	}
+}
+
+// Sets the principal of the token, *entity.User or *entity.ServiceAccount,
+// and the token itself as *entity.AccessToken for revocation
+func validateJWTToken(tokenString string, session SessionService, user UserService, accounts ServiceAccountService, keys KeyProvider, c echo.Context, config *config.Config) error {
 	if tokenString == "" {
 		return httpe.InvalidJWTToken
 	}
 
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signin method %v", t.Header["alg"])
-		}
-		secret := []byte(config.Server.JwtSecretKey)
-		return secret, nil
-	})
+	// key is selected by kid header
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
 
 	if err != nil {
 		return err
@@ -67,6 +107,13 @@ func validateJWTToken(tokenString string, user UserService, c echo.Context, conf
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		if isActionToken(claims) {
+			return httpe.InvalidJWTToken
+		}
+		if claims["principal"] == entity.PrincipalService {
+			return validateServiceToken(claims, session, accounts, c, config)
+		}
+
 		userID, ok := claims["id"].(string)
 		if !ok {
 			return httpe.InvalidJWTClaims
@@ -77,15 +124,115 @@ func validateJWTToken(tokenString string, user UserService, c echo.Context, conf
 			return err
 		}
 
+		accessToken := accessTokenOf(claims, userID)
+		if err := checkRevoked(c, session, accessToken); err != nil {
+			return err
+		}
+
 		u, err := user.GetUserByID(c.Request().Context(), userUUID)
 		if err != nil {
 			return err
 		}
 
-		c.Set("user", u)
+		if config.Verification.Required && !u.User.IsVerified() {
+			return httpe.NewForbiddenError(httpe.EmailNotVerified)
+		}
+
+		c.Set("user", u.User)
+		c.Set("access_token", accessToken)
 
-		ctx := context.WithValue(c.Request().Context(), "user", u)
+		ctx := context.WithValue(c.Request().Context(), "user", u.User)
 		c.SetRequest(c.Request().WithContext(ctx))
 	}
 	return nil
-}
\ No newline at end of file
+}
+// Service account of the token acts with the token scopes
+// which are still granted to the account
+func validateServiceToken(claims jwt.MapClaims, session SessionService, accounts ServiceAccountService, c echo.Context, config *config.Config) error {
+	clientID, ok := claims["sub"].(string)
+	if !ok || clientID == "" || !claims.VerifyIssuer(config.OAuth.Issuer, true) {
+		return httpe.InvalidJWTClaims
+	}
+
+	accessToken := accessTokenOf(claims, clientID)
+	if err := checkRevoked(c, session, accessToken); err != nil {
+		return err
+	}
+
+	account, err := accounts.GetServiceAccount(c.Request().Context(), clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return httpe.InvalidJWTToken
+	}
+	if err != nil {
+		return err
+	}
+
+	scope, _ := claims["scope"].(string)
+	granted := entity.SpaceList{}
+	for _, s := range strings.Fields(scope) {
+		if account.HasPermission(s) {
+			granted = append(granted, s)
+		}
+	}
+	account.Scopes = granted
+
+	c.Set("user", account)
+	c.Set("access_token", accessToken)
+
+	ctx := context.WithValue(c.Request().Context(), "user", account)
+	c.SetRequest(c.Request().WithContext(ctx))
+	return nil
+}
+
+// Action tokens of mailed links are signed by the same keys,
+// they never authenticate requests
+func isActionToken(claims jwt.MapClaims) bool {
+	_, ok := claims["action"]
+	return ok
+}
+
+// Access token issued to a user on sign-in, not to a service account or an oauth client
+func isUserAccessToken(claims jwt.MapClaims) bool {
+	if isActionToken(claims) {
+		return false
+	}
+	if _, ok := claims["principal"]; ok {
+		return false
+	}
+	if _, ok := claims["client_id"]; ok {
+		return false
+	}
+	_, ok := claims["id"].(string)
+	return ok
+}
+
+// Access token of the claims, tokens without jti are
+// still revoked by the watermark of their subject
+func accessTokenOf(claims jwt.MapClaims, subject string) *entity.AccessToken {
+	id, _ := claims["jti"].(string)
+	issuedAt, _ := claims["iat"].(float64)
+	expiresAt, _ := claims["exp"].(float64)
+	token := &entity.AccessToken{
+		ID:        id,
+		Subject:   subject,
+		IssuedAt:  time.Unix(int64(issuedAt), 0),
+		ExpiresAt: time.Unix(int64(expiresAt), 0),
+	}
+	// watermarks are in milliseconds, tokens without iat_ms fall back to iat
+	if issuedAtMs, ok := claims["iat_ms"].(float64); ok {
+		token.IssuedAt = time.UnixMilli(int64(issuedAtMs))
+	}
+	return token
+}
+
+// Reject access token revoked on its own or with all tokens of its subject
+func checkRevoked(c echo.Context, session SessionService, token *entity.AccessToken) error {
+	revoked, err := session.IsAccessTokenRevoked(c.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return httpe.InvalidJWTToken
+	}
+	return nil
+}
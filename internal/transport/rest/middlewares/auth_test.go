@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_AuthJWT(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, err := jwt.NewManager("secret")
+	require.NoError(t, err)
+	mockSessionService := mockservice.NewMockSession(ctrl)
+	mockUserService := mockservice.NewMockUser(ctrl)
+	mw := NewMiddlewareManager(mockSessionService, mockUserService, nil, manager, nil, &config.Config{}, nil, nil)
+	handler := mw.AuthJWTMiddleware()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	user := &entity.User{ID: uuid.New(), Email: "edbeermtn@gmail.com"}
+	request := func(tokenString string) int {
+		e := echo.New()
+		request := httptest.NewRequest(http.MethodGet, "/api/user/me", nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+tokenString)
+		recorder := httptest.NewRecorder()
+		require.NoError(t, handler(e.NewContext(request, recorder)))
+		return recorder.Code
+	}
+
+	t.Run("AccessToken", func(t *testing.T) {
+		accessToken, err := manager.GenerateJWTToken(user)
+		require.NoError(t, err)
+		mockSessionService.EXPECT().IsAccessTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockUserService.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(&entity.UserWithToken{User: user}, nil)
+
+		require.Equal(t, http.StatusOK, request(accessToken))
+	})
+
+	t.Run("ActionToken", func(t *testing.T) {
+		// mailed links are signed by the same keys but are not access tokens
+		for _, action := range []string{entity.ActionVerifyEmail, entity.ActionChangeEmail} {
+			actionToken, err := manager.GenerateActionToken(&entity.ActionToken{
+				ID:        uuid.New().String(),
+				UserID:    user.ID,
+				Email:     user.Email,
+				Action:    action,
+				ExpiresAt: time.Now().Add(time.Hour),
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, http.StatusUnauthorized, request(actionToken))
+		}
+	})
+}
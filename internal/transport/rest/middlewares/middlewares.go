@@ -6,13 +6,14 @@ import (
 	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 )
 
 // User service interface
 type UserService interface {
 	SignUp(ctx context.Context, input *entity.User) (*entity.UserWithToken, error)
-	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, error)
+	SignIn(ctx context.Context, user *entity.User) (*entity.UserWithToken, *entity.MFAChallenge, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.UserWithToken, error)
 }
 
@@ -20,24 +21,42 @@ type UserService interface {
 type SessionService interface {
 	CreateSession(ctx context.Context, session *entity.Session, expire int) (string, error)
 	GetUserID(ctx context.Context, refreshToken string) (uuid.UUID, error)
+	GetSession(ctx context.Context, refreshToken string) (*entity.Session, error)
+	IsAccessTokenRevoked(ctx context.Context, token *entity.AccessToken) (bool, error)
+}
+
+// Service account lookup interface
+type ServiceAccountService interface {
+	GetServiceAccount(ctx context.Context, clientID string) (*entity.ServiceAccount, error)
+}
+
+// Token verification key provider
+type KeyProvider interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
 }
 
 // Middleware manager
 type MiddlewareManager struct {
-	session SessionService
-	user    UserService
-	config  *config.Config
-	origins []string
-	logger  logger.Logger
+	session  SessionService
+	user     UserService
+	accounts ServiceAccountService
+	keys     KeyProvider
+	limiter  RateLimiter
+	config   *config.Config
+	origins  []string
+	logger   logger.Logger
 }
 
 // Middleware manager constructor
-func NewMiddlewareManager(session SessionService, user UserService, config *config.Config, origins []string, logger logger.Logger) *MiddlewareManager {
+func NewMiddlewareManager(session SessionService, user UserService, accounts ServiceAccountService, keys KeyProvider, limiter RateLimiter, config *config.Config, origins []string, logger logger.Logger) *MiddlewareManager {
 	return &MiddlewareManager{
-		session: session,
-		user:    user,
-		config:  config,
-		origins: origins,
-		logger:  logger,
+		session:  session,
+		user:     user,
+		accounts: accounts,
+		keys:     keys,
+		limiter:  limiter,
+		config:   config,
+		origins:  origins,
+		logger:   logger,
 	}
 }
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/labstack/echo/v4"
+)
+
+// Allow request only when the signed in user or the service account
+// has the permission, goes after AuthJWTMiddleware
+func (mw *MiddlewareManager) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := c.Get("user").(entity.Principal)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, httpe.NewUnauthorizedError(httpe.Unauthorized))
+			}
+
+			if !principal.HasPermission(permission) {
+				return c.JSON(http.StatusForbidden, httpe.NewForbiddenError(httpe.PermissionDenied))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_RequirePermission(t *testing.T) {
+	t.Parallel()
+
+	mw := NewMiddlewareManager(nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := mw.RequirePermission(entity.PermissionUsersRead)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		user   interface{}
+		status int
+	}{
+		{
+			name:   "NotSignedIn",
+			user:   nil,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "NoPermission",
+			user:   &entity.User{},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "OtherPermission",
+			user: &entity.User{Access: entity.Access{
+				Permissions: []string{entity.PermissionUsersWrite},
+			}},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "Granted",
+			user: &entity.User{Access: entity.Access{
+				Roles:       []string{entity.RoleAdmin},
+				Permissions: []string{entity.PermissionUsersRead},
+			}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ServiceAccountWithoutScope",
+			user:   &entity.ServiceAccount{Scopes: entity.SpaceList{entity.PermissionAuditRead}},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "ServiceAccountGranted",
+			user:   &entity.ServiceAccount{Scopes: entity.SpaceList{entity.PermissionUsersRead}},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			recorder := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/users", nil), recorder)
+			if tt.user != nil {
+				c.Set("user", tt.user)
+			}
+
+			require.NoError(t, handler(c))
+			require.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	"github.com/Edbeer/Project/pkg/httpe"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+// Rate limit keys
+const (
+	RateLimitByIP    = "ip"
+	RateLimitByUser  = "user"
+	RateLimitByRoute = "route"
+)
+
+// Rate limit headers
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// Rate limiter interface
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, policy *entity.RateLimitPolicy) (*entity.RateLimit, error)
+}
+
+// Distributed rate limit by the policy of the route
+func (mw *MiddlewareManager) RateLimit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cfg := mw.config.RateLimit
+			if !cfg.Enabled {
+				return next(c)
+			}
+
+			policy, bucket := rateLimitPolicy(c, &cfg)
+			if policy.Limit < 1 || policy.Period < 1 {
+				return next(c)
+			}
+
+			key := bucket + ":" + mw.rateLimitKey(c, policy.Key)
+			limit, err := mw.limiter.Allow(c.Request().Context(), key, &entity.RateLimitPolicy{
+				Limit:  policy.Limit,
+				Period: time.Duration(policy.Period) * time.Second,
+				Burst:  policy.Burst,
+			})
+			if err != nil {
+				if cfg.FailOpen {
+					mw.logger.Errorf("rate limit: %s %s: %v", c.Request().Method, c.Path(), err)
+					return next(c)
+				}
+				return c.JSON(http.StatusServiceUnavailable, httpe.NewRestError(http.StatusServiceUnavailable, httpe.RateLimitUnavailable.Error(), err))
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(policy.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(limit.Remaining))
+			header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(limit.Reset)))
+			header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", policy.Limit, policy.Period))
+
+			if !limit.Allowed {
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(ceilSeconds(limit.RetryAfter)))
+				return c.JSON(http.StatusTooManyRequests, httpe.NewRestError(http.StatusTooManyRequests, httpe.RateLimitExceeded.Error(), nil))
+			}
+			return next(c)
+		}
+	}
+}
+
+// Policy of the matched route and its quota name, Default is shared by other routes
+func rateLimitPolicy(c echo.Context, cfg *config.RateLimit) (*config.RateLimitPolicy, string) {
+	method := c.Request().Method
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		if route.Path == c.Path() && (route.Method == "" || strings.EqualFold(route.Method, method)) {
+			return route, route.Method + " " + route.Path
+		}
+	}
+	return &cfg.Default, "default"
+}
+
+// Quota owner of the request, user falls back to IP for anonymous requests
+func (mw *MiddlewareManager) rateLimitKey(c echo.Context, kind string) string {
+	switch kind {
+	case RateLimitByRoute:
+		return RateLimitByRoute
+	case RateLimitByUser:
+		if userID := mw.tokenUserID(c); userID != "" {
+			return RateLimitByUser + ":" + userID
+		}
+	}
+
+	// resolved by the trusted proxy extractor of the server
+	return RateLimitByIP + ":" + utils.GetIP(c)
+}
+
+// User id from the access token signature without loading the user,
+// rate limit runs before authentication. Only access tokens of the users
+// count, service, oauth and action tokens fall back to IP
+func (mw *MiddlewareManager) tokenUserID(c echo.Context) string {
+	tokenString := ""
+	if headerParts := strings.Split(c.Request().Header.Get("Authorization"), " "); len(headerParts) == 2 {
+		tokenString = headerParts[1]
+	} else if cookie, err := c.Cookie("jwt-token"); err == nil {
+		tokenString = cookie.Value
+	}
+	if tokenString == "" || mw.keys == nil {
+		return ""
+	}
+
+	token, err := jwt.Parse(tokenString, mw.keys.Keyfunc)
+	if err != nil || !token.Valid {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !isUserAccessToken(claims) {
+		return ""
+	}
+	userID, _ := claims["id"].(string)
+	return userID
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
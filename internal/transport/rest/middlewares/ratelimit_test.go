@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Edbeer/Project/config"
+	"github.com/Edbeer/Project/internal/entity"
+	mockservice "github.com/Edbeer/Project/internal/service/mock"
+	"github.com/Edbeer/Project/pkg/jwt"
+	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{
+		Server: config.Server{
+			TrustedProxies: []string{"192.0.2.0/24"},
+		},
+		RateLimit: config.RateLimit{
+			Enabled: true,
+			Default: config.RateLimitPolicy{
+				Key:    RateLimitByIP,
+				Limit:  100,
+				Period: 60,
+			},
+			Routes: []config.RateLimitPolicy{
+				{
+					Method: http.MethodPost,
+					Path:   "/api/user/sign-in",
+					Key:    RateLimitByIP,
+					Limit:  10,
+					Period: 60,
+					Burst:  5,
+				},
+				{
+					Method: http.MethodGet,
+					Path:   "/api/user/sessions",
+					Key:    RateLimitByUser,
+					Limit:  60,
+					Period: 60,
+				},
+			},
+		},
+	}
+	apiLogger := logger.NewApiLogger(&config.Config{})
+	apiLogger.InitLogger()
+
+	manager, err := jwt.NewManager("secret")
+	require.NoError(t, err)
+	ipExtractor, err := utils.NewIPExtractor(cfg)
+	require.NoError(t, err)
+	mockRateLimit := mockservice.NewMockRateLimit(ctrl)
+	mw := NewMiddlewareManager(nil, nil, nil, manager, mockRateLimit, cfg, nil, apiLogger)
+	handler := mw.RateLimit()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	newContext := func(method, path string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.IPExtractor = ipExtractor
+		request := httptest.NewRequest(method, path, nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.SetPath(path)
+		return c, recorder
+	}
+
+	t.Run("RoutePolicy", func(t *testing.T) {
+		c, recorder := newContext(http.MethodPost, "/api/user/sign-in")
+
+		mockRateLimit.EXPECT().Allow(gomock.Any(), "POST /api/user/sign-in:ip:192.0.2.1", &entity.RateLimitPolicy{
+			Limit:  10,
+			Period: time.Minute,
+			Burst:  5,
+		}).Return(&entity.RateLimit{Allowed: true, Remaining: 4, Reset: 6 * time.Second}, nil)
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "10", recorder.Header().Get(HeaderRateLimitLimit))
+		require.Equal(t, "4", recorder.Header().Get(HeaderRateLimitRemaining))
+		require.Equal(t, "6", recorder.Header().Get(HeaderRateLimitReset))
+		require.Equal(t, "10;w=60", recorder.Header().Get(HeaderRateLimitPolicy))
+	})
+
+	t.Run("Exceeded", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me")
+
+		mockRateLimit.EXPECT().Allow(gomock.Any(), "default:ip:192.0.2.1", gomock.Any()).
+			Return(&entity.RateLimit{RetryAfter: 600 * time.Millisecond, Reset: time.Minute}, nil)
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "0", recorder.Header().Get(HeaderRateLimitRemaining))
+		require.Equal(t, "1", recorder.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("TrustedProxy", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me")
+		c.Request().Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+
+		mockRateLimit.EXPECT().Allow(gomock.Any(), "default:ip:203.0.113.7", gomock.Any()).
+			Return(&entity.RateLimit{Allowed: true}, nil)
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UntrustedProxy", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me")
+		c.Request().RemoteAddr = "198.51.100.1:1234"
+		c.Request().Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+
+		// forwarded address of a direct client is spoofed
+		mockRateLimit.EXPECT().Allow(gomock.Any(), "default:ip:198.51.100.1", gomock.Any()).
+			Return(&entity.RateLimit{Allowed: true}, nil)
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UserToken", func(t *testing.T) {
+		userID := uuid.New()
+		accessToken, err := manager.GenerateJWTToken(&entity.User{ID: userID})
+		require.NoError(t, err)
+		c, recorder := newContext(http.MethodGet, "/api/user/sessions")
+		c.Request().Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+
+		mockRateLimit.EXPECT().Allow(gomock.Any(), "GET /api/user/sessions:user:"+userID.String(), gomock.Any()).
+			Return(&entity.RateLimit{Allowed: true}, nil)
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("NotUserToken", func(t *testing.T) {
+		serviceToken, err := manager.GenerateServiceToken("issuer", &entity.ServiceAccount{ClientID: "backup-job"}, nil)
+		require.NoError(t, err)
+		oauthToken, err := manager.GenerateOAuthToken("issuer", &entity.OAuthGrant{ClientID: "app", UserID: uuid.New()})
+		require.NoError(t, err)
+
+		// quota of the users is not shared with service accounts and oauth clients
+		for _, token := range []string{serviceToken, oauthToken} {
+			c, recorder := newContext(http.MethodGet, "/api/user/sessions")
+			c.Request().Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+			mockRateLimit.EXPECT().Allow(gomock.Any(), "GET /api/user/sessions:ip:192.0.2.1", gomock.Any()).
+				Return(&entity.RateLimit{Allowed: true}, nil)
+
+			require.NoError(t, handler(c))
+			require.Equal(t, http.StatusOK, recorder.Code)
+		}
+	})
+
+	t.Run("FailOpen", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me")
+
+		mw.config.RateLimit.FailOpen = true
+		defer func() { mw.config.RateLimit.FailOpen = false }()
+		mockRateLimit.EXPECT().Allow(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("redis is down"))
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("FailClosed", func(t *testing.T) {
+		c, recorder := newContext(http.MethodGet, "/api/user/me")
+
+		mockRateLimit.EXPECT().Allow(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("redis is down"))
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	})
+}
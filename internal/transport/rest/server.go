@@ -14,8 +14,12 @@ import (
 	"github.com/Edbeer/Project/internal/storage/psql"
 	"github.com/Edbeer/Project/internal/storage/redis"
 	"github.com/Edbeer/Project/internal/transport/rest/api"
+	"github.com/Edbeer/Project/pkg/hash"
 	"github.com/Edbeer/Project/pkg/jwt"
 	"github.com/Edbeer/Project/pkg/logger"
+	"github.com/Edbeer/Project/pkg/mail"
+	"github.com/Edbeer/Project/pkg/password"
+	"github.com/Edbeer/Project/pkg/utils"
 	"github.com/go-redis/redis/v9"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
@@ -43,28 +47,60 @@ func NewServer(config *config.Config, psql *sqlx.DB, redis *redis.Client, logger
 func (s *Server) Run() error {
 	// Services, Repos & API Handlers
 
-	tokenManager, err := jwt.NewManager(s.config.Server.JwtSecretKey)
+	tokenManager, err := jwt.NewManagerFromConfig(s.config)
 	if err != nil {
 		return err
 	}
+	hasher, err := hash.NewPasswordHasherFromConfig(s.config)
+	if err != nil {
+		return err
+	}
+	policy, err := password.NewPolicyFromConfig(s.config)
+	if err != nil {
+		return err
+	}
+	ipExtractor, err := utils.NewIPExtractor(s.config)
+	if err != nil {
+		return err
+	}
+	s.echo.IPExtractor = ipExtractor
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if s.config.JWT.KeyRingFile != "" {
+		go s.reloadKeyRing(backgroundCtx, tokenManager)
+	}
 	psql := psql.NewStorage(s.psql)
 	redis := redisrepo.NewStorage(redisrepo.Deps{
-		Redis: s.redis,
+		Redis:       s.redis,
+		TokenSecret: []byte(s.config.Session.TokenSecret),
 	})
 	service := service.NewServices(service.Deps{
 		Config:       s.config,
 		PsqlStorage:  psql,
 		RedisStorage: redis,
 		TokenManager: tokenManager,
+		Hasher:       hasher,
+		Policy:       policy,
+		Mailer:       mail.NewSMTPSender(s.config),
+		Logger:       s.logger,
 	})
 	handlers := api.NewHandlers(api.Deps{
-		UserService:    service.User,
-		SessionService: service.Session,
-		Config:         s.config,
+		UserService:     service.User,
+		SessionService:  service.Session,
+		WebAuthnService: service.WebAuthn,
+		AdminService:    service.User,
+		ExportService:   service.Export,
+		AuditService:    service.Audit,
+		OAuthService:    service.OAuth,
+		ServiceAccounts: service.Service,
+		RateLimiter:     service.RateLimit,
+		KeyManager:      tokenManager,
+		Config:          s.config,
 	})
 	if err := handlers.Init(s.echo, s.logger); err != nil {
 		log.Fatal(err)
 	}
+	go s.purgeDeletedAccounts(backgroundCtx, service.User)
 
 	server := &http.Server{
 		Addr:           s.config.Server.Port,
@@ -93,3 +129,55 @@ func (s *Server) Run() error {
 	s.logger.Info("Server Exited Properly")
 	return s.echo.Server.Shutdown(ctx)
 }
+
+// Periodically reload signing key ring so promoted keys are picked up without restart
+func (s *Server) reloadKeyRing(ctx context.Context, tokenManager *jwt.Manager) {
+	interval := time.Duration(s.config.JWT.ReloadInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ring, err := jwt.LoadKeyRing(s.config.JWT.KeyRingFile)
+			if err != nil {
+				s.logger.Errorf("Load key ring: %v", err)
+				continue
+			}
+			if err := tokenManager.SetKeyRing(ring); err != nil {
+				s.logger.Errorf("Set key ring: %v", err)
+			}
+		}
+	}
+}
+
+// Periodically erase accounts deleted longer than the grace period ago
+func (s *Server) purgeDeletedAccounts(ctx context.Context, users *service.UserService) {
+	interval := time.Duration(s.config.Deletion.PurgeInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := users.PurgeDeletedAccounts(ctx)
+			if err != nil {
+				s.logger.Errorf("Purge deleted accounts: %v", err)
+				continue
+			}
+			if purged > 0 {
+				s.logger.Infof("Purged %d deleted accounts", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package hash
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Edbeer/Project/config"
+)
+
+// Password hash algorithms
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("hash: password does not match")
+	ErrUnknownAlgorithm   = errors.New("hash: unknown password hash algorithm")
+)
+
+// Password hash algorithm, encoded hash records the algorithm and its parameters
+type Algorithm interface {
+	Name() string
+	// Check that encoded hash is produced by the algorithm
+	Identify(encoded string) bool
+	Hash(password string) (string, error)
+	Compare(encoded, password string) error
+	// Check that encoded hash uses weaker parameters than the algorithm
+	NeedsRehash(encoded string) bool
+}
+
+// Password hasher, new hashes use the first algorithm,
+// hashes of every algorithm can be verified
+type PasswordHasher struct {
+	algorithm  Algorithm
+	algorithms []Algorithm
+}
+
+// Password hasher constructor
+func NewPasswordHasher(algorithm Algorithm, others ...Algorithm) *PasswordHasher {
+	return &PasswordHasher{
+		algorithm:  algorithm,
+		algorithms: append([]Algorithm{algorithm}, others...),
+	}
+}
+
+// Password hasher constructor from password hash config
+func NewPasswordHasherFromConfig(cfg *config.Config) (*PasswordHasher, error) {
+	argon := NewArgon2id(Argon2idParams{
+		Memory:  cfg.PasswordHash.Argon2Memory,
+		Time:    cfg.PasswordHash.Argon2Time,
+		Threads: cfg.PasswordHash.Argon2Threads,
+		KeyLen:  cfg.PasswordHash.Argon2KeyLen,
+		SaltLen: cfg.PasswordHash.Argon2SaltLen,
+	})
+	bcrypt := NewBcrypt(cfg.PasswordHash.BcryptCost)
+	// hashes of imported users, upgraded on the first sign-in
+	legacy := []Algorithm{NewLegacySHA1(), NewPBKDF2SHA1(), NewPBKDF2SHA256(), NewPBKDF2SHA512()}
+
+	switch cfg.PasswordHash.Algorithm {
+	case "", AlgArgon2id:
+		return NewPasswordHasher(argon, append([]Algorithm{bcrypt}, legacy...)...), nil
+	case AlgBcrypt:
+		return NewPasswordHasher(bcrypt, append([]Algorithm{argon}, legacy...)...), nil
+	default:
+		return nil, fmt.Errorf("hash: unsupported password hash algorithm %q", cfg.PasswordHash.Algorithm)
+	}
+}
+
+// Hash password with the default algorithm
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.algorithm.Hash(password)
+}
+
+// Verify password against encoded hash, rehash reports that the hash
+// uses another algorithm or weaker parameters than the default one
+func (h *PasswordHasher) Verify(encoded, password string) (rehash bool, err error) {
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Identify(encoded) {
+			continue
+		}
+		if err := algorithm.Compare(encoded, password); err != nil {
+			return false, err
+		}
+		return algorithm.Name() != h.algorithm.Name() || algorithm.NeedsRehash(encoded), nil
+	}
+	return false, ErrUnknownAlgorithm
+}
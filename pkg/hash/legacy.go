@@ -0,0 +1,171 @@
+package hash
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Legacy password hash algorithms, hashes are only verified and upgraded on sign-in
+const (
+	AlgSHA1         = "sha1"
+	AlgPBKDF2SHA1   = "pbkdf2-sha1"
+	AlgPBKDF2SHA256 = "pbkdf2-sha256"
+	AlgPBKDF2SHA512 = "pbkdf2-sha512"
+)
+
+var (
+	ErrVerifyOnly        = errors.New("hash: legacy algorithm can only verify passwords")
+	errInvalidSHA1Hash   = errors.New("hash: invalid sha1 hash")
+	errInvalidPBKDF2Hash = errors.New("hash: invalid pbkdf2 hash")
+)
+
+// Salted SHA-1 of SHA1Hasher, hex of salt followed by SHA-1 of password,
+// encoded as $sha1$hex
+type LegacySHA1 struct{}
+
+// Legacy SHA-1 constructor
+func NewLegacySHA1() *LegacySHA1 {
+	return &LegacySHA1{}
+}
+
+func (s *LegacySHA1) Name() string {
+	return AlgSHA1
+}
+
+func (s *LegacySHA1) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$sha1$")
+}
+
+func (s *LegacySHA1) Hash(password string) (string, error) {
+	return "", ErrVerifyOnly
+}
+
+func (s *LegacySHA1) Compare(encoded, password string) error {
+	raw, err := hex.DecodeString(strings.TrimPrefix(encoded, "$sha1$"))
+	if err != nil || len(raw) < sha1.Size {
+		return errInvalidSHA1Hash
+	}
+
+	sum := sha1.Sum([]byte(password))
+	if subtle.ConstantTimeCompare(raw[len(raw)-sha1.Size:], sum[:]) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (s *LegacySHA1) NeedsRehash(encoded string) bool {
+	return true
+}
+
+// PBKDF2 with HMAC digest, encoded as $pbkdf2-sha256$i=iterations$salt$key
+type PBKDF2 struct {
+	name   string
+	digest func() hash.Hash
+}
+
+// PBKDF2-HMAC-SHA1 constructor
+func NewPBKDF2SHA1() *PBKDF2 {
+	return &PBKDF2{name: AlgPBKDF2SHA1, digest: sha1.New}
+}
+
+// PBKDF2-HMAC-SHA256 constructor
+func NewPBKDF2SHA256() *PBKDF2 {
+	return &PBKDF2{name: AlgPBKDF2SHA256, digest: sha256.New}
+}
+
+// PBKDF2-HMAC-SHA512 constructor
+func NewPBKDF2SHA512() *PBKDF2 {
+	return &PBKDF2{name: AlgPBKDF2SHA512, digest: sha512.New}
+}
+
+func (p *PBKDF2) Name() string {
+	return p.name
+}
+
+func (p *PBKDF2) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$"+p.name+"$")
+}
+
+func (p *PBKDF2) Hash(password string) (string, error) {
+	return "", ErrVerifyOnly
+}
+
+func (p *PBKDF2) Compare(encoded, password string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[1] != p.name {
+		return errInvalidPBKDF2Hash
+	}
+
+	iterations, err := strconv.Atoi(strings.TrimPrefix(parts[2], "i="))
+	if err != nil || iterations < 1 {
+		return errInvalidPBKDF2Hash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return errInvalidPBKDF2Hash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(key) == 0 {
+		return errInvalidPBKDF2Hash
+	}
+
+	other := pbkdf2.Key([]byte(password), salt, iterations, len(key), p.digest)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (p *PBKDF2) NeedsRehash(encoded string) bool {
+	return true
+}
+
+// Encode password hash exported from another system with its algorithm tag,
+// sha1 hash is SHA1Hasher hex output, pbkdf2 key and salt are hex,
+// bcrypt and argon2id hashes are kept as is
+func EncodeForeign(algorithm, hashed, salt string, iterations int) (string, error) {
+	switch algorithm {
+	case AlgSHA1:
+		raw, err := hex.DecodeString(hashed)
+		if err != nil || len(raw) < sha1.Size {
+			return "", errInvalidSHA1Hash
+		}
+		return "$sha1$" + strings.ToLower(hashed), nil
+	case AlgPBKDF2SHA1, AlgPBKDF2SHA256, AlgPBKDF2SHA512:
+		key, err := hex.DecodeString(hashed)
+		if err != nil || len(key) == 0 {
+			return "", errInvalidPBKDF2Hash
+		}
+		saltBytes, err := hex.DecodeString(salt)
+		if err != nil || iterations < 1 {
+			return "", errInvalidPBKDF2Hash
+		}
+		return fmt.Sprintf("$%s$i=%d$%s$%s", algorithm, iterations,
+			base64.RawStdEncoding.EncodeToString(saltBytes),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	case AlgBcrypt:
+		if !NewBcrypt(0).Identify(hashed) {
+			return "", errors.New("hash: invalid bcrypt hash")
+		}
+		return hashed, nil
+	case AlgArgon2id:
+		if _, _, _, err := decodeArgon2id(hashed); err != nil {
+			return "", err
+		}
+		return hashed, nil
+	default:
+		return "", ErrUnknownAlgorithm
+	}
+}
@@ -3,8 +3,8 @@ package hash
 import (
 	"crypto/sha1"
 	"fmt"
-	"math/rand"
-	"time"
+
+	"github.com/Edbeer/Project/pkg/token"
 )
 
 // SHA1Hasher uses SHA1 to hash passwords with provided salt
@@ -29,12 +29,7 @@ func (h *SHA1Hasher) Hash(password string) string {
 }
 
 func salt() (string, error) {
-	b := make([]byte, 32)
-
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	_, err := r.Read(b)
+	b, err := token.Random(32)
 	if err != nil {
 		return "", err
 	}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
@@ -40,6 +41,26 @@ var (
 	InvalidJWTClaims      = errors.New("Invalid JWT claims")
 	NotAllowedImageHeader = errors.New("Not allowed image header")
 	NoCookie              = errors.New("not found cookie header")
+	EmailNotVerified      = errors.New("Email is not verified")
+	InvalidActionToken    = errors.New("Invalid or already used link")
+	InvalidMFACode        = errors.New("Invalid verification code")
+	MFAAlreadyEnabled     = errors.New("Two-factor authentication is already enabled")
+	MFANotEnabled         = errors.New("Two-factor authentication is not enabled")
+	InvalidChallenge      = errors.New("Invalid or expired challenge")
+	CredentialExists      = errors.New("Credential is already registered")
+	SessionRevoked        = errors.New("Session revoked, sign in again")
+	SessionNotFound       = errors.New("Session not found")
+	UserSuspended         = errors.New("User is suspended")
+	WrongPassword         = errors.New("Wrong current password")
+	AccountDeleted        = errors.New("Account is deleted, follow the link from the letter to restore it")
+	TooManyAttempts       = errors.New("Too many failed sign-in attempts, try again later")
+	AccountLocked         = errors.New("Account is temporarily locked after too many failed sign-in attempts")
+	RateLimitExceeded     = errors.New("Rate limit exceeded, try again later")
+	RateLimitUnavailable  = errors.New("Rate limit is unavailable")
+	InvalidFields         = errors.New("Invalid fields")
+	UnknownOAuthClient    = errors.New("Unknown OAuth client")
+	InvalidRedirectURI    = errors.New("Redirect URI is not registered for the client")
+	InvalidConsent        = errors.New("Invalid or expired consent request")
 )
 
 // Rest error interface
@@ -144,6 +165,70 @@ func NewInternalServerError(causes interface{}) RestErr {
 	return result
 }
 
+// Invalid field of request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Rest error with invalid fields
+type ValidationError struct {
+	RestError
+	Fields []FieldError `json:"fields"`
+}
+
+// New Validation Error
+func NewValidationError(fields []FieldError) RestErr {
+	return ValidationError{
+		RestError: RestError{
+			ErrStatus: http.StatusBadRequest,
+			ErrError:  InvalidFields.Error(),
+		},
+		Fields: fields,
+	}
+}
+
+// Rest error with delay before the request can be retried
+type RetryError struct {
+	RestError
+	RetryAfter time.Duration `json:"-"`
+}
+
+// New Too Many Requests Error
+func NewTooManyRequestsError(causes interface{}, retryAfter time.Duration) RestErr {
+	return RetryError{
+		RestError: RestError{
+			ErrStatus: http.StatusTooManyRequests,
+			ErrError:  TooManyAttempts.Error(),
+			ErrCauses: causes,
+		},
+		RetryAfter: retryAfter,
+	}
+}
+
+// New Locked Error
+func NewLockedError(causes interface{}, retryAfter time.Duration) RestErr {
+	return RetryError{
+		RestError: RestError{
+			ErrStatus: http.StatusLocked,
+			ErrError:  AccountLocked.Error(),
+			ErrCauses: causes,
+		},
+		RetryAfter: retryAfter,
+	}
+}
+
+// Retry-After seconds of error, zero if error can not be retried later
+func RetryAfter(err error) int {
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter <= 0 {
+		return 0
+	}
+	// round up so the client never retries too early
+	return int((retryErr.RetryAfter + time.Second - 1) / time.Second)
+}
+
 // Parser of error string messages returns RestError
 func ParseErrors(err error) RestErr {
 	switch {
@@ -159,6 +244,8 @@ func ParseErrors(err error) RestErr {
 		return NewRestError(http.StatusBadRequest, BadRequest.Error(), err)
 	case strings.Contains(err.Error(), "UUID"):
 		return NewRestError(http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, InvalidJWTClaims):
+		return NewRestError(http.StatusUnauthorized, Unauthorized.Error(), err)
 	case strings.Contains(strings.ToLower(err.Error()), "cookie"):
 		return NewRestError(http.StatusUnauthorized, Unauthorized.Error(), err)
 	case strings.Contains(strings.ToLower(err.Error()), "token"):
@@ -183,7 +270,7 @@ func parseSqlErrors(err error) RestErr {
 
 func parseValidatorError(err error) RestErr {
 	if strings.Contains(err.Error(), "Password") {
-		return NewRestError(http.StatusBadRequest, "Invalid password, min length 6", err)
+		return NewRestError(http.StatusBadRequest, "Invalid password", err)
 	}
 
 	if strings.Contains(err.Error(), "Email") {
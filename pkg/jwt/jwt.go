@@ -4,49 +4,191 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Edbeer/Project/config"
 	"github.com/Edbeer/Project/internal/entity"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
+// Access token lifetime
+const AccessTokenTTL = 15 * time.Minute
+
 // Manager
 type Manager struct {
-	signingKey string
+	mu   sync.RWMutex
+	ring *KeyRing
 }
 
-// JWT Manager constructor
+// JWT Manager constructor with HS256 signing key
 func NewManager(signingKey string) (*Manager, error) {
-	if signingKey == "" {
-		return nil, errors.New("empty signing key")
+	key, err := NewHMACKey("default", []byte(signingKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyManager(key)
+}
+
+// JWT Manager constructor with a single signing key
+func NewKeyManager(key *Key) (*Manager, error) {
+	if key == nil {
+		return nil, ErrEmptySigningKey
+	}
+	if key.ActivatesAt.IsZero() {
+		key.ActivatesAt = time.Now()
+	}
+
+	return NewRingManager(&KeyRing{Keys: []*Key{key}})
+}
+
+// JWT Manager constructor with key ring
+func NewRingManager(ring *KeyRing) (*Manager, error) {
+	m := &Manager{}
+	if err := m.SetKeyRing(ring); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// JWT Manager constructor from jwt config
+func NewManagerFromConfig(cfg *config.Config) (*Manager, error) {
+	if cfg.JWT.KeyRingFile != "" {
+		ring, err := LoadKeyRing(cfg.JWT.KeyRingFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewRingManager(ring)
+	}
+
+	key, err := ConfigKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyManager(key)
+}
+
+// Single signing key from jwt config
+func ConfigKey(cfg *config.Config) (*Key, error) {
+	switch cfg.JWT.Algorithm {
+	case "", AlgHS256:
+		keyID := cfg.JWT.KeyID
+		if keyID == "" {
+			keyID = "default"
+		}
+		return NewHMACKey(keyID, []byte(cfg.Server.JwtSecretKey))
+	default:
+		return LoadPrivateKey(cfg.JWT.KeyID, cfg.JWT.Algorithm, cfg.JWT.PrivateKeyFile)
+	}
+}
+
+// Replace key ring, the ring must have an active signing key
+func (m *Manager) SetKeyRing(ring *KeyRing) error {
+	if _, err := ring.Signing(time.Now()); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ring = ring
+	return nil
+}
+
+// Algorithm of the active signing key
+func (m *Manager) SigningAlg() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, err := m.ring.Signing(time.Now())
+	if err != nil {
+		return ""
+	}
+	return key.Alg()
+}
+
+// Sign claims with the active key and put its kid header
+func (m *Manager) sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key, err := m.ring.Signing(time.Now())
+	m.mu.RUnlock()
+	if err != nil {
+		return "", err
 	}
 
-	return &Manager{signingKey: signingKey}, nil
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
 }
 
-// JWT Claims struct
+// Select verification key by kid header, any non-retired key verifies
+// and the token algorithm must match the key
+func (m *Manager) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	m.mu.RLock()
+	key, ok := m.ring.Verification(kid, time.Now())
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != key.Alg() {
+		return nil, fmt.Errorf("unexpected signin method %v", t.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// Public non-retired keys in JWKS format, scheduled keys are
+// published before activation so verifiers can cache them
+func (m *Manager) JWKS() *JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, key := range m.ring.Keys {
+		if key.Retired(now) {
+			continue
+		}
+		if jwk, ok := key.JWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+// JWT Claims struct, jti claim identifies the token for revocation
 type Claims struct {
 	Email string `json:"email"`
 	ID string `json:"id"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// issue time in milliseconds, revocation watermarks are finer than iat
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.StandardClaims
 }
 
 // Generate JWT token
 func (m *Manager) GenerateJWTToken(user *entity.User) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		Email: user.Email,
 		ID: user.ID.String(),
+		Roles:       user.Roles,
+		Permissions: user.Permissions,
+		IssuedAtMs:  now.UnixMilli(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 15).Unix(),
+			Id:        uuid.New().String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
 	// Register the JWT string
-	tokenString, err := token.SignedString([]byte(m.signingKey))
+	tokenString, err := m.sign(claims)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
 	return tokenString, nil
@@ -58,13 +200,7 @@ func (m *Manager) Parse(accessToken string) (string, error) {
 		log.Fatal("invalid jwt token")
 	}
 
-	token, err := jwt.Parse(accessToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signin method %v", t.Header["alg"])
-		}
-		secret := []byte(m.signingKey)
-		return secret, nil
-	})
+	token, err := jwt.Parse(accessToken, m.Keyfunc)
 
 	if err != nil {
 		return "", err
@@ -78,16 +214,212 @@ func (m *Manager) Parse(accessToken string) (string, error) {
 	return claims["id"].(string), nil
 }
 
-func (m *Manager) NewRefreshToken() string {
-	b := make([]byte, 32)
+// Action token claims, the user id is in sub and not in the id
+// claim of access tokens, so a mailed link never authenticates requests
+type ActionClaims struct {
+	Action string `json:"action"`
+	Email  string `json:"email"`
+	jwt.StandardClaims
+}
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
+// Generate signed action token
+func (m *Manager) GenerateActionToken(token *entity.ActionToken) (string, error) {
+	claims := &ActionClaims{
+		Action: token.Action,
+		Email:  token.Email,
+		StandardClaims: jwt.StandardClaims{
+			Id:        token.ID,
+			Subject:   token.UserID.String(),
+			ExpiresAt: token.ExpiresAt.Unix(),
+		},
+	}
 
-	_, err := r.Read(b)
+	return m.sign(claims)
+}
+
+// Parse action token and check its action
+func (m *Manager) ParseActionToken(tokenString, action string) (*entity.ActionToken, error) {
+	claims := &ActionClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.Keyfunc)
 	if err != nil {
-		return ""
+		return nil, err
+	}
+
+	if claims.Action != action || claims.Id == "" {
+		return nil, errors.New("invalid action token")
 	}
 
-	return fmt.Sprintf("%x", b)
-}
\ No newline at end of file
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ActionToken{
+		ID:        claims.Id,
+		UserID:    userID,
+		Email:     claims.Email,
+		Action:    claims.Action,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// OAuth access token claims, tokens have no id claim
+// so first-party endpoints do not accept them
+type OAuthClaims struct {
+	ClientID   string `json:"client_id"`
+	Scope      string `json:"scope,omitempty"`
+	IssuedAtMs int64  `json:"iat_ms,omitempty"`
+	jwt.StandardClaims
+}
+
+// Generate access token of the grant for the oauth client
+func (m *Manager) GenerateOAuthToken(issuer string, grant *entity.OAuthGrant) (string, error) {
+	now := time.Now()
+	claims := &OAuthClaims{
+		ClientID:   grant.ClientID,
+		Scope:      strings.Join(grant.Scopes, " "),
+		IssuedAtMs: now.UnixMilli(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    issuer,
+			Subject:   grant.UserID.String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+	}
+
+	return m.sign(claims)
+}
+
+// Parse oauth access token of the issuer
+func (m *Manager) ParseOAuthToken(issuer, tokenString string) (*entity.OAuthGrant, error) {
+	claims := &OAuthClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ClientID == "" || !claims.VerifyIssuer(issuer, true) {
+		return nil, errors.New("invalid oauth access token")
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthGrant{
+		ClientID: claims.ClientID,
+		UserID:   userID,
+		Scopes:   strings.Fields(claims.Scope),
+	}, nil
+}
+
+// Service account access token claims, the principal
+// claim tells them from tokens of the users
+type ServiceClaims struct {
+	ClientID  string `json:"client_id"`
+	Scope     string `json:"scope,omitempty"`
+	Principal string `json:"principal"`
+	jwt.StandardClaims
+}
+
+// Generate access token of the service account with the granted scopes
+func (m *Manager) GenerateServiceToken(issuer string, account *entity.ServiceAccount, scopes []string) (string, error) {
+	now := time.Now()
+	claims := &ServiceClaims{
+		ClientID:  account.ClientID,
+		Scope:     strings.Join(scopes, " "),
+		Principal: entity.PrincipalService,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    issuer,
+			Subject:   account.ClientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+	}
+
+	return m.sign(claims)
+}
+
+// Claims of an access token of any principal. Action and ID tokens
+// are signed by the same keys, action tokens carry the action claim
+// and ID tokens have neither id nor client_id
+type accessClaims struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	Action     string `json:"action"`
+	ClientID   string `json:"client_id"`
+	Scope      string `json:"scope"`
+	Principal  string `json:"principal"`
+	IssuedAtMs int64  `json:"iat_ms"`
+	jwt.StandardClaims
+}
+
+// Introspect access token of a user, an oauth client or a service account
+func (m *Manager) IntrospectToken(tokenString string) (*entity.Introspection, error) {
+	claims := &accessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Action != "" || claims.ID == "" && claims.ClientID == "" {
+		return nil, errors.New("not an access token")
+	}
+
+	introspection := &entity.Introspection{
+		Active:        true,
+		Scope:         claims.Scope,
+		ClientID:      claims.ClientID,
+		Username:      claims.Email,
+		ExpiresAt:     claims.ExpiresAt,
+		IssuedAt:      claims.IssuedAt,
+		IssuedAtMs:    claims.IssuedAtMs,
+		Subject:       claims.Subject,
+		Issuer:        claims.Issuer,
+		TokenID:       claims.Id,
+		PrincipalType: entity.PrincipalUser,
+	}
+	if claims.ID != "" {
+		// first-party token of the user
+		introspection.Subject = claims.ID
+	}
+	if claims.Principal == entity.PrincipalService {
+		introspection.PrincipalType = entity.PrincipalService
+	}
+	return introspection, nil
+}
+
+// OpenID Connect ID token claims
+type IDClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.StandardClaims
+}
+
+// Generate ID token of the grant for the client with the user claims
+func (m *Manager) GenerateIDToken(issuer string, grant *entity.OAuthGrant, user *entity.UserInfo) (string, error) {
+	now := time.Now()
+	claims := &IDClaims{
+		Nonce:         grant.Nonce,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    issuer,
+			Subject:   user.Subject,
+			Audience:  grant.ClientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+	}
+	if !grant.AuthTime.IsZero() {
+		claims.AuthTime = grant.AuthTime.Unix()
+	}
+
+	return m.sign(claims)
+}
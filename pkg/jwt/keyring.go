@@ -0,0 +1,267 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNoSigningKey = errors.New("key ring has no active signing key")
+	ErrDuplicateKey = errors.New("key id already exists in key ring")
+)
+
+// Key ring with one active signing key and verify-only keys.
+// The active key is the latest activated key which is not retired,
+// every other non-retired key only verifies tokens.
+type KeyRing struct {
+	Keys []*Key
+}
+
+// Whether key signs at the moment
+func (k *Key) active(now time.Time) bool {
+	return !k.ActivatesAt.IsZero() && !k.ActivatesAt.After(now) && !k.Retired(now)
+}
+
+// Whether key is retired at the moment
+func (k *Key) Retired(now time.Time) bool {
+	return !k.RetiresAt.IsZero() && !k.RetiresAt.After(now)
+}
+
+// Active signing key
+func (r *KeyRing) Signing(now time.Time) (*Key, error) {
+	var signing *Key
+	for _, key := range r.Keys {
+		if key.active(now) && (signing == nil || key.ActivatesAt.After(signing.ActivatesAt)) {
+			signing = key
+		}
+	}
+	if signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return signing, nil
+}
+
+// Non-retired key by kid
+func (r *KeyRing) Verification(kid string, now time.Time) (*Key, bool) {
+	key, ok := r.Get(kid)
+	if !ok || key.Retired(now) {
+		return nil, false
+	}
+	return key, true
+}
+
+// Key by kid
+func (r *KeyRing) Get(kid string) (*Key, bool) {
+	for _, key := range r.Keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Add key to the ring
+func (r *KeyRing) Add(key *Key) error {
+	if _, ok := r.Get(key.ID); ok {
+		return ErrDuplicateKey
+	}
+	r.Keys = append(r.Keys, key)
+	return nil
+}
+
+// Make key the signing key from the moment, previous signing keys become
+// verify-only then and retire after grace so outstanding tokens keep working
+func (r *KeyRing) Promote(kid string, at time.Time, grace time.Duration) error {
+	key, ok := r.Get(kid)
+	if !ok {
+		return ErrUnknownKey
+	}
+	for _, other := range r.Keys {
+		if other != key && other.active(at) && other.RetiresAt.IsZero() {
+			other.RetiresAt = at.Add(grace)
+		}
+	}
+	key.ActivatesAt = at
+	key.RetiresAt = time.Time{}
+	return nil
+}
+
+// Retire key at the moment
+func (r *KeyRing) Retire(kid string, at time.Time) error {
+	key, ok := r.Get(kid)
+	if !ok {
+		return ErrUnknownKey
+	}
+	key.RetiresAt = at
+	return nil
+}
+
+// Drop keys retired before the moment
+func (r *KeyRing) Prune(now time.Time) {
+	keys := r.Keys[:0]
+	for _, key := range r.Keys {
+		if !key.Retired(now) {
+			keys = append(keys, key)
+		}
+	}
+	r.Keys = keys
+}
+
+// Generate new key, kid defaults to the key thumbprint
+func GenerateKey(id, alg string) (*Key, error) {
+	key := &Key{ID: id}
+
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if key.ID == "" {
+			kid := make([]byte, 8)
+			if _, err := rand.Read(kid); err != nil {
+				return nil, err
+			}
+			key.ID = hex.EncodeToString(kid)
+		}
+		return NewHMACKey(key.ID, secret)
+	case AlgRS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.private, key.public = signingMethods[alg], private, &private.PublicKey
+	case AlgES256:
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.private, key.public = signingMethods[alg], private, &private.PublicKey
+	case AlgEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.private, key.public = signingMethods[alg], private, public
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+
+	if key.ID == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		key.ID = thumbprint
+	}
+	return key, nil
+}
+
+// Key ring file entry
+type keyRingEntry struct {
+	ID          string     `json:"kid"`
+	Alg         string     `json:"alg"`
+	PrivateKey  string     `json:"private_key,omitempty"`
+	Secret      []byte     `json:"secret,omitempty"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	RetiresAt   *time.Time `json:"retires_at,omitempty"`
+}
+
+// Load key ring from JSON file
+func LoadKeyRing(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []keyRingEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{}
+	for _, entry := range entries {
+		var key *Key
+		if entry.Alg == AlgHS256 {
+			key, err = NewHMACKey(entry.ID, entry.Secret)
+		} else {
+			key, err = ParsePrivateKey(entry.ID, entry.Alg, []byte(entry.PrivateKey))
+		}
+		if err != nil {
+			return nil, err
+		}
+		key.ActivatesAt = entry.ActivatesAt
+		if entry.RetiresAt != nil {
+			key.RetiresAt = *entry.RetiresAt
+		}
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return ring, nil
+}
+
+// Save key ring to JSON file, the file is replaced atomically
+func (r *KeyRing) Save(path string) error {
+	entries := make([]keyRingEntry, 0, len(r.Keys))
+	for _, key := range r.Keys {
+		entry := keyRingEntry{
+			ID:          key.ID,
+			Alg:         key.Alg(),
+			ActivatesAt: key.ActivatesAt,
+		}
+		if !key.RetiresAt.IsZero() {
+			retiresAt := key.RetiresAt
+			entry.RetiresAt = &retiresAt
+		}
+		if secret, ok := key.private.([]byte); ok {
+			entry.Secret = secret
+		} else {
+			der, err := x509.MarshalPKCS8PrivateKey(key.private)
+			if err != nil {
+				return err
+			}
+			entry.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ActivatesAt.Before(entries[j].ActivatesAt)
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keyring-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
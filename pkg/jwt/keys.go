@@ -0,0 +1,218 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrUnsupportedAlg    = errors.New("unsupported signing algorithm")
+	ErrKeyAlgMismatch    = errors.New("private key does not match signing algorithm")
+	ErrWeakKey           = errors.New("rsa key must be at least 2048 bits")
+	ErrEmptySigningKey   = errors.New("empty signing key")
+	ErrEmptySigningKeyID = errors.New("empty signing key id")
+)
+
+var signingMethods = map[string]jwt.SigningMethod{
+	AlgHS256: jwt.SigningMethodHS256,
+	AlgRS256: jwt.SigningMethodRS256,
+	AlgES256: jwt.SigningMethodES256,
+	AlgEdDSA: jwt.SigningMethodEdDSA,
+}
+
+// Signing key with its id, zero ActivatesAt means the key
+// is not scheduled for signing, zero RetiresAt means it never retires
+type Key struct {
+	ID          string
+	Method      jwt.SigningMethod
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	private     interface{}
+	public      interface{}
+}
+
+// New HMAC key, it is never published in JWKS
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySigningKey
+	}
+	if id == "" {
+		return nil, ErrEmptySigningKeyID
+	}
+	return &Key{
+		ID:      id,
+		Method:  signingMethods[AlgHS256],
+		private: secret,
+		public:  secret,
+	}, nil
+}
+
+// Parse PEM encoded private key for RS256, ES256 or EdDSA,
+// empty id is replaced with the RFC 7638 thumbprint of the public key
+func ParsePrivateKey(id, alg string, data []byte) (*Key, error) {
+	key := &Key{ID: id}
+
+	switch alg {
+	case AlgRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if private.N.BitLen() < 2048 {
+			return nil, ErrWeakKey
+		}
+		key.Method, key.private, key.public = signingMethods[alg], private, &private.PublicKey
+	case AlgES256:
+		private, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if private.Curve != elliptic.P256() {
+			return nil, ErrKeyAlgMismatch
+		}
+		key.Method, key.private, key.public = signingMethods[alg], private, &private.PublicKey
+	case AlgEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		edKey := private.(ed25519.PrivateKey)
+		key.Method, key.private, key.public = signingMethods[alg], edKey, edKey.Public()
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+
+	if key.ID == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		key.ID = thumbprint
+	}
+	return key, nil
+}
+
+// Load PEM encoded private key from file
+func LoadPrivateKey(id, alg, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(id, alg, data)
+}
+
+// Algorithm name of the key
+func (k *Key) Alg() string {
+	return k.Method.Alg()
+}
+
+// JSON web key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSON web key set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Public JWK of the key, false for symmetric keys
+func (k *Key) JWK() (JWK, bool) {
+	jwk := JWK{
+		Use: "sig",
+		Kid: k.ID,
+		Alg: k.Alg(),
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeSegment(public.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = encodeSegment(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeSegment(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeSegment(public)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// RFC 7638 thumbprint of the public key
+func (k *Key) Thumbprint() (string, error) {
+	jwk, ok := k.JWK()
+	if !ok {
+		return "", ErrUnsupportedAlg
+	}
+
+	// required members only, in lexicographic order
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("marshal thumbprint members: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return encodeSegment(sum[:]), nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/Edbeer/Project/config"
+)
+
+// Mail message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mail sender interface
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTP mail sender
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// SMTP mail sender constructor
+func NewSMTPSender(cfg *config.Config) *SMTPSender {
+	var auth smtp.Auth
+	if cfg.Mail.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.Host)
+	}
+	return &SMTPSender{
+		addr: fmt.Sprintf("%s:%s", cfg.Mail.Host, cfg.Mail.Port),
+		from: cfg.Mail.From,
+		auth: auth,
+	}
+}
+
+// Send message via smtp
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(b.String()))
+}
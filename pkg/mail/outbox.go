@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// In-memory mail sender, keeps every sent message
+type Outbox struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// In-memory outbox constructor
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Save message in outbox
+func (o *Outbox) Send(ctx context.Context, msg *Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Get all sent messages
+func (o *Outbox) Messages() []*Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	messages := make([]*Message, len(o.messages))
+	copy(messages, o.messages)
+	return messages
+}
+
+// Get last message sent to address
+func (o *Outbox) Last(to string) *Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			return o.messages[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Response and grant types
+const (
+	ResponseTypeCode       = "code"
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// Only S256 code challenge method is accepted, plain exposes the verifier
+const MethodS256 = "S256"
+
+// Bearer token type of token responses
+const TokenTypeBearer = "Bearer"
+
+// Error codes of RFC 6749
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrInvalidScope            = "invalid_scope"
+	ErrAccessDenied            = "access_denied"
+	ErrServerError             = "server_error"
+)
+
+// Error code of token revocation, RFC 7009
+const ErrUnsupportedTokenType = "unsupported_token_type"
+
+// Token type hints of introspection and revocation
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// Error codes of protected resources, RFC 6750
+const (
+	ErrInvalidToken      = "invalid_token"
+	ErrInsufficientScope = "insufficient_scope"
+)
+
+// Protocol error, sent as json from the token endpoint
+// and as redirect query params from the authorization endpoint
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// New protocol error
+func NewError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return "oauth: " + e.Code
+	}
+	return "oauth: " + e.Code + ": " + e.Description
+}
+
+// Http status of the token endpoint or protected resource response
+func (e *Error) Status() int {
+	switch e.Code {
+	case ErrInvalidClient, ErrInvalidToken:
+		return http.StatusUnauthorized
+	case ErrInsufficientScope:
+		return http.StatusForbidden
+	case ErrServerError:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// Authorization endpoint error reported to the client by redirect
+type RedirectError struct {
+	Err *Error
+	URL string
+}
+
+func (e *RedirectError) Error() string {
+	return e.Err.Error()
+}
+
+// New error redirect to the client with state and issuer params
+func NewRedirectError(e *Error, redirectURI, state, issuer string) *RedirectError {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	if issuer != "" {
+		params.Set("iss", issuer)
+	}
+	return &RedirectError{Err: e, URL: RedirectURL(redirectURI, params)}
+}
+
+// S256 code challenge of the verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Check code verifier against the challenge of the authorization request
+func VerifyChallenge(challenge, method, verifier string) bool {
+	if method != MethodS256 || !ValidVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(Challenge(verifier)), []byte(challenge)) == 1
+}
+
+// Code verifier is 43 to 128 unreserved characters
+func ValidVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, r := range verifier {
+		if !unreserved(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// S256 challenge is base64url of 32 bytes without padding
+func ValidChallenge(challenge string) bool {
+	if len(challenge) != 43 {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil
+}
+
+func unreserved(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '-' || r == '.' || r == '_' || r == '~'
+}
+
+// Split space delimited scope, duplicates are dropped
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, s := range strings.Fields(scope) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// Join scopes into space delimited scope
+func JoinScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Check that every requested scope is allowed
+func ScopeAllowed(requested, allowed []string) bool {
+	for _, s := range requested {
+		if !HasScope(allowed, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check that scopes contain the scope
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Match redirect uri against registered ones by exact string comparison.
+// Loopback redirect uris of native apps match on any port, RFC 8252 section 7.3
+func MatchRedirectURI(registered []string, redirectURI string) bool {
+	for _, r := range registered {
+		if r == redirectURI || loopbackMatch(r, redirectURI) {
+			return true
+		}
+	}
+	return false
+}
+
+func loopbackMatch(registered, redirectURI string) bool {
+	r, err := url.Parse(registered)
+	if err != nil || r.Scheme != "http" || !loopback(r.Hostname()) {
+		return false
+	}
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme != "http" || u.Hostname() != r.Hostname() {
+		return false
+	}
+	return u.Path == r.Path && u.RawQuery == r.RawQuery && u.Fragment == "" && u.User == nil
+}
+
+func loopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Check that redirect uri can be registered, an absolute uri without fragment,
+// private-use schemes of native apps have no host
+func ValidRedirectURI(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil || !u.IsAbs() || u.Fragment != "" {
+		return false
+	}
+	if (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+		return false
+	}
+	return !strings.ContainsAny(redirectURI, " \t\n")
+}
+
+// Append params to the query of redirect uri
+func RedirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, v := range values {
+			query.Add(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,200 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Edbeer/Project/config"
+)
+
+// Policy violation codes
+const (
+	ViolationTooShort         = "too_short"
+	ViolationTooLong          = "too_long"
+	ViolationMissingLowercase = "missing_lowercase"
+	ViolationMissingUppercase = "missing_uppercase"
+	ViolationMissingDigit     = "missing_digit"
+	ViolationMissingSymbol    = "missing_symbol"
+	ViolationContainsEmail    = "contains_email"
+	ViolationContainsName     = "contains_name"
+	ViolationDisallowed       = "disallowed"
+	ViolationBreached         = "breached"
+)
+
+// Parts of email and name shorter than this are not looked for in the password
+const minIdentityLength = 3
+
+// Broken policy rule
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Policy rules, zero values disable the rule
+type Rules struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	Disallowed    []string
+}
+
+// Password policy
+type Policy struct {
+	rules      Rules
+	disallowed map[string]struct{}
+	breached   *BreachedList
+}
+
+// Password policy constructor, breached list is optional
+func NewPolicy(rules Rules, breached *BreachedList) *Policy {
+	disallowed := make(map[string]struct{}, len(rules.Disallowed))
+	for _, password := range rules.Disallowed {
+		disallowed[strings.ToLower(password)] = struct{}{}
+	}
+	return &Policy{
+		rules:      rules,
+		disallowed: disallowed,
+		breached:   breached,
+	}
+}
+
+// Password policy constructor from password policy config
+func NewPolicyFromConfig(cfg *config.Config) (*Policy, error) {
+	rules := Rules{
+		MinLength:     cfg.PasswordPolicy.MinLength,
+		MaxLength:     cfg.PasswordPolicy.MaxLength,
+		RequireLower:  cfg.PasswordPolicy.RequireLower,
+		RequireUpper:  cfg.PasswordPolicy.RequireUpper,
+		RequireDigit:  cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol: cfg.PasswordPolicy.RequireSymbol,
+		Disallowed:    cfg.PasswordPolicy.Disallowed,
+	}
+	if cfg.PasswordPolicy.DisallowedFile != "" {
+		passwords, err := readLines(cfg.PasswordPolicy.DisallowedFile)
+		if err != nil {
+			return nil, err
+		}
+		rules.Disallowed = append(rules.Disallowed, passwords...)
+	}
+
+	var breached *BreachedList
+	if cfg.PasswordPolicy.BreachedDir != "" {
+		list, err := NewBreachedList(cfg.PasswordPolicy.BreachedDir)
+		if err != nil {
+			return nil, err
+		}
+		breached = list
+	}
+
+	return NewPolicy(rules, breached), nil
+}
+
+// Check password against the policy, identity is email and name of the user
+func (p *Policy) Check(password, email, name string) ([]Violation, error) {
+	var violations []Violation
+	add := func(code, message string) {
+		violations = append(violations, Violation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.rules.MinLength > 0 && length < p.rules.MinLength {
+		add(ViolationTooShort, fmt.Sprintf("Password must be at least %d characters long", p.rules.MinLength))
+	}
+	if p.rules.MaxLength > 0 && length > p.rules.MaxLength {
+		add(ViolationTooLong, fmt.Sprintf("Password must be at most %d characters long", p.rules.MaxLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.rules.RequireLower && !lower {
+		add(ViolationMissingLowercase, "Password must contain a lowercase letter")
+	}
+	if p.rules.RequireUpper && !upper {
+		add(ViolationMissingUppercase, "Password must contain an uppercase letter")
+	}
+	if p.rules.RequireDigit && !digit {
+		add(ViolationMissingDigit, "Password must contain a digit")
+	}
+	if p.rules.RequireSymbol && !symbol {
+		add(ViolationMissingSymbol, "Password must contain a symbol")
+	}
+
+	lowerPassword := strings.ToLower(password)
+	if containsAny(lowerPassword, emailParts(email)) {
+		add(ViolationContainsEmail, "Password must not contain your email")
+	}
+	if containsAny(lowerPassword, strings.Fields(strings.ToLower(name))) {
+		add(ViolationContainsName, "Password must not contain your name")
+	}
+
+	if _, ok := p.disallowed[lowerPassword]; ok {
+		add(ViolationDisallowed, "Password is too common")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			add(ViolationBreached, "Password has appeared in a data breach, choose another one")
+		}
+	}
+
+	return violations, nil
+}
+
+// Whole email and its local part
+func emailParts(email string) []string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return nil
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return []string{email, local}
+}
+
+func containsAny(password string, parts []string) bool {
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= minIdentityLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Non-empty lines of file
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"crypto/rand"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"strings"
+)
+
+// Token type, part of the token prefix
+type Type string
+
+// Types of minted tokens
+const (
+	RefreshToken  Type = "rt"
+	PasswordReset Type = "pr"
+	MFAChallenge  Type = "mc"
+	OAuthCode     Type = "ac"
+	OAuthConsent  Type = "oc"
+	OAuthRefresh  Type = "or"
+	ClientSecret  Type = "cs"
+)
+
+const (
+	// Prefix of every token, lets secret scanners recognize leaked tokens
+	Prefix = "prj"
+	// Random bytes in a token
+	entropyBytes = 32
+	// Base62 length of the random part and of the checksum
+	entropyLength  = 43
+	checksumLength = 6
+)
+
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrMalformed       = errors.New("malformed token")
+	ErrInvalidChecksum = errors.New("invalid token checksum")
+)
+
+// New token of the type like prj_rt_<random><checksum>, the random part
+// is 32 bytes from crypto/rand, the checksum is CRC32 of everything before it
+func New(t Type) (string, error) {
+	b, err := Random(entropyBytes)
+	if err != nil {
+		return "", err
+	}
+	body := Prefix + "_" + string(t) + "_" + encode(new(big.Int).SetBytes(b), entropyLength)
+	return body + checksum(body), nil
+}
+
+// Parse token and verify its checksum, returns the token type
+func Parse(token string) (Type, error) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != Prefix || parts[1] == "" {
+		return "", ErrMalformed
+	}
+	if len(parts[2]) != entropyLength+checksumLength || strings.Trim(parts[2], alphabet) != "" {
+		return "", ErrMalformed
+	}
+
+	body := token[:len(token)-checksumLength]
+	if checksum(body) != token[len(body):] {
+		return "", ErrInvalidChecksum
+	}
+	return Type(parts[1]), nil
+}
+
+// Random bytes from crypto/rand
+func Random(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func checksum(body string) string {
+	sum := crc32.ChecksumIEEE([]byte(body))
+	return encode(new(big.Int).SetUint64(uint64(sum)), checksumLength)
+}
+
+// Base62 encoding left padded with zeros to the length
+func encode(n *big.Int, length int) string {
+	b := make([]byte, length)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		b[i] = alphabet[mod.Int64()]
+	}
+	return string(b)
+}
@@ -0,0 +1,76 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken_New(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Format", func(t *testing.T) {
+		token, err := New(RefreshToken)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, "prj_rt_"))
+		require.Len(t, token, len("prj_rt_")+entropyLength+checksumLength)
+		require.Equal(t, "", strings.Trim(token[len("prj_rt_"):], alphabet))
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		first, err := New(PasswordReset)
+		require.NoError(t, err)
+		second, err := New(PasswordReset)
+		require.NoError(t, err)
+		require.NotEqual(t, first, second)
+	})
+}
+
+func TestToken_Parse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, kind := range []Type{RefreshToken, PasswordReset, MFAChallenge, OAuthCode, OAuthConsent, OAuthRefresh, ClientSecret} {
+			token, err := New(kind)
+			require.NoError(t, err)
+
+			parsed, err := Parse(token)
+			require.NoError(t, err)
+			require.Equal(t, kind, parsed)
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		token, err := New(OAuthRefresh)
+		require.NoError(t, err)
+
+		// a single changed character of the random part is caught
+		i := len("prj_or_")
+		replacement := "0"
+		if token[i] == '0' {
+			replacement = "1"
+		}
+		_, err = Parse(token[:i] + replacement + token[i+1:])
+		require.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		token, err := New(RefreshToken)
+		require.NoError(t, err)
+
+		for _, malformed := range []string{
+			"",
+			"refresh token",
+			"prj_rt",
+			"xyz" + token[len(Prefix):],
+			"prj__" + token[len("prj_rt_"):],
+			token[:len(token)-1],
+			token + "0",
+			token[:len(token)-1] + "-",
+		} {
+			_, err := Parse(malformed)
+			require.ErrorIs(t, err, ErrMalformed, malformed)
+		}
+	})
+}
@@ -2,10 +2,12 @@ package utils
 
 import (
 	"context"
+	"net"
 	"net/http"
 
 	"github.com/Edbeer/Project/config"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 )
 
 // Get request id from echo context
@@ -16,14 +18,60 @@ func GetRequestID(c echo.Context) string {
 // ReqIDCtxKey is a key used for the Request ID from context
 type ReqIDCtxKey struct{}
 
-// Get user IP address
+// Get request id from context
+func GetRequestIDFromCtx(ctx context.Context) string {
+	requestID, _ := ctx.Value(ReqIDCtxKey{}).(string)
+	return requestID
+}
+
+// Get user IP address without port, forwarded address is used only behind trusted proxies
 func GetIP(c echo.Context) string {
-	return c.Request().RemoteAddr
+	return c.RealIP()
+}
+
+// IP extractor trusting X-Forwarded-For only from configured proxy ranges
+func NewIPExtractor(cfg *config.Config) (echo.IPExtractor, error) {
+	if len(cfg.Server.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range cfg.Server.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "trusted proxy %q", cidr)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
 }
 
-// Get context  with request id
+// ClientCtxKey is a key used for the client of the request from context
+type ClientCtxKey struct{}
+
+// Client of the request
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Get client of the request from context
+func GetClientFromCtx(ctx context.Context) Client {
+	client, _ := ctx.Value(ClientCtxKey{}).(Client)
+	return client
+}
+
+// Get context with request id and client, the client IP comes from
+// the trusted proxy extractor of the server and has no port
 func GetRequestCtx(c echo.Context) context.Context {
-	return context.WithValue(c.Request().Context(), ReqIDCtxKey{}, GetRequestID(c))
+	ctx := context.WithValue(c.Request().Context(), ReqIDCtxKey{}, GetRequestID(c))
+	return context.WithValue(ctx, ClientCtxKey{}, Client{
+		IP:        GetIP(c),
+		UserAgent: c.Request().UserAgent(),
+	})
 }
 
 // Read request body and validate
@@ -0,0 +1,104 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Authenticator data flags
+const (
+	FlagUserPresent        byte = 0x01
+	FlagUserVerified       byte = 0x04
+	FlagAttestedCredential byte = 0x40
+	FlagExtensionData      byte = 0x80
+)
+
+var ErrInvalidAuthData = errors.New("webauthn: invalid authenticator data")
+
+// Parsed authenticator data
+type AuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// Check authenticator data flag
+func (a *AuthenticatorData) Has(flag byte) bool {
+	return a.Flags&flag == flag
+}
+
+// Parse authenticator data with optional attested credential data
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+
+	authData := &AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if authData.Has(FlagAttestedCredential) {
+		if len(rest) < 18 {
+			return nil, ErrInvalidAuthData
+		}
+		authData.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, ErrInvalidAuthData
+		}
+		authData.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		authData.PublicKey = rest[:n]
+		rest = rest[n:]
+	}
+
+	if authData.Has(FlagExtensionData) {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrInvalidAuthData
+	}
+	return authData, nil
+}
+
+// Parse attestation object, returns attestation format and authenticator data.
+// Attestation statements are not verified, the relying party requests "none" conveyance.
+func ParseAttestationObject(data []byte) (string, *AuthenticatorData, error) {
+	v, _, err := decodeCBOR(data)
+	if err != nil {
+		return "", nil, err
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return "", nil, ErrInvalidCBOR
+	}
+
+	format, _ := m["fmt"].(string)
+	rawAuthData, ok := m["authData"].([]byte)
+	if !ok || format == "" {
+		return "", nil, ErrInvalidCBOR
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return "", nil, err
+	}
+	return format, authData, nil
+}
@@ -0,0 +1,215 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Ceremony types from client data
+const (
+	TypeCreate = "webauthn.create"
+	TypeGet    = "webauthn.get"
+)
+
+// User verification requirements
+const (
+	VerificationRequired  = "required"
+	VerificationPreferred = "preferred"
+)
+
+var (
+	ErrInvalidClientData = errors.New("webauthn: invalid client data")
+	ErrInvalidOrigin     = errors.New("webauthn: origin is not allowed")
+	ErrInvalidRPID       = errors.New("webauthn: relying party id mismatch")
+	ErrUserNotPresent    = errors.New("webauthn: user presence is required")
+	ErrUserNotVerified   = errors.New("webauthn: user verification is required")
+)
+
+// Relying party
+type RelyingParty struct {
+	ID               string
+	Name             string
+	Origins          []string
+	UserVerification string
+	Timeout          int
+}
+
+// Client data collected by the browser
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// Parse client data JSON
+func ParseClientData(raw []byte) (*ClientData, error) {
+	clientData := &ClientData{}
+	if err := json.Unmarshal(raw, clientData); err != nil {
+		return nil, ErrInvalidClientData
+	}
+	if clientData.Challenge == "" {
+		return nil, ErrInvalidClientData
+	}
+	return clientData, nil
+}
+
+// Verify ceremony type and origin of client data
+func (rp *RelyingParty) VerifyClientData(clientData *ClientData, ceremony string) error {
+	if clientData.Type != ceremony || clientData.CrossOrigin {
+		return ErrInvalidClientData
+	}
+	for _, origin := range rp.Origins {
+		if strings.EqualFold(origin, clientData.Origin) {
+			return nil
+		}
+	}
+	return ErrInvalidOrigin
+}
+
+// Verify relying party id hash and user flags of authenticator data
+func (rp *RelyingParty) VerifyAuthenticatorData(authData *AuthenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return ErrInvalidRPID
+	}
+	if !authData.Has(FlagUserPresent) {
+		return ErrUserNotPresent
+	}
+	if rp.UserVerification == VerificationRequired && !authData.Has(FlagUserVerified) {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// Relying party entity
+type RPEntity struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// User entity
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// Public key credential parameters
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// Public key credential descriptor
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Authenticator selection criteria
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// Options for navigator.credentials.create
+type CreationOptions struct {
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              string                 `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// Options for navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// New registration options
+func (rp *RelyingParty) CreationOptions(challenge string, user UserEntity, exclude []CredentialDescriptor) *CreationOptions {
+	return &CreationOptions{
+		RP:        RPEntity{ID: rp.ID, Name: rp.Name},
+		User:      user,
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.Timeout * 1000,
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// New login options
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.Timeout * 1000,
+		RPID:             rp.ID,
+		AllowCredentials: allow,
+		UserVerification: rp.UserVerification,
+	}
+}
+
+// Attestation response of navigator.credentials.create, binary fields are base64url encoded
+type AttestationResponse struct {
+	ID       string `json:"id" validate:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" validate:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AttestationObject string `json:"attestationObject" validate:"required"`
+	} `json:"response"`
+}
+
+// Assertion response of navigator.credentials.get, binary fields are base64url encoded
+type AssertionResponse struct {
+	ID       string `json:"id" validate:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" validate:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AuthenticatorData string `json:"authenticatorData" validate:"required"`
+		Signature         string `json:"signature" validate:"required"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// New random challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b), nil
+}
+
+// Base64url encoding without padding
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode base64url with or without padding
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMP;
+
+UPDATE users SET verified_at = created_at;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_backup_codes CASCADE;
+DROP TABLE IF EXISTS user_totp CASCADE;
@@ -0,0 +1,18 @@
+CREATE TABLE user_totp
+(
+    user_id      UUID PRIMARY KEY            REFERENCES users (user_id) ON DELETE CASCADE,
+    secret       VARCHAR(64)                 NOT NULL CHECK ( secret <> '' ),
+    confirmed_at TIMESTAMP,
+    created_at   TIMESTAMP                   NOT NULL DEFAULT now()
+);
+
+CREATE TABLE user_backup_codes
+(
+    code_id      UUID PRIMARY KEY            DEFAULT uuid_generate_v4(),
+    user_id      UUID                        NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    code_hash    VARCHAR(64)                 NOT NULL,
+    used_at      TIMESTAMP,
+    created_at   TIMESTAMP                   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_backup_codes_user_id_idx ON user_backup_codes (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE webauthn_credentials
+(
+    credential_id BYTEA PRIMARY KEY,
+    user_id       UUID                       NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    public_key    BYTEA                      NOT NULL,
+    sign_count    BIGINT                     NOT NULL DEFAULT 0,
+    aaguid        BYTEA,
+    name          VARCHAR(64)                NOT NULL DEFAULT '',
+    created_at    TIMESTAMP                  NOT NULL DEFAULT now(),
+    last_used_at  TIMESTAMP
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
@@ -0,0 +1,43 @@
+CREATE TABLE roles
+(
+    role_id      SERIAL PRIMARY KEY,
+    name         VARCHAR(32)                 NOT NULL UNIQUE CHECK ( name <> '' ),
+    description  VARCHAR(250)                NOT NULL DEFAULT '',
+    created_at   TIMESTAMP                   NOT NULL DEFAULT now()
+);
+
+CREATE TABLE permissions
+(
+    permission_id SERIAL PRIMARY KEY,
+    name          VARCHAR(64)                NOT NULL UNIQUE CHECK ( name <> '' ),
+    description   VARCHAR(250)               NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions
+(
+    role_id       INT                        NOT NULL REFERENCES roles (role_id) ON DELETE CASCADE,
+    permission_id INT                        NOT NULL REFERENCES permissions (permission_id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles
+(
+    user_id      UUID                        NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    role_id      INT                         NOT NULL REFERENCES roles (role_id) ON DELETE CASCADE,
+    created_at   TIMESTAMP                   NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO roles (name, description)
+VALUES ('admin', 'Manages users');
+
+INSERT INTO permissions (name, description)
+VALUES ('users:read', 'Read user accounts'),
+       ('users:write', 'Modify user accounts');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r, permissions p
+WHERE r.name = 'admin';
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+
+CREATE INDEX users_created_at_idx ON users (created_at);
@@ -0,0 +1,39 @@
+CREATE TABLE audit_log
+(
+    seq          BIGINT PRIMARY KEY CHECK ( seq > 0 ),
+    event        VARCHAR(64)                 NOT NULL CHECK ( event <> '' ),
+    user_id      UUID,
+    ip           VARCHAR(64)                 NOT NULL DEFAULT '',
+    user_agent   TEXT                        NOT NULL DEFAULT '',
+    request_id   VARCHAR(64)                 NOT NULL DEFAULT '',
+    outcome      VARCHAR(16)                 NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE    NOT NULL,
+    prev_hash    CHAR(64)                    NOT NULL,
+    hash         CHAR(64)                    NOT NULL
+);
+
+CREATE INDEX audit_log_user_id_idx ON audit_log (user_id, seq);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO permissions (name, description)
+VALUES ('audit:read', 'Read security audit log');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'audit:read';
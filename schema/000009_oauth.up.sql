@@ -0,0 +1,9 @@
+CREATE TABLE oauth_clients
+(
+    client_id     VARCHAR(64) PRIMARY KEY CHECK ( client_id <> '' ),
+    secret_hash   CHAR(64),
+    name          VARCHAR(64)                NOT NULL CHECK ( name <> '' ),
+    redirect_uris TEXT                       NOT NULL CHECK ( redirect_uris <> '' ),
+    scopes        TEXT                       NOT NULL DEFAULT '',
+    created_at    TIMESTAMP                  NOT NULL DEFAULT now()
+);
@@ -0,0 +1,11 @@
+CREATE TABLE service_accounts
+(
+    client_id   VARCHAR(64) PRIMARY KEY CHECK ( client_id <> '' ),
+    secret_hash CHAR(64)                   NOT NULL,
+    name        VARCHAR(64)                NOT NULL CHECK ( name <> '' ),
+    owner_id    UUID                       NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    scopes      TEXT                       NOT NULL DEFAULT '',
+    created_at  TIMESTAMP                  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX service_accounts_owner_id_idx ON service_accounts (owner_id);
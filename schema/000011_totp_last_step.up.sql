@@ -0,0 +1 @@
+ALTER TABLE user_totp ADD COLUMN last_step BIGINT NOT NULL DEFAULT 0;